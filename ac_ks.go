@@ -1,24 +1,68 @@
 package ahocorasick
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 type MatchedHandler func(id uint, from, to uint64) error
-type matchedPattern func(pos uint64, ps *Pattern) error
+
+// PatternHandler receives the matched pattern itself rather than its ID, so
+// match-dense callers can read flags or content without a lookup. The pattern
+// is shared with the matcher and must not be modified.
+type PatternHandler func(p *Pattern, from, to uint64) error
+
+// DataHandler receives the value passed to ScanWithData along with each
+// match, letting one shared handler serve many callers without building a
+// closure per call.
+type DataHandler func(data any, id uint, from, to uint64) error
+type matchedPattern func(from, to uint64, ps *Pattern) error
 
 type Flag uint
 
 const (
-	Caseless Flag = 1 << iota // Caseless represents set case-insensitive matching.
-	SingleMatch
+	Caseless    Flag = 1 << iota // Caseless represents set case-insensitive matching.
+	SingleMatch                  // SingleMatch reports only the first match of the pattern ID.
+	// LineAnchoredStart matches only at the start of the input or right
+	// after a '\n'.
+	LineAnchoredStart
+	// LineAnchoredEnd matches only at the end of the input or right before
+	// a '\n' or '\r'.
+	LineAnchoredEnd
+	// ExpandEncodings also matches the URL-encoded, HTML-escaped and
+	// UTF-16LE forms of the pattern, reported under its ID.
+	ExpandEncodings
+	// WordBoundary matches only a whole word: the bytes just before and
+	// after the match, if any, are not word bytes [A-Za-z0-9_].
+	WordBoundary
 )
 
 type Pattern struct {
 	Content []byte
 	ID      uint // ID
 	Flags   Flag // Caseless represents set case-insensitive matching.
+	// Source optionally records where the pattern came from, such as
+	// "rules/web.txt:12" or a rule name. It is carried into Match results.
+	Source string
+	// Severity ranks the pattern for ScanBySeverity; higher is more severe.
+	Severity int
+	// SampleRate reports only one in every SampleRate hits of the pattern
+	// per scan or stream; 0 and 1 report every hit. See WithRandomSampling.
+	SampleRate uint32
+	// Categories tags the pattern with the topic lists it belongs to, for
+	// ScanCategories and Classify.
+	Categories []string
+	// Classes lets positions of Content match any byte of a set; see
+	// CharClass and ParseClassPattern.
+	Classes []CharClass
 	strlen  int
+	plen    int // length of the prefix stored in the automaton
+	slot    int // dense index of the pattern ID
+	cats    []int
+	sets    []*ByteSet // the class at each offset, nil without classes
 }
 
 // ACKS represents the Aho-Corasick Ken Steele matcher
@@ -28,199 +72,677 @@ type ACKS struct {
 	alphabetSize   int
 
 	// stateTable is a flattened 2D array: stateTable[state * alphabetSize + char]
-	stateTable []int32
+	// It only holds the first denseStates states; the rest use sparse rows.
+	stateTable  []int32
+	denseStates int
+
+	// Sparse rows: the goto transitions of state s >= denseStates are
+	// sparseChars/sparseNext[sparseIndex[s-denseStates]:sparseIndex[s-denseStates+1]].
+	sparseIndex []int32
+	sparseChars []uint8
+	sparseNext  []int32
+	failure     []int32
+	depth       []int32 // number of codes consumed to reach each state
 
 	// outputTable stores pattern IDs for each state.
 	// Using a slice of slices for O(1) access by state index.
 	outputTable    [][]int
 	stateHasOutput []bool // Fast check to avoid slice header access
+	// minRemain is the fewest bytes that must follow each state before a
+	// match can complete, see gate.go.
+	minRemain []int32
+	// costs is the share of every pattern in the tables, see cost.go.
+	costs          []PatternCost
 	size           int
 	maxID          uint
+	maxPatternLen  int
 	stateCount     int
 	hasSingleMatch bool
+	hasCaseless    bool
+	hasClasses     bool
+	hasAnchors     bool // some pattern has a line anchor or WordBoundary
+	hasSampling    bool // some pattern has a SampleRate above one
+	sampleRandom   bool
+	acc            *Accumulator
+
+	// foldCase merges A-Z into a-z for every letter, so case-sensitive
+	// candidates need verification. Otherwise splitCase marks the letters
+	// whose cases have distinct codes, and the automaton is exact.
+	foldCase   bool
+	splitCase  [256]bool
+	exactCase  bool
+	unusedCode bool // code 0 is reserved for bytes used by no pattern
+
+	// byteClass numbers the class of each byte given to WithByteClasses,
+	// 0 for none. classMerged is set when a class shares one code between
+	// bytes used by patterns, so every candidate needs verification.
+	byteClass   [256]uint8
+	classMerged bool
+
+	// startBytes marks the bytes with a transition out of the root.
+	startBytes [256]bool
+	startByte  byte // the only start byte, when startCount is 1
+	startCount int
+
+	maxDenseStates int
+	nibble         bool
+	normalizers    []Normalizer
+
+	prefixLen int
+	// ids lists the distinct pattern IDs by slot; idOrder lists the slots
+	// sorted by ID.
+	ids     []uint
+	idOrder []int32
+	// categories lists the distinct pattern categories in order of first
+	// appearance; categorySets holds the IDs tagged with each.
+	categories   []string
+	categorySets []*MatchSet
+	// muted is a bitset over ID slots of patterns silenced by Mute.
+	muted []atomic.Uint64
+	// literals holds the patterns of small sets, when complete inputs are
+	// searched for them directly.
+	literals []literal
+	// rareBytes are the anchor bytes of the rare-byte prefilter, and
+	// rareBefore and rareAfter the extent of the window around each.
+	rareBytes             []byte
+	rareBefore, rareAfter int
+	byteFreq              *[256]float64
+	rules                 []*Rule
+	captures              []captureSpec
+	recordDelim           []byte
+	transforms            []PatternTransform
+	// autoIDs maps the IDs in use to the key of the AddPatternAuto pattern
+	// holding them, once AddPatternAuto is used.
+	autoIDs map[uint]string
+	// image is the mapped database the tables alias, if any.
+	image []byte
+	// meta is set by WithMetadata or loaded with a database.
+	meta          *Metadata
+	extractors    []extractor
+	extractWindow int
+	// scanCache and memoCache hold the results of ScanCached and ScanMemo,
+	// created on first use unless set up by options.
+	scanCache *resultCache
+	memoCache *resultCache
+	cacheOnce sync.Once
+	// packets recycles the scan state of ScanPackets. gen counts the calls
+	// to Build and AddRule, which invalidate the recycled state.
+	packets sync.Pool
+	gen     uint64
+	// Online rule conditions, see rulewindow.go and ruledistance.go.
+	windows   []*windowSpec
+	distances []*distanceSpec
+
+	dedupWindow uint64
+	quickReject bool
+	grams       *gramFilter
+
+	minPatternLen int
+	shortPolicy   ShortPatternPolicy
+	warnings      []Warning
+}
+
+// Option configures an ACKS matcher.
+type Option func(*ACKS)
+
+// WithDenseStates limits the dense state table to the first k states in
+// breadth-first order. Deeper states are stored as sparse (char, next) pairs
+// searched with binary search, which cuts memory for large dictionaries at
+// the cost of slower transitions in deep states. k <= 0 keeps every row dense.
+func WithDenseStates(k int) Option {
+	return func(ac *ACKS) {
+		ac.maxDenseStates = k
+	}
+}
+
+// WithNibbleAlphabet makes the automaton consume every byte as two 4-bit
+// nibbles. Rows shrink to 16 entries while the state depth doubles, which
+// keeps the table small for binary signatures that use most byte values and
+// defeat alphabet compression.
+func WithNibbleAlphabet() Option {
+	return func(ac *ACKS) {
+		ac.nibble = true
+	}
+}
+
+// WithoutCaseFolding always gives both cases of the letters used by
+// case-sensitive patterns distinct codes. By default Build falls back to
+// merging all letters when spelling the Caseless patterns in both cases would
+// take more than 65536 trie states; with this option it never does, the
+// automaton is always exact, and Build fails instead. In nibble mode, where letters are never split,
+// it disables folding when no pattern is Caseless.
+func WithoutCaseFolding() Option {
+	return func(ac *ACKS) {
+		ac.exactCase = true
+	}
 }
 
-func NewACKS() *ACKS {
-	return &ACKS{
+func NewACKS(opts ...Option) *ACKS {
+	ac := &ACKS{
 		outputTable: make([][]int, 0),
 	}
+	for _, opt := range opts {
+		opt(ac)
+	}
+	return ac
 }
 
+// AddPattern adds p to the matcher, which must be built afterwards. Patterns
+// are independent: the same Content may be added several times with
+// different flags or IDs, and each copy reports its matches under its own
+// flags. Matches ending at the same offset are reported longest pattern
+// first, and copies of the same content in the order they were added.
+// Content and text are arbitrary bytes: NUL and bytes above 0x7F match like
+// any other, and Caseless only folds the ASCII letters.
 func (ac *ACKS) AddPattern(p Pattern) error {
+	if len(ac.transforms) == 0 && p.Flags&ExpandEncodings == 0 {
+		return ac.addPattern(p)
+	}
+	ps, err := ac.transform(p)
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if p.Flags&ExpandEncodings != 0 {
+			if err := ac.addEncodings(p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ac.addPattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ac *ACKS) addPattern(p Pattern) error {
+	if len(ac.normalizers) > 0 {
+		n := len(p.Content)
+		p.Content = ac.normalizePattern(p.Content)
+		if len(p.Classes) > 0 && len(p.Content) != n {
+			return fmt.Errorf("ahocorasick: pattern %d: normalizers moved its class offsets", p.ID)
+		}
+	}
+	if err := prepareClasses(&p); err != nil {
+		return err
+	}
 	p.strlen = len(p.Content)
 	newP := p
 	ac.patterns = append(ac.patterns, &newP)
+	if ac.autoIDs != nil {
+		ac.claimID(p.ID, "")
+	}
 
 	if p.Flags&SingleMatch > 0 {
 		ac.hasSingleMatch = true
 	}
+	if p.Flags&Caseless > 0 {
+		ac.hasCaseless = true
+	}
+	if p.sets != nil {
+		ac.hasClasses = true
+	}
+	if p.Flags&anchorFlags != 0 {
+		ac.hasAnchors = true
+	}
+	if p.SampleRate > 1 {
+		ac.hasSampling = true
+	}
 	ac.size = len(ac.patterns)
 	if p.ID > ac.maxID {
 		ac.maxID = p.ID
 	}
+	if p.strlen > ac.maxPatternLen {
+		ac.maxPatternLen = p.strlen
+	}
 	return nil
 }
 
-func (ac *ACKS) Build() {
-	ac.initTranslateTable()
+// Build compiles the patterns added so far. The automaton is always built,
+// since streams need its state; sets of up to four patterns are in addition
+// searched for directly in complete inputs, as Engine reports.
+func (ac *ACKS) Build() error {
+	ac.gen++
+	ac.clearCaches()
+	if err := ac.checkPolicy(); err != nil {
+		return err
+	}
+	if err := ac.stampMetadata(); err != nil {
+		return err
+	}
+	if ac.nibble && ac.hasClasses {
+		return errors.New("ahocorasick: character classes are not supported in nibble mode")
+	}
+	for _, p := range ac.patterns {
+		p.plen = p.strlen
+		if ac.prefixLen > 0 && p.plen > ac.prefixLen {
+			p.plen = ac.prefixLen
+		}
+	}
+	var oldIDs []uint
+	if ac.acc != nil {
+		oldIDs = append(oldIDs, ac.ids...)
+	}
+	ac.buildIDIndex()
+	if ac.acc != nil {
+		ac.acc.remap(oldIDs)
+	}
+	ac.buildCategories()
+	ac.muted = make([]atomic.Uint64, (len(ac.ids)+63)/64)
+	if err := ac.initTranslateTable(); err != nil {
+		return err
+	}
+	ac.checkAlphabet()
 	ac.buildStateMachine()
+	ac.buildGramFilter()
+	ac.buildLiterals()
+	ac.buildRareBytes()
+	return ac.checkDeadColumn()
 }
 
-func (ac *ACKS) initTranslateTable() {
-	var counts [256]int
+// initTranslateTable assigns a dense code to every byte used by a pattern.
+// Code 0 is shared by all bytes that appear in no pattern, so it never labels
+// a goto transition: reading such a byte always returns to the root.
+func (ac *ACKS) initTranslateTable() error {
+	if ac.nibble {
+		// Every byte is consumed as two 4-bit codes, no translation needed.
+		ac.foldCase = !ac.exactCase || ac.hasCaseless
+		ac.alphabetSize = 16
+		return nil
+	}
 
-	// 1. Count occurrences, merging uppercase to lowercase to compress alphabet
+	// 1. Give both cases of a letter their own code when a case-sensitive
+	// pattern uses it, so wrong-case text never reaches a candidate state.
+	// Caseless patterns are then expanded into both cases of those letters,
+	// unless that would take too many trie branches.
+	ac.splitCase = [256]bool{}
 	for _, p := range ac.patterns {
-		for _, b := range p.Content {
-			counts[toLower(b)]++
+		if p.Flags&Caseless != 0 {
+			continue
 		}
+		for i, b := range p.Content {
+			if set := p.classAt(i); set != nil {
+				for l := byte('a'); l <= 'z'; l++ {
+					if set.Has(l) || set.Has(l-32) {
+						ac.splitCase[l], ac.splitCase[l-32] = true, true
+					}
+				}
+				continue
+			}
+			if l := toLower(b); l >= 'a' && l <= 'z' {
+				ac.splitCase[l], ac.splitCase[l-32] = true, true
+			}
+		}
+	}
+	ac.foldCase = ac.caseStates() > maxCaseStates
+	if ac.foldCase && ac.exactCase {
+		return fmt.Errorf("ahocorasick: spelling Caseless patterns in both cases needs over %d states", maxCaseStates)
+	}
+	if ac.foldCase {
+		ac.splitCase = [256]bool{}
 	}
 
-	// 2. Build translation table
+	// 2. Count occurrences, merging uppercase to lowercase where the cases
+	// share a code. Class bytes count as written, since Caseless classes
+	// already hold both cases.
+	var counts [256]int
+	for _, p := range ac.patterns {
+		for i, b := range p.Content {
+			if set := p.classAt(i); set != nil {
+				for _, c := range set.appendBytes(nil) {
+					if l := toLower(c); !ac.splitCase[l] {
+						counts[l]++
+					} else {
+						counts[c]++
+					}
+				}
+				continue
+			}
+			l := toLower(b)
+			switch {
+			case !ac.splitCase[l]:
+				counts[l]++
+			case p.Flags&Caseless != 0:
+				counts[l]++
+				counts[l-32]++
+			default:
+				counts[b]++
+			}
+		}
+	}
+	used := 0
+	for i := 0; i < 256; i++ {
+		if counts[i] > 0 {
+			used++
+		}
+	}
+
+	// 3. Build translation table, giving the used bytes of a byte class
+	// one shared code
 	ac.alphabetSize = 1 // 0 is reserved for unused chars
+	ac.unusedCode = used < 256
+	if !ac.unusedCode {
+		// Every byte value is in use, so no code needs reserving.
+		ac.alphabetSize = 0
+	}
+	ac.classMerged = false
+	var classCode [256]int // code+1 of each byte class
 	for i := 0; i < 256; i++ {
-		// Skip uppercase, they will be mapped to lowercase indices later
-		if i >= 'A' && i <= 'Z' {
+		// Skip merged uppercase, they will be mapped to lowercase indices later
+		if i >= 'A' && i <= 'Z' && !ac.splitCase[i] {
 			continue
 		}
 
-		if counts[i] > 0 {
+		switch c := ac.byteClass[i]; {
+		case counts[i] == 0:
+			ac.translateTable[i] = 0
+		case c != 0 && classCode[c] != 0:
+			ac.translateTable[i] = uint8(classCode[c] - 1)
+			ac.classMerged = true
+		default:
 			ac.translateTable[i] = uint8(ac.alphabetSize)
+			if c != 0 {
+				classCode[c] = ac.alphabetSize + 1
+			}
 			ac.alphabetSize++
-		} else {
-			ac.translateTable[i] = 0
 		}
 	}
 
-	// 3. Map uppercase to the same index as lowercase
+	// 4. Map merged uppercase to the same index as lowercase
 	for i := 'A'; i <= 'Z'; i++ {
-		ac.translateTable[i] = ac.translateTable[i+32]
+		if !ac.splitCase[i] {
+			ac.translateTable[i] = ac.translateTable[i+32]
+		}
 	}
+	return nil
 }
 
-func (ac *ACKS) buildStateMachine() {
-	// Temporary Trie structure
-	trie := make(map[int]map[uint8]int)
-	ac.stateCount = 1 // State 0 is root
-
-	// Initialize output table for state 0
-	ac.outputTable = make([][]int, 0)
-	ac.outputTable = append(ac.outputTable, []int{})
+// maxCaseStates bounds the number of extra trie states Caseless patterns
+// may expand into before the alphabet falls back to merging all letters.
+const maxCaseStates = 1 << 16
 
-	// 1. Build Trie (Goto)
-	for k, p := range ac.patterns {
-		currentState := 0
-		for _, b := range p.Content {
-			// Use the compressed character code
-			tc := ac.translateTable[toLower(b)]
-
-			if trie[currentState] == nil {
-				trie[currentState] = make(map[uint8]int)
+// caseStates returns an upper bound on the extra trie states needed to
+// spell every Caseless pattern in both cases of its split letters: each
+// byte adds one state per variant of the prefix it ends, beyond the one of
+// a single spelling. It stops counting past maxCaseStates.
+func (ac *ACKS) caseStates() int {
+	total := 0
+	for _, p := range ac.patterns {
+		if p.Flags&Caseless == 0 {
+			continue
+		}
+		n := 1
+		for i, b := range p.Content[:p.plen] {
+			if p.classAt(i) == nil && ac.splitCase[toLower(b)] {
+				n *= 2
 			}
-
-			if next, exists := trie[currentState][tc]; exists {
-				currentState = next
-			} else {
-				newState := ac.stateCount
-				ac.stateCount++
-				trie[currentState][tc] = newState
-				// Expand output table
-				ac.outputTable = append(ac.outputTable, []int{})
-				currentState = newState
+			total += n - 1
+			if total > maxCaseStates {
+				return total
 			}
 		}
-		ac.outputTable[currentState] = append(ac.outputTable[currentState], k)
 	}
+	return total
+}
 
-	// 2. Build Failure Table
-	failure := make([]int, ac.stateCount)
-	queue := []int{}
-
-	// Depth 1 failure links point to root (0)
-	if rootTrans, ok := trie[0]; ok {
-		for _, nextState := range rootTrans {
-			queue = append(queue, nextState)
-			failure[nextState] = 0
-		}
+// fold returns the byte the automaton uses for b: its lowercase form unless
+// case folding is disabled.
+func (ac *ACKS) fold(b byte) byte {
+	if ac.foldCase {
+		return toLower(b)
 	}
+	return b
+}
 
-	// BFS
-	for len(queue) > 0 {
-		rState := queue[0]
-		queue = queue[1:]
+func (ac *ACKS) buildStateMachine() {
+	t := newTrie(ac.size + 1)
 
-		if transitions, ok := trie[rState]; ok {
-			for charCode, nextState := range transitions {
-				queue = append(queue, nextState)
-				fState := failure[rState]
+	// Initialize output table for state 0
+	outputs := make([][]int, 0)
+	outputs = append(outputs, []int{})
+	// owner is the pattern that created each state, for PatternCosts.
+	owner := []int32{-1}
 
-				for {
-					if trans, ok := trie[fState]; ok {
-						if val, ok := trans[charCode]; ok {
-							failure[nextState] = val
-							break
-						}
+	// 1. Build Trie (Goto)
+	var symbols []uint8
+	var frontier, branches []int
+	for k, p := range ac.patterns {
+		if p.Flags&Caseless != 0 && !ac.nibble && !ac.foldCase || p.sets != nil {
+			// Spell the pattern in both cases of every split letter, and
+			// with every byte of its classes.
+			frontier = append(frontier[:0], 0)
+			for i, b := range p.Content[:p.plen] {
+				branches = branches[:0]
+				symbols = symbols[:0]
+				switch set := p.classAt(i); {
+				case set != nil:
+					symbols = ac.classCodes(symbols, set)
+				case p.Flags&Caseless != 0 && !ac.foldCase:
+					l := toLower(b)
+					symbols = append(symbols, ac.translateTable[l])
+					if ac.splitCase[l] {
+						symbols = append(symbols, ac.translateTable[l-32])
 					}
-					if fState == 0 {
-						failure[nextState] = 0
-						break
+				default:
+					symbols = append(symbols, ac.translateTable[b])
+				}
+				for _, s := range frontier {
+					for _, c := range symbols {
+						next, created := t.add(s, c)
+						if created {
+							outputs = append(outputs, []int{})
+							owner = append(owner, int32(k))
+						}
+						branches = append(branches, next)
 					}
-					fState = failure[fState]
 				}
-				// Merge outputs
-				ac.outputTable[nextState] = append(ac.outputTable[nextState], ac.outputTable[failure[nextState]]...)
+				frontier, branches = branches, frontier
+			}
+			for _, s := range frontier {
+				outputs[s] = append(outputs[s], k)
 			}
+			continue
+		}
+		currentState := 0
+		symbols = ac.appendSymbols(symbols[:0], p.Content[:p.plen])
+		for _, tc := range symbols {
+			next, created := t.add(currentState, tc)
+			if created {
+				// Expand output table
+				outputs = append(outputs, []int{})
+				owner = append(owner, int32(k))
+			}
+			currentState = next
 		}
+		outputs[currentState] = append(outputs[currentState], k)
 	}
+	stateCount := t.len()
 
-	// 3. Build Delta Table (State Table)
-	ac.stateTable = make([]int32, ac.stateCount*ac.alphabetSize)
+	// 2. Build Failure Table, visiting states in BFS order.
+	// Children are visited in character order so the numbering is deterministic.
+	failure := make([]int, stateCount)
+	depth := make([]int32, stateCount)
+	order := make([]int, 0, stateCount)
+	order = append(order, 0)
 
-	for state := 0; state < ac.stateCount; state++ {
-		for charIdx := 0; charIdx < ac.alphabetSize; charIdx++ {
-			nextState := 0
-			curr := state
-			found := false
+	for head := 0; head < len(order); head++ {
+		rState := order[head]
+		for c := t.first[rState]; c >= 0; c = t.next[c] {
+			nextState, charCode := int(c), t.code[c]
+			order = append(order, nextState)
+			depth[nextState] = depth[rState] + 1
+			if rState == 0 {
+				// Depth 1 failure links point to root (0)
+				failure[nextState] = 0
+				continue
+			}
+			fState := failure[rState]
 
-			// Find transition
 			for {
-				if trans, ok := trie[curr]; ok {
-					if val, ok := trans[uint8(charIdx)]; ok {
-						nextState = val
-						found = true
-						break
-					}
+				if val := t.child(fState, charCode); val >= 0 {
+					failure[nextState] = val
+					break
 				}
-				if curr == 0 {
+				if fState == 0 {
+					failure[nextState] = 0
 					break
 				}
-				curr = failure[curr]
+				fState = failure[fState]
 			}
+			// Merge outputs
+			outputs[nextState] = append(outputs[nextState], outputs[failure[nextState]]...)
+		}
+	}
 
-			if !found {
-				nextState = 0
-			}
+	// 3. Renumber states in BFS order, so shallow states get the low indices
+	// and a failure link always points to a smaller index.
+	renum := make([]int32, stateCount)
+	for newState, oldState := range order {
+		renum[oldState] = int32(newState)
+	}
+	ac.stateCount = stateCount
+	ac.outputTable = make([][]int, stateCount)
+	ac.failure = make([]int32, stateCount)
+	ac.depth = make([]int32, stateCount)
+	for newState, oldState := range order {
+		ac.outputTable[newState] = outputs[oldState]
+		ac.failure[newState] = renum[failure[oldState]]
+		ac.depth[newState] = depth[oldState]
+	}
 
-			ac.stateTable[state*ac.alphabetSize+charIdx] = int32(nextState)
+	ac.denseStates = stateCount
+	if ac.maxDenseStates > 0 && ac.maxDenseStates < stateCount {
+		ac.denseStates = ac.maxDenseStates
+	}
+
+	// 4. Build Delta Table (State Table) for the dense states.
+	// In BFS order the failure row is always complete before it is needed.
+	ac.stateTable = make([]int32, ac.denseStates*ac.alphabetSize)
+	for state := 0; state < ac.denseStates; state++ {
+		row := ac.stateTable[state*ac.alphabetSize : (state+1)*ac.alphabetSize]
+		if state != 0 {
+			copy(row, ac.stateTable[int(ac.failure[state])*ac.alphabetSize:])
+		}
+		for c := t.first[order[state]]; c >= 0; c = t.next[c] {
+			row[t.code[c]] = renum[c]
 		}
 	}
 
-	// 4. Build fast output check table
+	// 5. Build sparse rows for the remaining states: only the goto transitions
+	// are kept, sorted by character code, and misses follow the failure link.
+	ac.sparseIndex = nil
+	ac.sparseChars = nil
+	ac.sparseNext = nil
+	if ac.denseStates < stateCount {
+		ac.sparseIndex = make([]int32, 0, stateCount-ac.denseStates+1)
+		for state := ac.denseStates; state < stateCount; state++ {
+			ac.sparseIndex = append(ac.sparseIndex, int32(len(ac.sparseChars)))
+			for c := t.first[order[state]]; c >= 0; c = t.next[c] {
+				ac.sparseChars = append(ac.sparseChars, t.code[c])
+				ac.sparseNext = append(ac.sparseNext, renum[c])
+			}
+		}
+		ac.sparseIndex = append(ac.sparseIndex, int32(len(ac.sparseChars)))
+	}
+
+	// 6. Find the bytes that leave the root, for skipping ahead while idle
+	ac.buildStartBytes()
+
+	// 7. Build fast output check table
 	ac.stateHasOutput = make([]bool, ac.stateCount)
 	for i, out := range ac.outputTable {
 		if len(out) > 0 {
 			ac.stateHasOutput[i] = true
 		}
 	}
+
+	// 8. Record how many bytes each state needs to reach an output
+	ac.buildMinRemain()
+
+	// 9. Charge every state to the pattern that created it
+	newOwner := make([]int32, stateCount)
+	for oldState, k := range owner {
+		newOwner[renum[oldState]] = k
+	}
+	ac.buildCosts(newOwner)
+}
+
+// buildStartBytes records which bytes can start a match. While the automaton
+// sits at the root, every other byte loops back to the root, so the scan can
+// jump straight to the next start byte.
+func (ac *ACKS) buildStartBytes() {
+	ac.startBytes = [256]bool{}
+	ac.startCount = 0
+	if ac.nibble || ac.stateCount == 1 {
+		return
+	}
+	for b := 0; b < 256; b++ {
+		if ac.stateTable[ac.translateTable[b]] != 0 {
+			ac.startBytes[b] = true
+			ac.startByte = byte(b)
+			ac.startCount++
+		}
+	}
+}
+
+// checkDeadColumn verifies that the unused-byte code 0 leads back to the root
+// from every state, so collapsing unseen bytes can never produce a candidate.
+func (ac *ACKS) checkDeadColumn() error {
+	if ac.nibble || !ac.unusedCode {
+		return nil
+	}
+	for state := 0; state < ac.denseStates; state++ {
+		if ac.stateTable[state*ac.alphabetSize] != 0 {
+			return fmt.Errorf("ahocorasick: state %d has a transition on the unused byte code", state)
+		}
+	}
+	for _, c := range ac.sparseChars {
+		if c == 0 {
+			return fmt.Errorf("ahocorasick: sparse state has a transition on the unused byte code")
+		}
+	}
+	return nil
+}
+
+// appendSymbols appends the character codes the automaton consumes for content.
+func (ac *ACKS) appendSymbols(dst []uint8, content []byte) []uint8 {
+	for _, b := range content {
+		if ac.nibble {
+			lb := ac.fold(b)
+			dst = append(dst, lb>>4, lb&0x0f)
+			continue
+		}
+		// Use the compressed character code
+		dst = append(dst, ac.translateTable[b])
+	}
+	return dst
+}
+
+// next returns the state reached from state on character code tc.
+func (ac *ACKS) next(state int, tc uint8) int {
+	for state >= ac.denseStates {
+		row := state - ac.denseStates
+		chars := ac.sparseChars[ac.sparseIndex[row]:ac.sparseIndex[row+1]]
+		j := sort.Search(len(chars), func(k int) bool { return chars[k] >= tc })
+		if j < len(chars) && chars[j] == tc {
+			return int(ac.sparseNext[int(ac.sparseIndex[row])+j])
+		}
+		state = int(ac.failure[state])
+	}
+	return int(ac.stateTable[state*ac.alphabetSize+int(tc)])
 }
 
 func (ac *ACKS) Search(text []byte) ([]uint, error) {
+	if len(ac.patterns) == 0 {
+		return []uint{}, nil
+	}
 	matches := make([]uint, 0, ac.size)
-	h := func(pos uint64, ps *Pattern) error {
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
 		matches = append(matches, ps.ID)
 		return nil
-	}
-	err := ac.searchPatterns(text, h)
+	}}
+	err := ac.searchPatterns(text, &h)
 	if err != nil {
 		return nil, err
 	}
@@ -228,87 +750,371 @@ func (ac *ACKS) Search(text []byte) ([]uint, error) {
 }
 
 func (ac *ACKS) Scan(text []byte, m MatchedHandler) error {
-	h := func(pos uint64, ps *Pattern) error {
-		if m == nil {
-			return nil
-		}
-		err := m(ps.ID, 0, pos)
-		if err != nil {
-			return err
-		}
-		return nil
-	}
-	err := ac.searchPatterns(text, h)
+	h := handler{scan: m}
+	err := ac.searchPatterns(text, &h)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (ac *ACKS) searchPatterns(text []byte, matched matchedPattern) error {
-	currentState := 0
-	const maxSliceSize = 16 * 1024 * 1024
-	useSlice := ac.maxID <= maxSliceSize
-	var recordSlice []uint64
-	var recordMap map[uint]struct{}
-	if ac.hasSingleMatch {
-		if useSlice {
-			recordSlice = make([]uint64, (ac.maxID/64)+1)
-		} else {
-			recordMap = make(map[uint]struct{})
+// ScanPatterns scans text and calls h with a pointer to each matched pattern
+// and the start and end offsets of the match.
+func (ac *ACKS) ScanPatterns(text []byte, h PatternHandler) error {
+	return ac.searchPatterns(text, &handler{patterns: h})
+}
+
+// ScanWithData scans text and calls h with data and each match. Passing a
+// pointer as data does not allocate.
+func (ac *ACKS) ScanWithData(text []byte, data any, h DataHandler) error {
+	return ac.searchPatterns(text, &handler{data: h, userData: data})
+}
+
+// handler delivers matches to whichever callback form the caller used. The
+// public handler types are called directly rather than through a closure
+// built for every scan.
+type handler struct {
+	scan     MatchedHandler
+	patterns PatternHandler
+	data     DataHandler
+	fn       matchedPattern
+	stream   StreamHandler
+	result   *ScanResult
+	userData any
+	streamID uint64
+
+	// offsets maps normalized positions back into the original text. base
+	// and normBase are the offsets of the current buffer in each.
+	offsets        []int
+	base, normBase uint64
+}
+
+func (h *handler) report(from, to uint64, ps *Pattern) error {
+	if h.offsets != nil {
+		// A match starting in an earlier buffer is clamped to this one.
+		start := 0
+		if from > h.normBase {
+			start = h.offsets[from-h.normBase]
 		}
+		from = h.base + uint64(start)
+		to = h.base + uint64(h.offsets[to-h.normBase])
+	}
+	switch {
+	case h.scan != nil:
+		return h.scan(ps.ID, 0, to)
+	case h.patterns != nil:
+		return h.patterns(ps, from, to)
+	case h.data != nil:
+		return h.data(h.userData, ps.ID, from, to)
+	case h.fn != nil:
+		return h.fn(from, to, ps)
+	case h.stream != nil:
+		return h.stream(h.streamID, ps.ID, from, to)
+	case h.result != nil:
+		h.result.add(ps)
+	}
+	return nil
+}
+
+func (ac *ACKS) searchPatterns(text []byte, h *handler) error {
+	if len(ac.patterns) == 0 {
+		return nil
+	}
+	ss := scanState{record: ac.newMatchRecord()}
+	return ac.searchWith(&ss, text, h)
+}
+
+// searchWith scans text as a complete input, reusing the scratch in ss.
+func (ac *ACKS) searchWith(ss *scanState, text []byte, h *handler) error {
+	if len(ac.patterns) == 0 {
+		return nil
+	}
+	if len(ac.normalizers) > 0 {
+		text, h.offsets = ac.normalize(text)
+	}
+	if !ss.observe && !ac.MayMatch(text) {
+		return nil
+	}
+	if len(ac.literals) > 0 && !ss.observe {
+		return ac.searchLiterals(ss, text, h)
 	}
-	for i, b := range text {
-		tc := ac.translateTable[b]
+	if len(ac.rareBytes) > 0 && !ss.observe {
+		return ac.searchRare(ss, text, h)
+	}
+	return ac.searchText(ss, text, h)
+}
+
+// scanState is the automaton position carried between consecutive buffers.
+type scanState struct {
+	state int
+	// base is the offset of the current buffer within the whole input.
+	base uint64
+	// history holds the bytes preceding the current buffer, for verifying
+	// case-sensitive patterns that started in an earlier buffer.
+	history []byte
+	record  matchRecord
+
+	// observe enables per-byte bookkeeping: the deepest state visited in
+	// maxDepth and, when profile is set, visit counts.
+	observe  bool
+	maxDepth int32
+	profile  *Profile
+	matches  uint64
+
+	// stream marks buffers that may be followed by more input, so long
+	// pattern tails that run past the buffer end are kept in pending.
+	stream  bool
+	pending []pendingTail
+
+	// rules collects hits for rule evaluation, if any rules are evaluated.
+	rules *ruleState
+
+	// probe marks a query that sees every match, without SingleMatch,
+	// dedup, sampling or hit counting.
+	probe bool
+	// raw makes a probe report muted IDs too, for Verify.
+	raw bool
+	// leadIn and limit bound the matches of ScanRange, which reads context
+	// on both sides: matches ending at or before leadIn, or after a
+	// non-zero limit, are dropped before any other step.
+	leadIn, limit uint64
+
+	// lastEnd holds, per ID slot, one past the end of the last match
+	// reported, for WithDedupWindow.
+	lastEnd []uint64
+
+	// hits counts the hits of each ID slot for SampleRate, and rng drives
+	// random sampling.
+	hits []uint32
+	rng  uint64
+}
+
+func (ac *ACKS) searchText(ss *scanState, text []byte, h *handler) error {
+	if len(ss.pending) > 0 {
+		if err := ac.resumePending(ss, text, h); err != nil {
+			return err
+		}
+	}
+	currentState := ss.state
+	// Profiling must see every byte, so it disables skipping at the root.
+	skip := ac.startCount > 0 && ss.profile == nil
+	// Near the end of a complete input, stop as soon as too few bytes are
+	// left for any pattern to complete.
+	gateFrom := len(text)
+	if !ss.stream && !ss.observe {
+		gateFrom = len(text) - int(ac.minRemain[0]) + 1
+	}
+	for i := 0; i < len(text); i++ {
+		if i >= gateFrom && len(text)-i < int(ac.minRemain[currentState]) {
+			break
+		}
+		if currentState == 0 && skip {
+			// Stuck at the root: skip bytes that cannot start a match.
+			if ac.startCount == 1 {
+				j := indexByte(text[i:], ac.startByte)
+				if j < 0 {
+					break
+				}
+				i += j
+			} else {
+				for i < len(text) && !ac.startBytes[text[i]] {
+					i++
+				}
+				if i == len(text) {
+					break
+				}
+			}
+		}
+
+		b := text[i]
+		if ac.nibble {
+			// Outputs are only checked on byte boundaries, so a pattern can
+			// never be reported at an odd nibble offset.
+			lb := ac.fold(b)
+			currentState = ac.next(currentState, lb>>4)
+			currentState = ac.next(currentState, lb&0x0f)
+		} else {
+			tc := ac.translateTable[b]
 
-		// O(1) transition
-		idx := currentState*ac.alphabetSize + int(tc)
-		currentState = int(ac.stateTable[idx])
+			// O(1) transition for dense rows, binary search for sparse rows
+			if currentState < ac.denseStates {
+				idx := currentState*ac.alphabetSize + int(tc)
+				currentState = int(ac.stateTable[idx])
+			} else {
+				currentState = ac.next(currentState, tc)
+			}
+		}
+
+		if ss.observe {
+			ac.observe(ss, b, currentState)
+		}
 
 		// Check outputs
 		if ac.stateHasOutput[currentState] {
 			for _, id := range ac.outputTable[currentState] {
 				pat := ac.patterns[id]
-				if pat.Flags&SingleMatch > 0 {
-					if useSlice {
-						idx := pat.ID / 64
-						mask := uint64(1) << (pat.ID % 64)
-						if recordSlice[idx]&mask != 0 {
-							continue
-						}
-						recordSlice[idx] |= mask
-					} else {
-						if _, exists := recordMap[pat.ID]; exists {
-							continue
-						}
-						recordMap[pat.ID] = struct{}{}
+				// Without case folding or byte classes the automaton is exact and
+				// needs no verification.
+				if ac.inexact(pat) && !verify(pat, text, i, ss.history) {
+					continue
+				}
+				if pat.Flags&anchorFlags != 0 && !ss.atStart(pat, text, i+1-pat.plen) {
+					continue
+				}
+				end := i + 1
+				if pat.plen < pat.strlen {
+					// Only a prefix is in the automaton, check the tail.
+					if !ac.verifyTail(ss, pat, text, end) {
+						continue
 					}
+					end += pat.strlen - pat.plen
 				}
-
-				if pat.Flags&Caseless > 0 {
-					err := matched(uint64(i+1), pat)
-					if err != nil {
-						return err
+				if pat.Flags&(LineAnchoredEnd|WordBoundary) != 0 {
+					// At the end of a stream chunk, the next byte decides.
+					ok, wait := ss.atEnd(pat, text, end)
+					if wait {
+						ss.pending = append(ss.pending, pendingTail{pat: pat, done: pat.strlen - pat.plen, end: ss.base + uint64(end)})
 					}
-				} else {
-					if memcmp(pat.Content, text[i-pat.strlen+1:], pat.strlen) {
-						err := matched(uint64(i+1), pat)
-						if err != nil {
-							return err
-						}
+					if !ok {
+						continue
 					}
 				}
+				if err := ac.emit(ss, h, pat, ss.base+uint64(end)); err != nil {
+					ss.state = currentState
+					return err
+				}
 			}
 		}
 	}
+	ss.state = currentState
 	return nil
 }
 
-func memcmp(a, b []byte, l int) bool {
-	if l > len(b) || l > len(a) {
+// emit reports a verified match of pat ending at the absolute offset to.
+func (ac *ACKS) emit(ss *scanState, h *handler, pat *Pattern, to uint64) error {
+	if to <= ss.leadIn || ss.limit > 0 && to > ss.limit {
+		return nil
+	}
+	if ss.probe {
+		if !ss.raw && ac.mutedSlot(pat.slot) {
+			return nil
+		}
+		return h.report(to-uint64(pat.strlen), to, pat)
+	}
+	if ac.acc != nil {
+		ac.acc.counts[pat.slot].Add(1)
+	}
+	if ac.mutedSlot(pat.slot) {
+		return nil
+	}
+	from := to - uint64(pat.strlen)
+	if ss.rules != nil {
+		// Rules see every hit, like the counters of an Accumulator.
+		ss.rules.observe(pat, from, to)
+	}
+	if pat.Flags&SingleMatch > 0 && ss.record.seen(pat.ID) {
+		return nil
+	}
+	if ac.dedupWindow > 0 && ss.suppress(pat.slot, to, ac) {
+		return nil
+	}
+	if pat.SampleRate > 1 && ss.sampleOut(pat, ac) {
+		return nil
+	}
+	ss.matches++
+	return h.report(from, to, pat)
+}
+
+// observe records the per-byte bookkeeping enabled by ss.observe.
+func (ac *ACKS) observe(ss *scanState, b byte, state int) {
+	if ac.depth[state] > ss.maxDepth {
+		ss.maxDepth = ac.depth[state]
+	}
+	if p := ss.profile; p != nil {
+		p.Bytes++
+		p.ByteCounts[b]++
+		p.StateVisits[state]++
+	}
+}
+
+// reset prepares ss for scanning an unrelated input.
+func (ss *scanState) reset() {
+	ss.state = 0
+	ss.base = 0
+	ss.history = ss.history[:0]
+	ss.record.reset()
+	ss.maxDepth = 0
+	ss.matches = 0
+	ss.pending = ss.pending[:0]
+	ss.leadIn, ss.limit = 0, 0
+	clear(ss.lastEnd)
+	clear(ss.hits)
+	if ss.rules != nil {
+		ss.rules.reset()
+	}
+}
+
+// verify checks the exact bytes of a case-sensitive pattern ending at text[i],
+// reading the part that precedes text from history.
+func verify(pat *Pattern, text []byte, i int, history []byte) bool {
+	start := i - pat.plen + 1
+	if start >= 0 {
+		return equalPattern(pat, 0, text[start:i+1])
+	}
+	head := -start
+	if head > len(history) {
 		return false
 	}
-	return bytes.Equal(a[:l], b[:l])
+	return equalPattern(pat, 0, history[len(history)-head:]) &&
+		equalPattern(pat, head, text[:i+1])
+}
+
+// inexact reports whether the automaton can reach pat's output state on
+// text that does not match it, so candidates must be verified.
+func (ac *ACKS) inexact(pat *Pattern) bool {
+	return ac.classMerged || ac.foldCase && pat.Flags&Caseless == 0
+}
+
+// matchRecord remembers which SingleMatch IDs were already reported.
+type matchRecord struct {
+	bits []uint64
+	ids  map[uint]struct{}
+}
+
+func (ac *ACKS) newMatchRecord() matchRecord {
+	const maxSliceSize = 16 * 1024 * 1024
+	var r matchRecord
+	if ac.hasSingleMatch {
+		if ac.maxID <= maxSliceSize {
+			r.bits = make([]uint64, (ac.maxID/64)+1)
+		} else {
+			r.ids = make(map[uint]struct{})
+		}
+	}
+	return r
+}
+
+// seen reports whether id was already recorded, and records it.
+func (r *matchRecord) seen(id uint) bool {
+	if r.bits != nil {
+		idx := id / 64
+		mask := uint64(1) << (id % 64)
+		if r.bits[idx]&mask != 0 {
+			return true
+		}
+		r.bits[idx] |= mask
+		return false
+	}
+	if _, exists := r.ids[id]; exists {
+		return true
+	}
+	r.ids[id] = struct{}{}
+	return false
+}
+
+func (r *matchRecord) reset() {
+	clear(r.bits)
+	clear(r.ids)
 }
 
 func toLower(b byte) byte {
@@ -317,3 +1123,10 @@ func toLower(b byte) byte {
 	}
 	return b
 }
+
+func toUpper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 32
+	}
+	return b
+}
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -103,6 +104,299 @@ func TestACKS_Search_CaseSensitive(t *testing.T) {
 	}
 }
 
+func TestACKS_Search_SparseRows(t *testing.T) {
+	words := []string{"he", "she", "his", "hers", "ushers", "sheriff", "rif"}
+	dense := NewACKS()
+	sparse := NewACKS(WithDenseStates(3))
+	for i, w := range words {
+		dense.AddPattern(mkPat(w, uint(i+1), 0))
+		sparse.AddPattern(mkPat(w, uint(i+1), 0))
+	}
+	dense.Build()
+	sparse.Build()
+
+	if len(sparse.stateTable) >= len(dense.stateTable) {
+		t.Fatalf("Expected sparse table to be smaller: %d >= %d", len(sparse.stateTable), len(dense.stateTable))
+	}
+
+	for _, text := range []string{"ushers", "sheriffs hishers", "rhisherifushe"} {
+		want, _ := dense.Search([]byte(text))
+		got, _ := sparse.Search([]byte(text))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: Expected %v, got %v", text, want, got)
+		}
+	}
+}
+
+func TestACKS_Search_Nibble(t *testing.T) {
+	ac := NewACKS(WithNibbleAlphabet())
+	ac.AddPattern(mkPat("\x12\x34", 1, 0))
+	ac.AddPattern(mkPat("\xff\x00\xfe", 2, 0))
+	ac.AddPattern(mkPat("Foo", 3, Caseless))
+	ac.Build()
+
+	if ac.alphabetSize != 16 {
+		t.Fatalf("Expected alphabet size 16, got %d", ac.alphabetSize)
+	}
+
+	// "\x01\x23\x40" contains the nibbles 1,2,3,4 at an odd offset only.
+	text := []byte("\x01\x23\x40\x12\x34\xff\x00\xfe fOO")
+	matches, err := ac.Search(text)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	expected := []uint{1, 2, 3}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected %v, got %v", expected, matches)
+	}
+}
+
+func TestACKS_Build_UnusedByteCode(t *testing.T) {
+	ac := NewACKS(WithDenseStates(2))
+	ac.AddPattern(mkPat("ab\x00c", 1, 0))
+	ac.AddPattern(mkPat("b\xffd", 2, 0))
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Bytes outside the pattern alphabet share code 0 and must reset the
+	// automaton instead of extending a partial match.
+	for _, b := range []byte{'x', 'Z', 0x01, 0xfe} {
+		text := []byte{'a', 'b', b, 'c', 'b', b, 'd'}
+		matches, err := ac.Search(text)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(matches) != 0 {
+			t.Errorf("%q: Expected no matches, got %v", text, matches)
+		}
+	}
+
+	matches, _ := ac.Search([]byte("zab\x00cb\xffd"))
+	if !reflect.DeepEqual(matches, []uint{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", matches)
+	}
+}
+
+func TestACKS_Search_SkipAtRoot(t *testing.T) {
+	for _, words := range [][]string{{"xyz"}, {"xyz", "Qq"}} {
+		ac := NewACKS()
+		for i, w := range words {
+			ac.AddPattern(mkPat(w, uint(i+1), Caseless))
+		}
+		ac.Build()
+
+		matches, _ := ac.Search([]byte("aaXYZbbbxyzqQQqxyz"))
+		want := []uint{1, 1, 1}
+		if len(words) == 2 {
+			want = []uint{1, 1, 2, 2, 2, 1}
+		}
+		if !reflect.DeepEqual(matches, want) {
+			t.Errorf("%v: Expected %v, got %v", words, want, matches)
+		}
+	}
+}
+
+func TestACKS_Search_WithoutCaseFolding(t *testing.T) {
+	ac := NewACKS(WithoutCaseFolding())
+	ac.AddPattern(mkPat("Abc", 1, 0))
+	ac.AddPattern(mkPat("abC", 2, 0))
+	ac.Build()
+
+	if ac.translateTable['A'] == ac.translateTable['a'] {
+		t.Fatalf("Expected distinct codes for 'A' and 'a'")
+	}
+	matches, _ := ac.Search([]byte("ABC abc Abc abC"))
+	if !reflect.DeepEqual(matches, []uint{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", matches)
+	}
+
+	// A Caseless pattern still matches in any case.
+	mixed := NewACKS(WithoutCaseFolding())
+	mixed.AddPattern(mkPat("Abc", 1, 0))
+	mixed.AddPattern(mkPat("xyz", 2, Caseless))
+	mixed.Build()
+	matches, _ = mixed.Search([]byte("ABC Abc XYZ"))
+	if !reflect.DeepEqual(matches, []uint{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", matches)
+	}
+}
+
+func TestACKS_Search_SplitCase(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("Abc", 1, 0))
+	ac.AddPattern(mkPat("aBC", 2, Caseless))
+	ac.AddPattern(mkPat("bc", 3, 0))
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if ac.foldCase {
+		t.Fatalf("Expected an exact alphabet")
+	}
+	for text, want := range map[string][]uint{
+		"ABC": {2},
+		"Abc": {1, 2, 3},
+		"abC": {2},
+		"aBc": {2},
+		"xbc": {3},
+	} {
+		matches, _ := ac.Search([]byte(text))
+		sortSlice(matches)
+		if !reflect.DeepEqual(matches, want) {
+			t.Errorf("%q: Expected %v, got %v", text, want, matches)
+		}
+	}
+
+	// Too many case variants fall back to merging all letters.
+	long := NewACKS()
+	long.AddPattern(mkPat("abcdefghijklmnopqrstuvwxyz", 1, 0))
+	long.AddPattern(mkPat("ThisPatternIsVeryLong", 2, Caseless))
+	long.Build()
+	if !long.foldCase {
+		t.Fatalf("Expected folding for a long Caseless pattern")
+	}
+	if matches, _ := long.Search([]byte("THISPATTERNISVERYLONG")); !reflect.DeepEqual(matches, []uint{2}) {
+		t.Errorf("Expected [2], got %v", matches)
+	}
+
+	// The bound is on states, not paths: a few variants of a long tail
+	// would still take millions of states.
+	tail := "abcdefghijklmnop" + strings.Repeat("0", 40)
+	for _, opts := range [][]Option{nil, {WithoutCaseFolding()}} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("abcdefghijklmnopqrstuvwxyz", 1, 0))
+		ac.AddPattern(mkPat(tail, 2, Caseless))
+		err := ac.Build()
+		if opts != nil {
+			if err == nil {
+				t.Errorf("Expected WithoutCaseFolding to refuse the expansion")
+			}
+			continue
+		}
+		if err != nil || !ac.foldCase || ac.stateCount > 100 {
+			t.Fatalf("Expected folding with few states, got %d states, %v", ac.stateCount, err)
+		}
+		if matches, _ := ac.Search([]byte(strings.ToUpper(tail))); !reflect.DeepEqual(matches, []uint{2}) {
+			t.Errorf("Expected [2], got %v", matches)
+		}
+	}
+}
+
+func TestACKS_Search_DuplicateContent(t *testing.T) {
+	// A long Caseless pattern over case-split letters forces the folded
+	// alphabet, where exact copies are verified rather than kept apart by
+	// the automaton.
+	fold := []Pattern{mkPat("abcdefghijklmnopqrstuvwxyz", 8, 0), mkPat("ThisPatternIsVeryLong", 9, Caseless)}
+	for name, opts := range map[string][]Option{
+		"split":  nil,
+		"folded": nil,
+		"nibble": {WithNibbleAlphabet()},
+		"prefix": {WithLongPatternPrefix(2)},
+		"sparse": {WithDenseStates(1)},
+	} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("Abc", 1, Caseless))
+		ac.AddPattern(mkPat("Abc", 2, 0))
+		ac.AddPattern(mkPat("Abc", 3, Caseless|SingleMatch))
+		ac.AddPattern(mkPat("Abc", 1, 0))
+		ac.AddPattern(mkPat("bc", 4, 0))
+		if name == "folded" {
+			ac.AddPattern(fold[0])
+			ac.AddPattern(fold[1])
+		}
+		if err := ac.Build(); err != nil {
+			t.Fatalf("%s: Build failed: %v", name, err)
+		}
+		if name == "folded" && !ac.foldCase {
+			t.Fatalf("Expected a folded alphabet")
+		}
+		// Each copy reports under its own flags, in the order added, before
+		// the shorter pattern ending at the same offset.
+		matches, _ := ac.Search([]byte("abc ABC Abc"))
+		want := []uint{1, 3, 4, 1, 1, 2, 1, 4}
+		if !reflect.DeepEqual(matches, want) {
+			t.Errorf("%s: Expected %v, got %v", name, want, matches)
+		}
+	}
+}
+
+func TestACKS_Search_FullAlphabet(t *testing.T) {
+	ac := NewACKS(WithoutCaseFolding())
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	ac.AddPattern(Pattern{Content: all, ID: 1})
+	ac.AddPattern(Pattern{Content: []byte{0xff, 0x00}, ID: 2})
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if ac.alphabetSize != 256 {
+		t.Fatalf("Expected alphabet size 256, got %d", ac.alphabetSize)
+	}
+	matches, _ := ac.Search(append(all, 0))
+	if !reflect.DeepEqual(matches, []uint{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", matches)
+	}
+}
+
+func TestACKS_ScanPatterns(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("foo", 1, Caseless))
+	ac.AddPattern(mkPat("bar", 2, 0))
+	ac.Build()
+
+	var got []string
+	err := ac.ScanPatterns([]byte("FOO bar"), func(p *Pattern, from, to uint64) error {
+		got = append(got, fmt.Sprintf("%d:%s:%d:%d-%d", p.ID, p.Content, p.Flags, from, to))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanPatterns failed: %v", err)
+	}
+	expected := []string{"1:foo:1:0-3", "2:bar:0:4-7"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+type testSink struct {
+	ids   []uint
+	spans []string
+}
+
+func collectIntoSink(data any, id uint, from, to uint64) error {
+	sink := data.(*testSink)
+	sink.ids = append(sink.ids, id)
+	sink.spans = append(sink.spans, fmt.Sprintf("%d-%d", from, to))
+	return nil
+}
+
+func TestACKS_ScanWithData(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("foo", 1, 0))
+	ac.AddPattern(mkPat("bar", 2, 0))
+	ac.Build()
+
+	a, b := &testSink{}, &testSink{}
+	ac.ScanWithData([]byte("foo"), a, collectIntoSink)
+	ac.ScanWithData([]byte("bar foo"), b, collectIntoSink)
+	if !reflect.DeepEqual(a.ids, []uint{1}) || !reflect.DeepEqual(b.ids, []uint{2, 1}) {
+		t.Errorf("Unexpected sinks %v and %v", a.ids, b.ids)
+	}
+	if want := []string{"0-3", "4-7"}; !reflect.DeepEqual(b.spans, want) {
+		t.Errorf("Expected spans %v, got %v", want, b.spans)
+	}
+
+	text := []byte("foo bar")
+	allocs := testing.AllocsPerRun(100, func() {
+		ac.ScanWithData(text, a, func(data any, id uint, from, to uint64) error { return nil })
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
 func mkPat(content string, id uint, flags Flag) Pattern {
 	return Pattern{
 		Content: []byte(content),
@@ -164,6 +458,58 @@ func BenchmarkACKS_Search_RandomPatterns_10000(b *testing.B) {
 	}
 }
 
+func BenchmarkACKS_ScanPatterns_RandomPatterns_10000(b *testing.B) {
+	ac := NewACKS()
+	numPatterns := 10000
+	patterns := make([]string, 0, numPatterns)
+	for i := 0; i < numPatterns; i++ {
+		s := randomString(10)
+		patterns = append(patterns, s)
+		_ = ac.AddPattern(mkPat(s, uint(i+1), 0))
+	}
+	ac.Build()
+
+	var buffer bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buffer.WriteString(randomString(10))
+		buffer.WriteString(patterns[rand.Intn(numPatterns)])
+	}
+	text := buffer.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	handler := PatternHandler(func(p *Pattern, from, to uint64) error { return nil })
+	for i := 0; i < b.N; i++ {
+		_ = ac.ScanPatterns(text, handler)
+	}
+}
+
+func BenchmarkACKS_Scan_LowDensity(b *testing.B) {
+	for _, tc := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"OneStartByte", []string{"#include", "#define", "#pragma"}},
+		{"FewStartBytes", []string{"#include", "@import", "$ENV", "%TEMP%"}},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			ac := NewACKS()
+			for i, p := range tc.patterns {
+				_ = ac.AddPattern(mkPat(p, uint(i+1), 0))
+			}
+			ac.Build()
+
+			text := []byte(randomString(64 * 1024))
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = ac.Scan(text, nil)
+			}
+		})
+	}
+}
+
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 func randomString(n int) string {
@@ -0,0 +1,19 @@
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// nativeLittleEndian reports whether int32 values in an image can be used
+// as they are on this host.
+var nativeLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// aliasInt32s returns b viewed as little-endian int32 values without
+// copying, or nil if the host byte order or the alignment of b prevents it.
+func aliasInt32s(b []byte) []int32 {
+	if !nativeLittleEndian || len(b) == 0 || uintptr(unsafe.Pointer(&b[0]))%4 != 0 {
+		return nil
+	}
+	return unsafe.Slice((*int32)(unsafe.Pointer(&b[0])), len(b)/4)
+}
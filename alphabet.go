@@ -0,0 +1,21 @@
+package ahocorasick
+
+// AlphabetSize returns the number of symbols the built automaton reads,
+// which is the width of a dense row of its transition table. In nibble mode
+// it is 16.
+func (ac *ACKS) AlphabetSize() int {
+	return ac.alphabetSize
+}
+
+// TranslateTable returns the code of every byte value. Code 0 is shared by
+// the bytes that appear in no pattern, unless all 256 are used; the letters
+// of a case-insensitive alphabet share the code of their lowercase form, and
+// the bytes of a class given to WithByteClasses share one code. In nibble
+// mode bytes are read as their high and then low nibble and the table is
+// all zero.
+func (ac *ACKS) TranslateTable() [256]uint8 {
+	if ac.nibble {
+		return [256]uint8{}
+	}
+	return ac.translateTable
+}
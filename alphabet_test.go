@@ -0,0 +1,26 @@
+package ahocorasick
+
+import "testing"
+
+func TestACKS_TranslateTable(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("abc", 1, Caseless))
+	ac.AddPattern(mkPat("bX", 2, 0))
+	ac.Build()
+
+	tt := ac.TranslateTable()
+	if tt['q'] != 0 || tt['a'] == 0 || tt['a'] != tt['A'] || tt['x'] == tt['X'] {
+		t.Errorf("Unexpected codes a=%d A=%d q=%d x=%d X=%d", tt['a'], tt['A'], tt['q'], tt['x'], tt['X'])
+	}
+	// Every code must index a column of the dense rows.
+	for b, c := range tt {
+		if int(c) >= ac.AlphabetSize() {
+			t.Fatalf("Code %d of byte %d is outside an alphabet of %d", c, b, ac.AlphabetSize())
+		}
+	}
+
+	nb := buildWords([]string{"abc"}, WithNibbleAlphabet())
+	if nb.AlphabetSize() != 16 || nb.TranslateTable() != [256]uint8{} {
+		t.Errorf("Unexpected nibble alphabet %d", nb.AlphabetSize())
+	}
+}
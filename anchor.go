@@ -0,0 +1,87 @@
+package ahocorasick
+
+// anchorFlags are the flags that look at the bytes around a match.
+const anchorFlags = LineAnchoredStart | LineAnchoredEnd | WordBoundary
+
+// atStart reports whether a match of pat starting at text[start] satisfies
+// the anchors of pat that look at the byte before it.
+func (ss *scanState) atStart(pat *Pattern, text []byte, start int) bool {
+	if pat.Flags&LineAnchoredStart != 0 && !ss.atLineStart(text, start) {
+		return false
+	}
+	return pat.Flags&WordBoundary == 0 || ss.atWordStart(text, start)
+}
+
+// atEnd reports whether a match of pat ending just before text[end]
+// satisfies the anchors of pat that look at the byte after it, or wait if
+// that byte is in the next stream buffer.
+func (ss *scanState) atEnd(pat *Pattern, text []byte, end int) (ok, wait bool) {
+	if end == len(text) && ss.stream {
+		return false, true
+	}
+	if pat.Flags&LineAnchoredEnd != 0 {
+		if ok, _ := ss.atLineEnd(text, end); !ok {
+			return false, false
+		}
+	}
+	if pat.Flags&WordBoundary != 0 {
+		return ss.atWordEnd(text, end)
+	}
+	return true, false
+}
+
+// before returns the byte before text[start], or false at the start of the
+// input. start may be negative for a match that began in an earlier buffer,
+// whose bytes are read from the history.
+func (ss *scanState) before(text []byte, start int) (byte, bool) {
+	if int64(ss.base)+int64(start) == 0 {
+		return 0, false
+	}
+	if start > 0 {
+		return text[start-1], true
+	}
+	if j := len(ss.history) + start - 1; j >= 0 {
+		return ss.history[j], true
+	}
+	// With anchors the history always holds the byte before a match, so
+	// this is not reached in practice.
+	return 0, true
+}
+
+// atLineStart reports whether a match starting at text[start] begins a
+// line.
+func (ss *scanState) atLineStart(text []byte, start int) bool {
+	b, ok := ss.before(text, start)
+	return !ok || b == '\n'
+}
+
+// atLineEnd reports whether a match ending just before text[end] ends a
+// line. At the end of a stream buffer the answer depends on the next one,
+// so it reports wait instead.
+func (ss *scanState) atLineEnd(text []byte, end int) (ok, wait bool) {
+	if end < len(text) {
+		return text[end] == '\n' || text[end] == '\r', false
+	}
+	return !ss.stream, ss.stream
+}
+
+// atWordStart reports whether a match starting at text[start] is not
+// preceded by a word byte.
+func (ss *scanState) atWordStart(text []byte, start int) bool {
+	b, ok := ss.before(text, start)
+	return !ok || !isWordByte(b)
+}
+
+// atWordEnd reports whether a match ending just before text[end] is not
+// followed by a word byte, or wait at the end of a stream buffer.
+func (ss *scanState) atWordEnd(text []byte, end int) (ok, wait bool) {
+	if end < len(text) {
+		return !isWordByte(text[end]), false
+	}
+	return !ss.stream, ss.stream
+}
+
+// isWordByte reports whether b is in [A-Za-z0-9_].
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b|0x20 >= 'a' && b|0x20 <= 'z'
+}
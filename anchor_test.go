@@ -0,0 +1,124 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_LineAnchors(t *testing.T) {
+	text := []byte("ERROR x\nan ERROR\r\nERROR\nINFO ERROR y\nERRORS")
+	for _, opts := range [][]Option{nil, {WithLongPatternPrefix(2)}, {WithNibbleAlphabet()}} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("error", 1, Caseless|LineAnchoredStart))
+		ac.AddPattern(mkPat("ERROR", 2, LineAnchoredEnd))
+		ac.AddPattern(mkPat("ERROR", 3, LineAnchoredStart|LineAnchoredEnd))
+		ac.AddPattern(mkPat("y", 4, LineAnchoredEnd))
+		ac.Build()
+
+		var want []string
+		ac.Scan(text, func(id uint, from, to uint64) error {
+			want = append(want, fmt.Sprint(id, "@", to))
+			return nil
+		})
+		if exp := []string{"1@5", "2@16", "1@23", "2@23", "3@23", "4@36", "1@42"}; !reflect.DeepEqual(want, exp) {
+			t.Fatalf("%v: Expected %v, got %v", opts, exp, want)
+		}
+
+		// Streams give the same matches wherever the chunks are cut.
+		for cut := 0; cut <= len(text); cut++ {
+			st := ac.NewStream()
+			var got []string
+			m := func(id uint, from, to uint64) error {
+				got = append(got, fmt.Sprint(id, "@", to))
+				return nil
+			}
+			ac.ScanStream(st, text[:cut], m)
+			ac.ScanStream(st, text[cut:], m)
+			ac.FinishStream(st, m)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v cut at %d: Expected %v, got %v", opts, cut, want, got)
+			}
+		}
+	}
+}
+
+func TestFlag_LineAnchorModifiers(t *testing.T) {
+	f, err := ParseModifiers("/i^$")
+	if err != nil || f != Caseless|LineAnchoredStart|LineAnchoredEnd {
+		t.Fatalf("Unexpected result %v, %v", f, err)
+	}
+	if s := f.String(); s != "caseless|linestart|lineend" {
+		t.Errorf("Unexpected name %q", s)
+	}
+}
+
+func TestACKS_WordBoundary(t *testing.T) {
+	text := []byte("cat concat cat_ cats (cat) CAT9 Cat")
+	for _, opts := range [][]Option{nil, {WithLongPatternPrefix(2)}, {WithNibbleAlphabet()}} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("cat", 1, Caseless|WordBoundary))
+		ac.AddPattern(mkPat("cat", 2, 0))
+		ac.Build()
+
+		var want []string
+		ac.Scan(text, func(id uint, from, to uint64) error {
+			if id == 1 {
+				want = append(want, fmt.Sprint(id, "@", to))
+			}
+			return nil
+		})
+		if exp := []string{"1@3", "1@25", "1@35"}; !reflect.DeepEqual(want, exp) {
+			t.Fatalf("%v: Expected %v, got %v", opts, exp, want)
+		}
+
+		// Streams give the same matches wherever the chunks are cut.
+		for cut := 0; cut <= len(text); cut++ {
+			st := ac.NewStream()
+			var got []string
+			m := func(id uint, from, to uint64) error {
+				if id == 1 {
+					got = append(got, fmt.Sprint(id, "@", to))
+				}
+				return nil
+			}
+			ac.ScanStream(st, text[:cut], m)
+			ac.ScanStream(st, text[cut:], m)
+			ac.FinishStream(st, m)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v cut at %d: Expected %v, got %v", opts, cut, want, got)
+			}
+		}
+	}
+}
+
+func TestFlag_WordBoundaryModifier(t *testing.T) {
+	f, err := ParseModifiers("/iw")
+	if err != nil || f != Caseless|WordBoundary {
+		t.Fatalf("Unexpected result %v, %v", f, err)
+	}
+	if s, m := f.String(), f.Modifiers(); s != "caseless|wordboundary" || m != "/iw" {
+		t.Errorf("Unexpected name %q and modifiers %q", s, m)
+	}
+}
+
+func TestACKS_WordBoundaryAtEOF(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("dog", 1, WordBoundary))
+	ac.Build()
+	text := []byte("hotdog dog")
+	path := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(path, text, 0o644)
+	var got []uint64
+	if err := ac.ScanFile(path, func(id uint, from, to uint64) error {
+		got = append(got, to)
+		return nil
+	}, FileChunkSize(4)); err != nil {
+		t.Fatalf("ScanFile failed: %v", err)
+	}
+	if want := []uint64{10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
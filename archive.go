@@ -0,0 +1,170 @@
+package ahocorasick
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrArchiveLimit is returned by ScanArchive when an entry or the archive as
+// a whole decompresses to more than its limit.
+var ErrArchiveLimit = errors.New("ahocorasick: archive size limit exceeded")
+
+// ArchiveOption configures ScanArchive.
+type ArchiveOption func(*archiveConfig)
+
+type archiveConfig struct {
+	maxEntry, maxTotal int64
+	nested             bool
+}
+
+// ArchiveMaxEntrySize limits the decompressed size of each entry. The
+// default is 256 MiB.
+func ArchiveMaxEntrySize(n int64) ArchiveOption {
+	return func(c *archiveConfig) {
+		c.maxEntry = n
+	}
+}
+
+// ArchiveMaxTotalSize limits the decompressed size of all entries together.
+// The default is 1 GiB.
+func ArchiveMaxTotalSize(n int64) ArchiveOption {
+	return func(c *archiveConfig) {
+		c.maxTotal = n
+	}
+}
+
+// ArchiveNested also opens archives found inside the archive, one level
+// deep, recognized by a .zip, .tar, .tgz or .tar.gz name. A nested archive
+// is held in memory, within the entry size limit, and its entries are named
+// "outer.zip/inner.txt".
+func ArchiveNested() ArchiveOption {
+	return func(c *archiveConfig) {
+		c.nested = true
+	}
+}
+
+// ScanArchive scans every regular file in a zip, tar or gzip-compressed tar
+// archive, detected from its content, and reports matches with the name of
+// the entry they were found in. Entries are decompressed and streamed
+// through the matcher one at a time; match offsets are relative to the
+// entry.
+func (ac *ACKS) ScanArchive(r io.ReaderAt, size int64, m func(entry string, match Match) error, opts ...ArchiveOption) error {
+	cfg := archiveConfig{maxEntry: 256 << 20, maxTotal: 1 << 30}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	a := archiveScan{ac: ac, cfg: &cfg, m: m}
+	return a.scan(r, size, "", 0)
+}
+
+// archiveScan is the state of one ScanArchive call.
+type archiveScan struct {
+	ac    *ACKS
+	cfg   *archiveConfig
+	m     func(string, Match) error
+	total int64
+}
+
+func (a *archiveScan) scan(r io.ReaderAt, size int64, prefix string, depth int) error {
+	var head [262]byte
+	n, _ := r.ReadAt(head[:], 0)
+	switch {
+	case n >= 4 && (string(head[:4]) == "PK\x03\x04" || string(head[:4]) == "PK\x05\x06"):
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return fmt.Errorf("ahocorasick: %s%w", prefix, err)
+		}
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("ahocorasick: %s%s: %w", prefix, f.Name, err)
+			}
+			err = a.entry(prefix+f.Name, rc, depth)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case n >= 2 && head[0] == 0x1f && head[1] == 0x8b:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("ahocorasick: %s%w", prefix, err)
+		}
+		return a.scanTar(gz, prefix, depth)
+	case n >= 262 && string(head[257:262]) == "ustar":
+		return a.scanTar(io.NewSectionReader(r, 0, size), prefix, depth)
+	}
+	return fmt.Errorf("ahocorasick: %snot a zip or tar archive", prefix)
+}
+
+func (a *archiveScan) scanTar(r io.Reader, prefix string, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ahocorasick: %s%w", prefix, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := a.entry(prefix+hdr.Name, tr, depth); err != nil {
+			return err
+		}
+	}
+}
+
+// entry scans one regular file, or opens it if it is a nested archive.
+func (a *archiveScan) entry(name string, r io.Reader, depth int) error {
+	lr := &archiveLimiter{r: r, a: a, name: name}
+	if a.cfg.nested && depth == 0 && isArchiveName(name) {
+		b, err := io.ReadAll(lr)
+		if err != nil {
+			return err
+		}
+		return a.scan(bytes.NewReader(b), int64(len(b)), name+"/", depth+1)
+	}
+	return a.ac.scanAll(lr, &handler{fn: func(from, to uint64, ps *Pattern) error {
+		return a.m(name, newMatch(from, to, ps))
+	}})
+}
+
+func isArchiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tgz", ".tar.gz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveLimiter enforces the size limits on an entry as it is read.
+type archiveLimiter struct {
+	r    io.Reader
+	a    *archiveScan
+	name string
+	n    int64
+}
+
+func (l *archiveLimiter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	l.a.total += int64(n)
+	if l.n > l.a.cfg.maxEntry || l.a.total > l.a.cfg.maxTotal {
+		return 0, fmt.Errorf("%w: %s", ErrArchiveLimit, l.name)
+	}
+	return n, err
+}
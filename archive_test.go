@@ -0,0 +1,71 @@
+package ahocorasick
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTar(t *testing.T, w io.Writer, files map[string]string) {
+	tw := tar.NewWriter(w)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if body, ok := files[name]; ok {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(body))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestACKS_ScanArchive(t *testing.T) {
+	ac := buildWords([]string{"token", "secret"})
+
+	var inner bytes.Buffer
+	writeTar(t, &inner, map[string]string{"a.txt": "a secret", "b.txt": "nothing"})
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	for _, f := range []struct{ name, body string }{
+		{"dir/", ""},
+		{"dir/c.txt", "token and secret"},
+		{"inner.tar", inner.String()},
+	} {
+		w, _ := zw.Create(f.name)
+		w.Write([]byte(f.body))
+	}
+	zw.Close()
+
+	var got []string
+	collect := func(entry string, m Match) error {
+		got = append(got, entry)
+		return nil
+	}
+	z := bytes.NewReader(zbuf.Bytes())
+	if err := ac.ScanArchive(z, z.Size(), collect, ArchiveNested()); err != nil {
+		t.Fatalf("ScanArchive failed: %v", err)
+	}
+	want := []string{"dir/c.txt", "dir/c.txt", "inner.tar/a.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	var tgz bytes.Buffer
+	gw := gzip.NewWriter(&tgz)
+	writeTar(t, gw, map[string]string{"a.txt": strings.Repeat("x", 100) + "token"})
+	gw.Close()
+	got = nil
+	r := bytes.NewReader(tgz.Bytes())
+	if err := ac.ScanArchive(r, r.Size(), collect); err != nil || !reflect.DeepEqual(got, []string{"a.txt"}) {
+		t.Errorf("Unexpected tar.gz result %v, %v", got, err)
+	}
+	if err := ac.ScanArchive(r, r.Size(), collect, ArchiveMaxEntrySize(64)); !errors.Is(err, ErrArchiveLimit) {
+		t.Errorf("Expected ErrArchiveLimit, got %v", err)
+	}
+}
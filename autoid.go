@@ -0,0 +1,50 @@
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// AddPatternAuto adds a pattern whose ID is a hash of its content and
+// flags, so the same pattern gets the same ID in every build without the
+// caller managing IDs. If the ID is already held by another pattern, the
+// next free one is used; only then does the ID depend on the order patterns
+// are added. Adding the same content and flags again adds nothing and
+// returns the ID they already have. IDs fit in 32 bits.
+func (ac *ACKS) AddPatternAuto(content []byte, flags Flag) (uint, error) {
+	if ac.autoIDs == nil {
+		ac.autoIDs = make(map[uint]string, len(ac.patterns))
+		for _, p := range ac.patterns {
+			ac.claimID(p.ID, "")
+		}
+	}
+	var fb [8]byte
+	binary.LittleEndian.PutUint64(fb[:], uint64(flags))
+	key := string(fb[:]) + string(content)
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	id := uint(h.Sum32())
+	for {
+		held, ok := ac.autoIDs[id]
+		if !ok {
+			break
+		}
+		if held == key {
+			return id, nil
+		}
+		id = uint(uint32(id + 1))
+	}
+	if err := ac.AddPattern(Pattern{Content: content, ID: id, Flags: flags}); err != nil {
+		return 0, err
+	}
+	ac.autoIDs[id] = key
+	return id, nil
+}
+
+// claimID marks id as held by the pattern with the given key, or by a
+// pattern with an explicit ID if key is empty.
+func (ac *ACKS) claimID(id uint, key string) {
+	if _, ok := ac.autoIDs[id]; !ok {
+		ac.autoIDs[id] = key
+	}
+}
@@ -0,0 +1,31 @@
+package ahocorasick
+
+import "testing"
+
+func TestACKS_AddPatternAuto(t *testing.T) {
+	a, b := NewACKS(), NewACKS()
+	ida, _ := a.AddPatternAuto([]byte("evil"), Caseless)
+	b.AddPatternAuto([]byte("other"), 0)
+	idb, _ := b.AddPatternAuto([]byte("evil"), Caseless)
+	if ida != idb {
+		t.Errorf("Expected a stable ID, got %d and %d", ida, idb)
+	}
+	if again, _ := a.AddPatternAuto([]byte("evil"), Caseless); again != ida || len(a.patterns) != 1 {
+		t.Errorf("Expected the same pattern to be added once")
+	}
+	if id, _ := a.AddPatternAuto([]byte("evil"), 0); id == ida {
+		t.Errorf("Expected flags to change the ID")
+	}
+
+	// An ID taken by an explicit pattern moves the auto ID along.
+	c := NewACKS()
+	c.AddPattern(mkPat("x", ida, 0))
+	idc, _ := c.AddPatternAuto([]byte("evil"), Caseless)
+	if idc != ida+1 {
+		t.Errorf("Expected %d, got %d", ida+1, idc)
+	}
+	c.Build()
+	if ids, _ := c.Search([]byte("EVIL")); len(ids) != 1 || ids[0] != idc {
+		t.Errorf("Expected [%d], got %v", idc, ids)
+	}
+}
@@ -0,0 +1,87 @@
+package ahocorasick
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BatchHandler receives the matches of ScanBatch along with the index of the
+// text they were found in.
+type BatchHandler func(textIdx int, match Match) error
+
+// BatchOption configures ScanBatch.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	workers int
+}
+
+// BatchWorkers scans the batch with n goroutines. The handler is then called
+// concurrently and must be safe for concurrent use. Texts are handed out in
+// order, but their matches may interleave.
+func BatchWorkers(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.workers = n
+	}
+}
+
+// ScanBatch scans every text in texts, reusing one set of scan scratch per
+// worker instead of setting it up for each text. This makes scanning millions
+// of short strings much cheaper than calling Scan in a loop. The first error
+// returned by m stops the batch and is returned.
+func (ac *ACKS) ScanBatch(texts [][]byte, m BatchHandler, opts ...BatchOption) error {
+	cfg := batchConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var next atomic.Int64
+	var stopped atomic.Bool
+	claim := func() int {
+		if stopped.Load() {
+			return len(texts)
+		}
+		return int(next.Add(1) - 1)
+	}
+	if cfg.workers <= 1 || len(texts) <= 1 {
+		return ac.scanBatchWorker(texts, claim, m)
+	}
+
+	var (
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < min(cfg.workers, len(texts)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ac.scanBatchWorker(texts, claim, m); err != nil {
+				once.Do(func() { firstErr = err })
+				stopped.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// scanBatchWorker scans the texts whose indexes it takes from claim, until
+// they run out, with a single scan scratch.
+func (ac *ACKS) scanBatchWorker(texts [][]byte, claim func() int, m BatchHandler) error {
+	ss := scanState{record: ac.newMatchRecord()}
+	var idx int
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		if m == nil {
+			return nil
+		}
+		return m(idx, newMatch(from, to, ps))
+	}}
+	for idx = claim(); idx < len(texts); idx = claim() {
+		ss.reset()
+		h.offsets = nil
+		if err := ac.searchWith(&ss, texts[idx], &h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestACKS_ScanBatch(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("foo", 1, SingleMatch))
+	ac.AddPattern(mkPat("bar", 2, 0))
+	ac.Build()
+
+	texts := make([][]byte, 100)
+	for i := range texts {
+		texts[i] = []byte(fmt.Sprintf("%d foo foo bar", i))
+	}
+
+	for _, workers := range []int{1, 4} {
+		var mu sync.Mutex
+		counts := make(map[int]int)
+		err := ac.ScanBatch(texts, func(idx int, m Match) error {
+			mu.Lock()
+			counts[idx]++
+			mu.Unlock()
+			if string(texts[idx][m.From:m.To]) != map[uint]string{1: "foo", 2: "bar"}[m.ID] {
+				t.Errorf("Unexpected match %+v in %q", m, texts[idx])
+			}
+			return nil
+		}, BatchWorkers(workers))
+		if err != nil {
+			t.Fatalf("ScanBatch failed: %v", err)
+		}
+		// The SingleMatch record must be reset between texts.
+		for i := range texts {
+			if counts[i] != 2 {
+				t.Errorf("workers=%d text %d: Expected 2 matches, got %d", workers, i, counts[i])
+			}
+		}
+	}
+}
+
+func TestACKS_ScanBatch_StopsOnError(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("x", 1, 0))
+	ac.Build()
+
+	errBoom := errors.New("boom")
+	seen := 0
+	err := ac.ScanBatch([][]byte{[]byte("x"), []byte("x"), []byte("x")}, func(idx int, m Match) error {
+		seen++
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) || seen != 1 {
+		t.Errorf("Expected to stop after the first error, got %v after %d calls", err, seen)
+	}
+}
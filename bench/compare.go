@@ -0,0 +1,106 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+// Result is the measured cost of one way of searching the text.
+type Result struct {
+	Name    string
+	PerScan time.Duration // average time to search the whole text once
+	MBPerS  float64
+	Found   int // matches, or patterns present for the Contains loop
+}
+
+// Report compares the matcher with standard library alternatives.
+type Report struct {
+	Matcher  Result // ahocorasick.ACKS.Scan
+	Contains Result // a bytes.Contains loop over the patterns
+	Regexp   Result // a regexp alternation of the quoted patterns
+}
+
+// Speedup returns how many times faster the matcher was than r.
+func (rep *Report) Speedup(r Result) float64 {
+	return float64(r.PerScan) / float64(rep.Matcher.PerScan)
+}
+
+// String formats the report as a table.
+func (rep *Report) String() string {
+	var sb strings.Builder
+	for _, r := range []Result{rep.Matcher, rep.Contains, rep.Regexp} {
+		fmt.Fprintf(&sb, "%-10s %12v/scan %10.1f MB/s %8d found %8.1fx\n",
+			r.Name, r.PerScan, r.MBPerS, r.Found, rep.Speedup(r))
+	}
+	return sb.String()
+}
+
+// Compare builds a matcher with opts over patterns and times it against a
+// bytes.Contains loop and a regexp alternation on text, each for at least
+// minTime. The Contains loop only answers which patterns occur, so it does
+// less work than the others; the regexp finds non-overlapping leftmost
+// matches. Use it with your own patterns and a representative sample to
+// decide whether the matcher pays off, and from how many patterns.
+func Compare(patterns [][]byte, text []byte, minTime time.Duration, opts ...ahocorasick.Option) (*Report, error) {
+	ac := ahocorasick.NewACKS(opts...)
+	quoted := make([]string, len(patterns))
+	for i, p := range patterns {
+		if err := ac.AddPattern(ahocorasick.Pattern{Content: p, ID: uint(i + 1)}); err != nil {
+			return nil, err
+		}
+		quoted[i] = regexp.QuoteMeta(string(p))
+	}
+	if err := ac.Build(); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(strings.Join(quoted, "|"))
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{}
+	rep.Matcher = measure("ahocorasick", text, minTime, func() int {
+		n := 0
+		ac.Scan(text, func(id uint, from, to uint64) error {
+			n++
+			return nil
+		})
+		return n
+	})
+	rep.Contains = measure("contains", text, minTime, func() int {
+		n := 0
+		for _, p := range patterns {
+			if bytes.Contains(text, p) {
+				n++
+			}
+		}
+		return n
+	})
+	rep.Regexp = measure("regexp", text, minTime, func() int {
+		return len(re.FindAllIndex(text, -1))
+	})
+	return rep, nil
+}
+
+// measure runs f repeatedly for at least minTime and averages its time.
+func measure(name string, text []byte, minTime time.Duration, f func() int) Result {
+	found := f() // warm up
+	runs := 0
+	start := time.Now()
+	for runs == 0 || time.Since(start) < minTime {
+		f()
+		runs++
+	}
+	per := time.Since(start) / time.Duration(runs)
+	return Result{
+		Name:    name,
+		PerScan: per,
+		MBPerS:  float64(len(text)) / per.Seconds() / 1e6,
+		Found:   found,
+	}
+}
@@ -0,0 +1,24 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	pats := Patterns(PatternConfig{Count: 50, MinLen: 8, MaxLen: 8, Seed: 3})
+	text := Text(TextConfig{Size: 16 * 1024, HitsPerKiB: 1, Seed: 3}, pats)
+	rep, err := Compare(pats, text, 0)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if rep.Matcher.Found != rep.Regexp.Found || rep.Matcher.Found == 0 || rep.Contains.Found == 0 {
+		t.Errorf("Unexpected results %+v", rep)
+	}
+	if rep.Matcher.PerScan <= 0 || rep.Speedup(rep.Matcher) != 1 {
+		t.Errorf("Unexpected timings %+v", rep.Matcher)
+	}
+	if s := rep.String(); strings.Count(s, "\n") != 3 || !strings.Contains(s, "regexp") {
+		t.Errorf("Unexpected report:\n%s", s)
+	}
+}
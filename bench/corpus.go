@@ -0,0 +1,88 @@
+// Package bench generates benchmark workloads for ahocorasick matchers, so
+// backends and options can be compared on pattern sets and texts shaped like
+// real ones rather than on a fixed synthetic benchmark, and compares the
+// matcher with standard library alternatives.
+package bench
+
+import (
+	"math/rand/v2"
+)
+
+// DefaultAlphabet is used when a config leaves Alphabet empty.
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// PatternConfig shapes a generated pattern set.
+type PatternConfig struct {
+	Count          int
+	MinLen, MaxLen int // lengths are uniform in [MinLen, MaxLen]
+	// Overlap is the fraction of patterns, from 0 to 1, that extend a
+	// prefix of an earlier pattern, as in dictionaries of related words.
+	// Overlapping patterns make the automaton deeper and narrower.
+	Overlap  float64
+	Alphabet string
+	Seed     uint64
+}
+
+// Patterns generates a pattern set. The same config always yields the same
+// patterns. Duplicates are possible with small alphabets or lengths.
+func Patterns(cfg PatternConfig) [][]byte {
+	rng := rand.New(rand.NewPCG(cfg.Seed, 1))
+	alpha := alphabet(cfg.Alphabet)
+	minLen := max(cfg.MinLen, 1)
+	maxLen := max(cfg.MaxLen, minLen)
+	pats := make([][]byte, 0, cfg.Count)
+	for len(pats) < cfg.Count {
+		n := minLen + rng.IntN(maxLen-minLen+1)
+		p := make([]byte, 0, n)
+		if len(pats) > 0 && rng.Float64() < cfg.Overlap {
+			prev := pats[rng.IntN(len(pats))]
+			p = append(p, prev[:rng.IntN(min(len(prev), n))+1]...)
+		}
+		for len(p) < n {
+			p = append(p, alpha[rng.IntN(len(alpha))])
+		}
+		pats = append(pats, p)
+	}
+	return pats
+}
+
+// TextConfig shapes a generated text.
+type TextConfig struct {
+	Size int
+	// HitsPerKiB is the number of patterns planted per KiB of text. Random
+	// filler can add a few more matches of short patterns.
+	HitsPerKiB float64
+	Alphabet   string // the filler alphabet
+	Seed       uint64
+}
+
+// Text generates Size bytes of random filler with patterns planted at random
+// positions at the configured density. The same config and patterns always
+// yield the same text.
+func Text(cfg TextConfig, patterns [][]byte) []byte {
+	rng := rand.New(rand.NewPCG(cfg.Seed, 2))
+	alpha := alphabet(cfg.Alphabet)
+	text := make([]byte, cfg.Size)
+	for i := range text {
+		text[i] = alpha[rng.IntN(len(alpha))]
+	}
+	if len(patterns) == 0 {
+		return text
+	}
+	hits := int(cfg.HitsPerKiB * float64(cfg.Size) / 1024)
+	for i := 0; i < hits; i++ {
+		p := patterns[rng.IntN(len(patterns))]
+		if len(p) > len(text) {
+			continue
+		}
+		copy(text[rng.IntN(len(text)-len(p)+1):], p)
+	}
+	return text
+}
+
+func alphabet(a string) string {
+	if a == "" {
+		return DefaultAlphabet
+	}
+	return a
+}
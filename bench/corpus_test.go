@@ -0,0 +1,35 @@
+package bench
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+func TestCorpus(t *testing.T) {
+	pc := PatternConfig{Count: 200, MinLen: 6, MaxLen: 12, Overlap: 0.5, Alphabet: "abcdef", Seed: 7}
+	pats := Patterns(pc)
+	if len(pats) != 200 || !reflect.DeepEqual(pats, Patterns(pc)) {
+		t.Fatalf("Expected 200 reproducible patterns")
+	}
+	for _, p := range pats {
+		if len(p) < 6 || len(p) > 12 || len(bytes.Trim(p, "abcdef")) != 0 {
+			t.Fatalf("Pattern %q out of shape", p)
+		}
+	}
+
+	ac := ahocorasick.NewACKS()
+	for i, p := range pats {
+		ac.AddPattern(ahocorasick.Pattern{Content: p, ID: uint(i + 1)})
+	}
+	ac.Build()
+	sparse := Text(TextConfig{Size: 64 * 1024, HitsPerKiB: 0.5, Alphabet: "xyz", Seed: 1}, pats)
+	dense := Text(TextConfig{Size: 64 * 1024, HitsPerKiB: 10, Alphabet: "xyz", Seed: 1}, pats)
+	ns, _ := ac.Search(sparse)
+	nd, _ := ac.Search(dense)
+	if len(sparse) != 64*1024 || len(ns) < 16 || len(ns) > 64 || len(nd) < 10*len(ns) {
+		t.Errorf("Unexpected hit counts %d and %d", len(ns), len(nd))
+	}
+}
@@ -0,0 +1,115 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// binaryAlphabet is weighted towards the bytes that trip up byte handling:
+// NUL, 0xFF, the bytes next to letters, and letters whose case could be
+// confused with high bytes.
+var binaryAlphabet = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x80, 0x7f, 0xc1, 0xe1, 0xdf, '@', '[', '`', '{', 'a', 'A', 'z', 'Z', '\n'}
+
+func matchKeys(ms []Match) []string {
+	var out []string
+	for _, m := range ms {
+		out = append(out, fmt.Sprint(m.ID, m.To))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestBinaryInputs(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	word := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = binaryAlphabet[r.Intn(len(binaryAlphabet))]
+		}
+		return b
+	}
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	for round := 0; round < 40; round++ {
+		var pats []Pattern
+		for i := 0; i < 1+r.Intn(8); i++ {
+			pats = append(pats, Pattern{Content: word(1 + r.Intn(6)), ID: uint(i + 1), Flags: Flag(r.Intn(2)) * Caseless})
+		}
+		if round%4 == 0 {
+			// Every byte value in use leaves no code for unused bytes.
+			pats = append(pats, Pattern{Content: all, ID: 99})
+		}
+		text := word(300)
+		text = append(text, all...)
+		for _, opts := range [][]Option{nil, {WithNibbleAlphabet()}, {WithDenseStates(2)}, {WithLongPatternPrefix(2)}, {WithoutCaseFolding()}} {
+			ac := NewACKS(opts...)
+			for _, p := range pats {
+				ac.AddPattern(p)
+			}
+			if err := ac.Build(); err != nil {
+				t.Fatal(err)
+			}
+			want := matchKeys(naiveMatches(ac, text))
+			if got := matchKeys(ac.FindN(text, 0)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("round %d %v: Expected %v, got %v", round, opts, want, got)
+			}
+
+			// The same matches when the text arrives one byte at a time.
+			var streamed []Match
+			st := ac.NewStream()
+			for i := range text {
+				ac.ScanStream(st, text[i:i+1], func(id uint, from, to uint64) error {
+					streamed = append(streamed, Match{ID: id, To: to})
+					return nil
+				})
+			}
+			if got := matchKeys(streamed); !reflect.DeepEqual(got, want) {
+				t.Fatalf("round %d %v streamed: Expected %v, got %v", round, opts, want, got)
+			}
+
+			// Serialization keeps every byte of the patterns.
+			var buf bytes.Buffer
+			ac.WriteTo(&buf)
+			loaded, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchKeys(loaded.FindN(text, 0)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("round %d %v loaded: Expected %v, got %v", round, opts, want, got)
+			}
+		}
+	}
+}
+
+func TestBinaryInputs_SingleBytes(t *testing.T) {
+	// NUL and 0xFF alone exercise the literal engine and the start-byte skip.
+	for _, b := range []byte{0x00, 0xff} {
+		ac := NewACKS()
+		ac.AddPattern(Pattern{Content: []byte{b}, ID: 1})
+		ac.AddPattern(Pattern{Content: []byte{b, b}, ID: 2, Flags: Caseless})
+		ac.Build()
+		text := []byte{'a', b, b, 0x7f, b}
+		if got, want := matchKeys(ac.FindN(text, 0)), matchKeys(naiveMatches(ac, text)); !reflect.DeepEqual(got, want) || len(got) != 4 {
+			t.Errorf("byte %#x: Expected %v, got %v", b, want, got)
+		}
+	}
+}
+
+func TestBinaryInputs_CaselessHighBytes(t *testing.T) {
+	// Only ASCII letters fold: 0xC1 and 0xE1 differ by the case bit but are
+	// distinct bytes, as are '@' and '`'.
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte{0xc1, '@'}, ID: 1, Flags: Caseless})
+	ac.AddPattern(Pattern{Content: []byte("a\x00"), ID: 2, Flags: Caseless})
+	ac.Build()
+	ids, _ := ac.Search([]byte("\xe1`\xc1` \xc1@ A\x00 a\x20 \x81\x00"))
+	if want := []uint{1, 2}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+}
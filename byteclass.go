@@ -0,0 +1,27 @@
+package ahocorasick
+
+// Common byte classes for WithByteClasses.
+const (
+	WhitespaceClass = " \t\n\v\f\r"
+	DigitClass      = "0123456789"
+)
+
+// WithByteClasses makes the bytes of each class share one alphabet code,
+// so patterns that differ only within a class share automaton states and
+// the tables shrink. Candidates are then verified against the exact pattern
+// content, so matching stays exact. Letters are ignored, as their codes are
+// governed by case handling, and so are classes in nibble mode.
+func WithByteClasses(classes ...string) Option {
+	return func(ac *ACKS) {
+		for k, class := range classes {
+			if k == 255 {
+				break
+			}
+			for i := 0; i < len(class); i++ {
+				if l := toLower(class[i]); l < 'a' || l > 'z' {
+					ac.byteClass[class[i]] = uint8(k + 1)
+				}
+			}
+		}
+	}
+}
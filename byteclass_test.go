@@ -0,0 +1,34 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ByteClasses(t *testing.T) {
+	words := []string{"v1.2.3", "v1.2.4", "v9.9.9", "a b", "a\tb", "x 1"}
+	plain := buildWords(words, WithDenseStates(1000))
+	classed := buildWords(words, WithDenseStates(1000), WithByteClasses(DigitClass, WhitespaceClass))
+	if classed.alphabetSize >= plain.alphabetSize || classed.stateCount >= plain.stateCount {
+		t.Errorf("Expected a smaller automaton: %d codes, %d states vs %d, %d",
+			classed.alphabetSize, classed.stateCount, plain.alphabetSize, plain.stateCount)
+	}
+
+	text := []byte("v1.2.3 v1.2.5 v9.9.9 a b a\tb a\nb x 1 x 2")
+	want, _ := plain.Search(text)
+	got, _ := classed.Search(text)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected exact matches %v, got %v", want, got)
+	}
+
+	var buf bytes.Buffer
+	classed.WriteTo(&buf)
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got, _ := loaded.Search(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the loaded matcher to verify too, got %v", got)
+	}
+}
@@ -0,0 +1,149 @@
+/* acks.c - reference C reader, see acks.h. */
+#include "acks.h"
+
+#include <string.h>
+
+static uint32_t crc32_ieee(const uint8_t *p, size_t n)
+{
+	uint32_t crc = 0xffffffff;
+	while (n--) {
+		crc ^= *p++;
+		for (int k = 0; k < 8; k++)
+			crc = (crc >> 1) ^ (0xedb88320 & -(crc & 1));
+	}
+	return ~crc;
+}
+
+static size_t align8(size_t n) { return (n + 7) & ~(size_t)7; }
+
+int acks_open(struct acks_db *db, const void *image, size_t size)
+{
+	const uint8_t *base = image;
+	const struct acks_header *h = image;
+	size_t pos = ACKS_HEADER_SIZE, sparse_rows = 0;
+
+	if (size < ACKS_HEADER_SIZE + 8 || size % 8 != 0 ||
+	    memcmp(h->magic, ACKS_MAGIC, 8) != 0 || h->version < 2 || h->version > ACKS_VERSION)
+		return -1;
+	if (crc32_ieee(base, size - 8) != *(const uint32_t *)(base + size - 8))
+		return -1;
+	if (h->dense_states < h->state_count)
+		sparse_rows = h->state_count - h->dense_states + 1;
+
+#define SECTION(field, type, count)                                  \
+	do {                                                         \
+		db->field = (const type *)(base + pos);              \
+		pos = align8(pos + (size_t)(count) * sizeof(type));  \
+		if (pos > size - 8)                                  \
+			return -1;                                   \
+	} while (0)
+	db->hdr = h;
+	SECTION(translate, uint8_t, 256);
+	SECTION(state_table, int32_t, (size_t)h->dense_states * h->alphabet_size);
+	SECTION(failure, int32_t, h->state_count);
+	SECTION(depth, int32_t, h->state_count);
+	SECTION(sparse_index, int32_t, sparse_rows);
+	SECTION(sparse_chars, uint8_t, h->sparse_count);
+	SECTION(sparse_next, int32_t, h->sparse_count);
+	SECTION(output_index, uint32_t, (size_t)h->state_count + 1);
+	SECTION(outputs, uint32_t, h->output_count);
+	SECTION(patterns, struct acks_pattern, h->pattern_count);
+	SECTION(strings, uint8_t, h->strings_size);
+	SECTION(meta, uint8_t, h->version >= 5 ? h->meta_size : 0);
+#undef SECTION
+	for (uint32_t k = 0; k < h->pattern_count; k++)
+		if (db->patterns[k].flags & ~(uint32_t)ACKS_KNOWN_FLAGS)
+			return -1;
+	return pos == size - 8 ? 0 : -1;
+}
+
+static int32_t next_state(const struct acks_db *db, int32_t s, uint8_t tc)
+{
+	uint32_t dense = db->hdr->dense_states;
+	while ((uint32_t)s >= dense) {
+		int32_t row = s - (int32_t)dense;
+		for (int32_t j = db->sparse_index[row]; j < db->sparse_index[row + 1]; j++)
+			if (db->sparse_chars[j] == tc)
+				return db->sparse_next[j];
+		s = db->failure[s];
+	}
+	return db->state_table[(size_t)s * db->hdr->alphabet_size + tc];
+}
+
+static uint8_t lower(uint8_t b) { return b >= 'A' && b <= 'Z' ? b + 32 : b; }
+
+static int is_word(uint8_t b)
+{
+	return b == '_' || (b >= '0' && b <= '9') || (lower(b) >= 'a' && lower(b) <= 'z');
+}
+
+/* equal compares n content bytes of p from offset off with b. */
+static int equal(const struct acks_db *db, const struct acks_pattern *p,
+                 size_t off, const uint8_t *b, size_t n)
+{
+	const uint8_t *a = db->strings + p->content_off + off;
+	const struct acks_class *c =
+	    (const struct acks_class *)(db->strings + p->classes_off);
+	const struct acks_class *end = c + p->class_count;
+
+	if (p->class_count == 0 && !(p->flags & ACKS_CASELESS))
+		return memcmp(a, b, n) == 0;
+	while (c < end && c->offset < off)
+		c++;
+	for (size_t i = 0; i < n; i++) {
+		if (c < end && c->offset == off + i) {
+			if (!(c->set[b[i] >> 3] & (1 << (b[i] & 7))))
+				return 0;
+			c++;
+		} else if (a[i] != b[i] &&
+		           (!(p->flags & ACKS_CASELESS) || lower(a[i]) != lower(b[i]))) {
+			return 0;
+		}
+	}
+	return 1;
+}
+
+int acks_scan(const struct acks_db *db, const uint8_t *text, size_t len,
+              acks_match_fn fn, void *ctx)
+{
+	uint32_t flags = db->hdr->flags;
+	int32_t s = 0;
+
+	for (size_t i = 0; i < len; i++) {
+		if (flags & ACKS_FLAG_NIBBLE) {
+			uint8_t b = flags & ACKS_FLAG_FOLD_CASE ? lower(text[i]) : text[i];
+			s = next_state(db, s, b >> 4);
+			s = next_state(db, s, b & 0x0f);
+		} else {
+			s = next_state(db, s, db->translate[text[i]]);
+		}
+		for (uint32_t k = db->output_index[s]; k < db->output_index[s + 1]; k++) {
+			const struct acks_pattern *p = &db->patterns[db->outputs[k]];
+			size_t end = i + 1, tail = p->content_len - p->plen;
+			/* With folded letters, case-sensitive candidates are verified;
+			 * with shared byte class codes, all of them are. */
+			if (((flags & ACKS_FLAG_VERIFY_ALL) ||
+			     ((flags & ACKS_FLAG_FOLD_CASE) && !(p->flags & ACKS_CASELESS))) &&
+			    !equal(db, p, 0, text + end - p->plen, p->plen))
+				continue;
+			if (tail > 0) {
+				if (len - end < tail || !equal(db, p, p->plen, text + end, tail))
+					continue;
+				end += tail;
+			}
+			size_t from = end - p->content_len;
+			if ((p->flags & ACKS_LINE_START) && from > 0 && text[from - 1] != '\n')
+				continue;
+			if ((p->flags & ACKS_LINE_END) && end < len &&
+			    text[end] != '\n' && text[end] != '\r')
+				continue;
+			if ((p->flags & ACKS_WORD_BOUNDARY) &&
+			    ((from > 0 && is_word(text[from - 1])) || (end < len && is_word(text[end]))))
+				continue;
+			int rc = fn(ctx, p, from, end);
+			if (rc)
+				return rc;
+		}
+	}
+	return 0;
+}
@@ -0,0 +1,122 @@
+/*
+ * acks.h - reference C reader for databases written by the Go package
+ * github.com/yanlinLiu0424/ahocorasick (ACKS.WriteTo).
+ *
+ * The image is little-endian and every section starts on an 8-byte
+ * boundary. Sections follow the 64-byte header in this order:
+ *
+ *   translate    uint8_t[256]
+ *   state_table  int32_t[dense_states * alphabet_size]
+ *   failure      int32_t[state_count]
+ *   depth        int32_t[state_count]
+ *   sparse_index int32_t[state_count - dense_states + 1], absent if equal
+ *   sparse_chars uint8_t[sparse_count]
+ *   sparse_next  int32_t[sparse_count]
+ *   output_index uint32_t[state_count + 1]
+ *   outputs      uint32_t[output_count]
+ *   patterns     struct acks_pattern[pattern_count]
+ *   strings      uint8_t[strings_size], starting with struct acks_class
+ *                records
+ *   meta         uint8_t[meta_size], key/value pairs of build metadata
+ *   trailer      uint32_t crc32 (IEEE) of all preceding bytes, uint32_t 0
+ *
+ * This reader assumes a little-endian host and an 8-byte aligned image.
+ */
+#ifndef ACKS_H
+#define ACKS_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+#define ACKS_MAGIC "ACKSDB\0\0"
+#define ACKS_VERSION 5
+#define ACKS_HEADER_SIZE 64
+#define ACKS_PATTERN_SIZE 48
+#define ACKS_CLASS_SIZE 36
+
+/* acks_header.flags */
+#define ACKS_FLAG_NIBBLE 0x1
+#define ACKS_FLAG_FOLD_CASE 0x2
+#define ACKS_FLAG_UNUSED_CODE 0x4
+#define ACKS_FLAG_EXACT_CASE 0x8
+#define ACKS_FLAG_VERIFY_ALL 0x10
+
+/* acks_pattern.flags, the Go Flag values. A reader must reject a pattern
+ * with any other bit set rather than match it without the rule it names. */
+#define ACKS_CASELESS 0x1
+#define ACKS_SINGLE_MATCH 0x2
+#define ACKS_LINE_START 0x4 /* match only at the start of a line */
+#define ACKS_LINE_END 0x8   /* match only before '\n', '\r' or the end */
+#define ACKS_EXPAND_ENCODINGS 0x10 /* build time only, no effect on matching */
+#define ACKS_WORD_BOUNDARY 0x20 /* match only a whole word */
+#define ACKS_KNOWN_FLAGS 0x3f
+
+struct acks_header {
+	char magic[8];
+	uint32_t version;
+	uint32_t flags;
+	uint32_t alphabet_size;
+	uint32_t state_count;
+	uint32_t dense_states;
+	uint32_t pattern_count;
+	uint32_t sparse_count;
+	uint32_t output_count;
+	uint32_t strings_size;
+	uint32_t prefix_len;
+	uint32_t max_pattern_len;
+	uint32_t meta_size; /* version 5, 0 before */
+	uint32_t reserved[2];
+};
+
+struct acks_pattern {
+	uint64_t id;
+	uint32_t flags;
+	uint32_t plen;        /* bytes of content stored in the automaton */
+	uint32_t content_off; /* offsets into the strings section */
+	uint32_t content_len;
+	uint32_t source_off;
+	uint32_t source_len;
+	int32_t severity;
+	uint32_t sample_rate; /* left to the caller, like SingleMatch */
+	uint32_t classes_off; /* struct acks_class records in strings */
+	uint32_t class_count;
+};
+
+/* acks_class lets content[offset] match any byte whose bit is set. */
+struct acks_class {
+	uint32_t offset;
+	uint8_t set[32];
+};
+
+/* acks_db points into a loaded image; it owns no memory. */
+struct acks_db {
+	const struct acks_header *hdr;
+	const uint8_t *translate;
+	const int32_t *state_table;
+	const int32_t *failure;
+	const int32_t *depth;
+	const int32_t *sparse_index;
+	const uint8_t *sparse_chars;
+	const int32_t *sparse_next;
+	const uint32_t *output_index;
+	const uint32_t *outputs;
+	const struct acks_pattern *patterns;
+	const uint8_t *strings;
+	const uint8_t *meta;
+};
+
+/* acks_match_fn receives each match; a non-zero return stops the scan. */
+typedef int (*acks_match_fn)(void *ctx, const struct acks_pattern *p,
+                             size_t from, size_t to);
+
+/* acks_open checks the image and fills db. Returns 0 on success. */
+int acks_open(struct acks_db *db, const void *image, size_t size);
+
+/*
+ * acks_scan scans text and calls fn for every match, like ACKS.ScanPatterns.
+ * SingleMatch is left to the caller. Returns the non-zero value from fn, or 0.
+ */
+int acks_scan(const struct acks_db *db, const uint8_t *text, size_t len,
+              acks_match_fn fn, void *ctx);
+
+#endif
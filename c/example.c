@@ -0,0 +1,47 @@
+/* example.c - scan stdin with a database: example DB < input */
+#include "acks.h"
+
+#include <stdio.h>
+#include <stdlib.h>
+
+static void *slurp(FILE *f, size_t *n)
+{
+	size_t cap = 4096;
+	/* malloc memory is suitably aligned for the image. */
+	char *buf = malloc(cap);
+	*n = 0;
+	for (size_t r; buf && (r = fread(buf + *n, 1, cap - *n, f)) > 0;) {
+		*n += r;
+		if (*n == cap)
+			buf = realloc(buf, cap *= 2);
+	}
+	return buf;
+}
+
+static int print_match(void *ctx, const struct acks_pattern *p, size_t from, size_t to)
+{
+	(void)ctx;
+	printf("%llu %zu %zu\n", (unsigned long long)p->id, from, to);
+	return 0;
+}
+
+int main(int argc, char **argv)
+{
+	struct acks_db db;
+	size_t dblen, textlen;
+	FILE *f;
+
+	if (argc != 2 || !(f = fopen(argv[1], "rb"))) {
+		fprintf(stderr, "usage: example DB < input\n");
+		return 2;
+	}
+	void *image = slurp(f, &dblen);
+	fclose(f);
+	uint8_t *text = slurp(stdin, &textlen);
+	if (!image || !text || acks_open(&db, image, dblen) != 0) {
+		fprintf(stderr, "example: cannot load %s\n", argv[1]);
+		return 1;
+	}
+	acks_scan(&db, text, textlen, print_match, NULL);
+	return 0;
+}
@@ -0,0 +1,191 @@
+package ahocorasick
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// defaultScanCache is the number of buffers ScanCached remembers unless
+// WithScanCache is given.
+const defaultScanCache = 1024
+
+// WithScanCache sets how many distinct buffers ScanCached remembers the
+// results of.
+func WithScanCache(n int) Option {
+	return func(ac *ACKS) {
+		ac.scanCache = newResultCache(max(n, 1), 0)
+	}
+}
+
+// WithMemoCache sets how many keys ScanMemo remembers the results of, and
+// for how long; ttl <= 0 keeps results until they are evicted.
+func WithMemoCache(n int, ttl time.Duration) Option {
+	return func(ac *ACKS) {
+		ac.memoCache = newResultCache(max(n, 1), ttl)
+	}
+}
+
+// ScanCached returns the set of pattern IDs found in text, like MatchSet,
+// but remembers the results for the most recently scanned distinct
+// buffers, so repeated buffers such as recurring log lines are looked up by
+// a hash of their content instead of being scanned again. Buffers are told
+// apart by a 64-bit seeded hash. A cached result reflects the matcher as it
+// was when the buffer was first scanned, before any later Mute; Build drops
+// them all. It is safe
+// for concurrent use.
+func (ac *ACKS) ScanCached(text []byte) (*MatchSet, error) {
+	ac.initCaches()
+	return ac.cachedSet(ac.scanCache, maphash.Bytes(ac.scanCache.seed, text), text)
+}
+
+// ScanMemo is ScanCached with a key chosen by the caller, such as the ID of
+// the template a message was rendered from: text is scanned only if key has
+// no result cached, and the result is then stored under key. Texts sharing
+// a key must therefore share their matches. The number of keys and how long
+// they are kept are set with WithMemoCache, by default 1024 keys without
+// expiry. Build drops every result. It is safe for concurrent use.
+func (ac *ACKS) ScanMemo(key uint64, text []byte) (*MatchSet, error) {
+	ac.initCaches()
+	return ac.cachedSet(ac.memoCache, key, text)
+}
+
+// cachedSet returns the set cached in c under key, scanning text to fill it
+// if there is none.
+func (ac *ACKS) cachedSet(c *resultCache, key uint64, text []byte) (*MatchSet, error) {
+	if s := c.get(key); s != nil {
+		return s, nil
+	}
+	s, err := ac.MatchSet(text)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, s)
+	return s.clone(), nil
+}
+
+// ForgetMemo drops the result ScanMemo cached under key, if any.
+func (ac *ACKS) ForgetMemo(key uint64) {
+	ac.initCaches()
+	ac.memoCache.remove(key)
+}
+
+// clearCaches drops the cached results, whose IDs and ranks belong to the
+// previous build.
+func (ac *ACKS) clearCaches() {
+	if ac.scanCache != nil {
+		ac.scanCache.clear()
+	}
+	if ac.memoCache != nil {
+		ac.memoCache.clear()
+	}
+}
+
+// initCaches creates the caches not set up by options.
+func (ac *ACKS) initCaches() {
+	ac.cacheOnce.Do(func() {
+		if ac.scanCache == nil {
+			ac.scanCache = newResultCache(defaultScanCache, 0)
+		}
+		if ac.memoCache == nil {
+			ac.memoCache = newResultCache(defaultScanCache, 0)
+		}
+	})
+}
+
+// resultCache is an LRU of MatchSets by key, whose entries optionally
+// expire ttl after they are stored.
+type resultCache struct {
+	mu    sync.Mutex
+	max   int
+	ttl   time.Duration
+	now   func() time.Time
+	seed  maphash.Seed
+	lru   *list.List // of *cacheEntry, most recent first
+	index map[uint64]*list.Element
+}
+
+type cacheEntry struct {
+	key     uint64
+	set     *MatchSet
+	expires time.Time
+}
+
+func newResultCache(n int, ttl time.Duration) *resultCache {
+	return &resultCache{
+		max:   n,
+		ttl:   ttl,
+		now:   time.Now,
+		seed:  maphash.MakeSeed(),
+		lru:   list.New(),
+		index: make(map[uint64]*list.Element),
+	}
+}
+
+// get returns a copy of the set stored under key, or nil if there is none
+// or it expired.
+func (c *resultCache) get(key uint64) *MatchSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.index[key]
+	if !ok {
+		return nil
+	}
+	ent := e.Value.(*cacheEntry)
+	if c.ttl > 0 && c.now().After(ent.expires) {
+		delete(c.index, key)
+		c.lru.Remove(e)
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return ent.set.clone()
+}
+
+// put stores s under key, evicting the least recently used entry when the
+// cache is full. The cache keeps s itself.
+func (c *resultCache) put(key uint64, s *MatchSet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if e, ok := c.index[key]; ok {
+		ent := e.Value.(*cacheEntry)
+		ent.set, ent.expires = s, expires
+		c.lru.MoveToFront(e)
+		return
+	}
+	if c.lru.Len() >= c.max {
+		old := c.lru.Back()
+		delete(c.index, old.Value.(*cacheEntry).key)
+		c.lru.Remove(old)
+	}
+	c.index[key] = c.lru.PushFront(&cacheEntry{key: key, set: s, expires: expires})
+}
+
+// remove drops the entry stored under key.
+func (c *resultCache) remove(key uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.index[key]; ok {
+		delete(c.index, key)
+		c.lru.Remove(e)
+	}
+}
+
+// clear drops every entry.
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	clear(c.index)
+}
+
+// len returns the number of cached entries.
+func (c *resultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
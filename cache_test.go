@@ -0,0 +1,122 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestACKS_ScanCached(t *testing.T) {
+	ac := NewACKS(WithScanCache(2))
+	for i, w := range []string{"he", "she", "hers"} {
+		ac.AddPattern(mkPat(w, uint(i+1), 0))
+	}
+	ac.Build()
+
+	for _, tt := range []struct {
+		text string
+		ids  []uint
+	}{
+		{"ushers", []uint{1, 2, 3}},
+		{"he", []uint{1}},
+		{"ushers", []uint{1, 2, 3}},
+		{"nothing", nil},
+		{"he", []uint{1}},
+	} {
+		s, err := ac.ScanCached([]byte(tt.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.IDs(); !reflect.DeepEqual(got, tt.ids) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.ids, got)
+		}
+		// Results are copies: changing one leaves the cache intact.
+		s.Clear()
+	}
+	if n := ac.scanCache.len(); n != 2 {
+		t.Errorf("Expected 2 cached buffers, got %d", n)
+	}
+
+	// A cached buffer is not scanned again.
+	ac.Mute(1)
+	if s, _ := ac.ScanCached([]byte("he")); !s.Contains(1) {
+		t.Errorf("Expected the cached result")
+	}
+	if s, _ := ac.ScanCached([]byte("ushers")); s.Contains(1) {
+		t.Errorf("Expected an evicted buffer to be scanned again")
+	}
+}
+
+func TestACKS_ScanCached_Rebuild(t *testing.T) {
+	ac := NewACKS()
+	for i := 0; i < 64; i++ {
+		ac.AddPattern(mkPat(fmt.Sprintf("w%02d", i), uint(i+10), 0))
+	}
+	ac.Build()
+	text := []byte("w05 w63")
+	if _, err := ac.ScanCached(text); err != nil {
+		t.Fatal(err)
+	}
+
+	ac.AddPattern(mkPat("w05", 1, 0))
+	ac.Build()
+	s, err := ac.ScanCached(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint{15, 73, 1}; !reflect.DeepEqual(s.IDs(), want) {
+		t.Errorf("Expected %v, got %v", want, s.IDs())
+	}
+	fresh, _ := ac.MatchSet(text)
+	s.Union(fresh)
+}
+
+func TestACKS_ScanMemo_Rebuild(t *testing.T) {
+	ac := NewACKS()
+	for i := 0; i < 64; i++ {
+		ac.AddPattern(mkPat(fmt.Sprintf("w%02d", i), uint(i+10), 0))
+	}
+	ac.Build()
+	if _, err := ac.ScanMemo(1, []byte("w05 w63")); err != nil {
+		t.Fatal(err)
+	}
+
+	ac.AddPattern(mkPat("w05", 1, 0))
+	ac.Build()
+	s, err := ac.ScanMemo(1, []byte("w05 w63"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint{15, 73, 1}; !reflect.DeepEqual(s.IDs(), want) {
+		t.Errorf("Expected %v, got %v", want, s.IDs())
+	}
+	other, _ := ac.MatchSet([]byte("w05"))
+	s.Intersect(other)
+}
+
+func TestACKS_ScanMemo(t *testing.T) {
+	ac := NewACKS(WithMemoCache(8, time.Minute))
+	ac.AddPattern(mkPat("error", 1, 0))
+	ac.AddPattern(mkPat("warn", 2, 0))
+	ac.Build()
+	now := time.Unix(1000, 0)
+	ac.initCaches()
+	ac.memoCache.now = func() time.Time { return now }
+
+	if s, _ := ac.ScanMemo(7, []byte("error: disk 1 full")); !reflect.DeepEqual(s.IDs(), []uint{1}) {
+		t.Fatalf("Unexpected result %v", s.IDs())
+	}
+	// Same key: the cached result is returned without scanning.
+	if s, _ := ac.ScanMemo(7, []byte("warn: disk 2 slow")); !reflect.DeepEqual(s.IDs(), []uint{1}) {
+		t.Errorf("Expected the memoized result, got %v", s.IDs())
+	}
+	now = now.Add(2 * time.Minute)
+	if s, _ := ac.ScanMemo(7, []byte("warn: disk 2 slow")); !reflect.DeepEqual(s.IDs(), []uint{2}) {
+		t.Errorf("Expected an expired result to be rescanned, got %v", s.IDs())
+	}
+	ac.ForgetMemo(7)
+	if s, _ := ac.ScanMemo(7, []byte("fine")); len(s.IDs()) != 0 {
+		t.Errorf("Expected a forgotten key to be rescanned, got %v", s.IDs())
+	}
+}
@@ -0,0 +1,114 @@
+package ahocorasick
+
+import (
+	"slices"
+	"strconv"
+)
+
+// CaptureKind selects the value ScanCaptures reads after an anchor pattern.
+type CaptureKind int
+
+const (
+	// CaptureInt reads a decimal integer with an optional sign.
+	CaptureInt CaptureKind = iota
+	// CaptureToken reads the bytes up to whitespace, ';', ',' or the end
+	// of the text.
+	CaptureToken
+)
+
+// defaultCaptureLen bounds captured values when CaptureAfter is given no
+// limit.
+const defaultCaptureLen = 64
+
+// Capture is a value found after a match of an anchor pattern.
+type Capture struct {
+	Match
+	// Value holds the raw bytes of the value. It aliases the scanned text.
+	Value []byte
+	// Int is the parsed value of a CaptureInt.
+	Int int64
+}
+
+type captureSpec struct {
+	id     uint
+	kind   CaptureKind
+	maxLen int
+}
+
+// CaptureAfter makes ScanCaptures read a value of the given kind after every
+// match of pattern id, as in "Content-Length:" followed by a number. Spaces
+// and tabs before the value are skipped, and a value longer than maxLen
+// bytes (64 if maxLen is 0) is not captured. Calling it again for the same
+// id replaces the earlier setting. It must not be called during scans.
+func (ac *ACKS) CaptureAfter(id uint, kind CaptureKind, maxLen int) {
+	if maxLen <= 0 {
+		maxLen = defaultCaptureLen
+	}
+	spec := captureSpec{id: id, kind: kind, maxLen: maxLen}
+	if i := slices.IndexFunc(ac.captures, func(c captureSpec) bool { return c.id == id }); i >= 0 {
+		ac.captures[i] = spec
+		return
+	}
+	ac.captures = append(ac.captures, spec)
+}
+
+// ScanCaptures scans text and calls m with the value following each match
+// of a pattern given to CaptureAfter, in match order. Matches of other
+// patterns, and anchors not followed by a valid value, are skipped.
+func (ac *ACKS) ScanCaptures(text []byte, m func(c Capture) error) error {
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		i := slices.IndexFunc(ac.captures, func(c captureSpec) bool { return c.id == ps.ID })
+		if i < 0 {
+			return nil
+		}
+		c := Capture{Match: newMatch(from, to, ps)}
+		var ok bool
+		if c.Value, c.Int, ok = ac.captures[i].read(text[to:]); !ok {
+			return nil
+		}
+		return m(c)
+	}}
+	return ac.searchPatterns(text, &h)
+}
+
+// read parses the value at the start of rest.
+func (s captureSpec) read(rest []byte) ([]byte, int64, bool) {
+	i := 0
+	for i < len(rest) && (rest[i] == ' ' || rest[i] == '\t') {
+		i++
+	}
+	start := i
+	switch s.kind {
+	case CaptureInt:
+		if i < len(rest) && (rest[i] == '+' || rest[i] == '-') {
+			i++
+		}
+		for i < len(rest) && i-start <= s.maxLen && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+	case CaptureToken:
+		for i < len(rest) && i-start <= s.maxLen && !isTokenEnd(rest[i]) {
+			i++
+		}
+	}
+	value := rest[start:i]
+	if len(value) == 0 || len(value) > s.maxLen {
+		return nil, 0, false
+	}
+	if s.kind != CaptureInt {
+		return value, 0, true
+	}
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return nil, 0, false
+	}
+	return value, n, true
+}
+
+func isTokenEnd(b byte) bool {
+	switch b {
+	case ' ', '\t', '\r', '\n', '\v', '\f', ';', ',':
+		return true
+	}
+	return false
+}
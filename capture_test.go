@@ -0,0 +1,49 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanCaptures(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("content-length:", 1, Caseless))
+	ac.AddPattern(mkPat("host:", 2, Caseless))
+	ac.AddPattern(mkPat("GET", 3, 0))
+	ac.Build()
+	ac.CaptureAfter(1, CaptureInt, 0)
+	ac.CaptureAfter(2, CaptureToken, 0)
+
+	text := []byte("GET / HTTP/1.1\r\nHost: example.com\r\nContent-Length: \t-42\r\n" +
+		"content-length:x\r\nContent-Length: 99999999999999999999\r\nHOST:a;b")
+	var got []string
+	err := ac.ScanCaptures(text, func(c Capture) error {
+		got = append(got, fmt.Sprintf("%d@%d %q %d", c.ID, c.To, c.Value, c.Int))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`2@21 "example.com" 0`, `1@50 "-42" -42`, `2@118 "a" 0`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestACKS_CaptureMaxLen(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("id=", 1, 0))
+	ac.Build()
+	ac.CaptureAfter(1, CaptureToken, 0)
+	ac.CaptureAfter(1, CaptureToken, 4)
+
+	var got []string
+	ac.ScanCaptures([]byte("id=abcd id=abcde id=ab,c"), func(c Capture) error {
+		got = append(got, string(c.Value))
+		return nil
+	})
+	if want := []string{"abcd", "ab"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
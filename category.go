@@ -0,0 +1,77 @@
+package ahocorasick
+
+// buildCategories numbers the distinct pattern categories, records the
+// numbers of each pattern's categories and collects the IDs of each.
+func (ac *ACKS) buildCategories() {
+	ac.categories = ac.categories[:0]
+	ac.categorySets = ac.categorySets[:0]
+	for _, p := range ac.patterns {
+		p.cats = p.cats[:0]
+		for _, c := range p.Categories {
+			k := ac.categoryIndex(c)
+			if k < 0 {
+				k = len(ac.categories)
+				ac.categories = append(ac.categories, c)
+				ac.categorySets = append(ac.categorySets, ac.NewMatchSet())
+			}
+			ac.categorySets[k].bits[p.slot/64] |= 1 << (p.slot % 64)
+			if !containsInt(p.cats, k) {
+				p.cats = append(p.cats, k)
+			}
+		}
+	}
+}
+
+func (ac *ACKS) categoryIndex(name string) int {
+	for k, c := range ac.categories {
+		if c == name {
+			return k
+		}
+	}
+	return -1
+}
+
+// Categories returns the distinct categories of the patterns, in order of
+// first appearance. CategoryCounts.Hits is indexed the same way.
+func (ac *ACKS) Categories() []string {
+	return ac.categories
+}
+
+// CategoryCounts holds the hits of each category found by ScanCategories.
+type CategoryCounts struct {
+	ac   *ACKS
+	Hits []uint64 // reported matches per category, indexed like Categories
+}
+
+// Count returns the hits of category, or 0 if no pattern carries it.
+func (c *CategoryCounts) Count(category string) uint64 {
+	if k := c.ac.categoryIndex(category); k >= 0 {
+		return c.Hits[k]
+	}
+	return 0
+}
+
+// Touched returns the categories with at least one hit.
+func (c *CategoryCounts) Touched() []string {
+	var cats []string
+	for k, n := range c.Hits {
+		if n > 0 {
+			cats = append(cats, c.ac.categories[k])
+		}
+	}
+	return cats
+}
+
+// ScanCategories scans text and counts the reported matches of each
+// category. A match of a pattern with several categories counts towards
+// each of them.
+func (ac *ACKS) ScanCategories(text []byte) (*CategoryCounts, error) {
+	c := &CategoryCounts{ac: ac, Hits: make([]uint64, len(ac.categories))}
+	err := ac.searchPatterns(text, &handler{fn: func(from, to uint64, ps *Pattern) error {
+		for _, k := range ps.cats {
+			c.Hits[k]++
+		}
+		return nil
+	}})
+	return c, err
+}
@@ -0,0 +1,42 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanCategories(t *testing.T) {
+	ac := NewACKS()
+	for i, p := range []struct {
+		word string
+		cats []string
+	}{
+		{"stock", []string{"finance"}},
+		{"bond", []string{"finance"}},
+		{"goal", []string{"sports"}},
+		{"bet", []string{"sports", "finance", "sports"}},
+		{"rain", []string{"weather"}},
+	} {
+		pat := mkPat(p.word, uint(i+1), 0)
+		pat.Categories = p.cats
+		ac.AddPattern(pat)
+	}
+	ac.Build()
+	if got := ac.Categories(); !reflect.DeepEqual(got, []string{"finance", "sports", "weather"}) {
+		t.Fatalf("Unexpected categories %v", got)
+	}
+
+	c, err := ac.ScanCategories([]byte("a bet on the bond, a bet on the goal"))
+	if err != nil {
+		t.Fatalf("ScanCategories failed: %v", err)
+	}
+	if !reflect.DeepEqual(c.Hits, []uint64{3, 3, 0}) {
+		t.Errorf("Expected hits [3 3 0], got %v", c.Hits)
+	}
+	if c.Count("sports") != 3 || c.Count("music") != 0 {
+		t.Errorf("Unexpected counts %d, %d", c.Count("sports"), c.Count("music"))
+	}
+	if got := c.Touched(); !reflect.DeepEqual(got, []string{"finance", "sports"}) {
+		t.Errorf("Unexpected touched categories %v", got)
+	}
+}
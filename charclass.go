@@ -0,0 +1,219 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+)
+
+// ByteSet is a set of byte values.
+type ByteSet [4]uint64
+
+// ByteRange returns the set of bytes from lo to hi inclusive.
+func ByteRange(lo, hi byte) ByteSet {
+	var s ByteSet
+	for b := int(lo); b <= int(hi); b++ {
+		s.Add(byte(b))
+	}
+	return s
+}
+
+// Add adds b to the set.
+func (s *ByteSet) Add(b byte) { s[b/64] |= 1 << (b % 64) }
+
+// Has reports whether b is in the set.
+func (s *ByteSet) Has(b byte) bool { return s[b/64]&(1<<(b%64)) != 0 }
+
+// Len returns the number of bytes in the set.
+func (s *ByteSet) Len() int {
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// appendBytes appends the members of the set in increasing order.
+func (s *ByteSet) appendBytes(dst []byte) []byte {
+	for b := 0; b < 256; b++ {
+		if s.Has(byte(b)) {
+			dst = append(dst, byte(b))
+		}
+	}
+	return dst
+}
+
+// CharClass lets the pattern byte at Offset match any byte of Set, so a
+// signature such as "v[0-9].[0-9]" needs one pattern rather than one per
+// variant. The automaton branches over the class, so the matches stay exact.
+type CharClass struct {
+	Offset int
+	Set    ByteSet
+}
+
+// maxClassPaths bounds the number of trie paths the classes of one pattern
+// may expand into: the product of their sizes.
+const maxClassPaths = 1 << 16
+
+// ParseClassPattern parses a pattern written with bracketed classes, such as
+// "ver [0-9].[0-9a-f]" or "[^ ]=", into its Content and Classes. A class
+// lists bytes and ranges and is negated by a leading '^'. A backslash
+// escapes the next byte, and \xHH gives a byte in hex, inside or outside
+// classes.
+func ParseClassPattern(expr string) (Pattern, error) {
+	var p Pattern
+	for i := 0; i < len(expr); {
+		if expr[i] != '[' {
+			b, n, err := classByte(expr, i)
+			if err != nil {
+				return Pattern{}, err
+			}
+			p.Content = append(p.Content, b)
+			i += n
+			continue
+		}
+		i++
+		negate := i < len(expr) && expr[i] == '^'
+		if negate {
+			i++
+		}
+		var set ByteSet
+		for first := true; ; first = false {
+			if i >= len(expr) {
+				return Pattern{}, fmt.Errorf("ahocorasick: unterminated class in %q", expr)
+			}
+			if expr[i] == ']' && !first {
+				i++
+				break
+			}
+			lo, n, err := classByte(expr, i)
+			if err != nil {
+				return Pattern{}, err
+			}
+			i += n
+			hi := lo
+			if i+1 < len(expr) && expr[i] == '-' && expr[i+1] != ']' {
+				if hi, n, err = classByte(expr, i+1); err != nil {
+					return Pattern{}, err
+				}
+				if hi < lo {
+					return Pattern{}, fmt.Errorf("ahocorasick: invalid range %q-%q in %q", lo, hi, expr)
+				}
+				i += 1 + n
+			}
+			r := ByteRange(lo, hi)
+			for w := range set {
+				set[w] |= r[w]
+			}
+		}
+		if negate {
+			for w := range set {
+				set[w] = ^set[w]
+			}
+		}
+		if set.Len() == 0 {
+			return Pattern{}, fmt.Errorf("ahocorasick: empty class in %q", expr)
+		}
+		p.Classes = append(p.Classes, CharClass{Offset: len(p.Content), Set: set})
+		p.Content = append(p.Content, set.appendBytes(nil)[0])
+	}
+	return p, nil
+}
+
+// classByte decodes the possibly escaped byte at expr[i] and returns it with
+// the number of bytes it spans.
+func classByte(expr string, i int) (byte, int, error) {
+	if expr[i] != '\\' {
+		return expr[i], 1, nil
+	}
+	if i+1 >= len(expr) {
+		return 0, 0, fmt.Errorf("ahocorasick: trailing backslash in %q", expr)
+	}
+	if expr[i+1] != 'x' {
+		return expr[i+1], 2, nil
+	}
+	if i+4 > len(expr) {
+		return 0, 0, fmt.Errorf("ahocorasick: short \\x escape in %q", expr)
+	}
+	v, err := strconv.ParseUint(expr[i+2:i+4], 16, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ahocorasick: invalid \\x escape in %q", expr)
+	}
+	return byte(v), 4, nil
+}
+
+// prepareClasses checks the classes of p and puts them in canonical form:
+// sorted by offset, both cases of letters in Caseless patterns, and the
+// Content byte at each offset replaced by the smallest byte of the set, so
+// Content is always one of the texts the pattern matches.
+func prepareClasses(p *Pattern) error {
+	if len(p.Classes) == 0 {
+		p.Classes, p.sets = nil, nil
+		return nil
+	}
+	classes := slices.Clone(p.Classes)
+	slices.SortFunc(classes, func(a, b CharClass) int { return a.Offset - b.Offset })
+	content := slices.Clone(p.Content)
+	paths := 1
+	for i := range classes {
+		c := &classes[i]
+		if c.Offset < 0 || c.Offset >= len(content) {
+			return fmt.Errorf("ahocorasick: pattern %d: class offset %d out of range", p.ID, c.Offset)
+		}
+		if i > 0 && c.Offset == classes[i-1].Offset {
+			return fmt.Errorf("ahocorasick: pattern %d: two classes at offset %d", p.ID, c.Offset)
+		}
+		if p.Flags&Caseless != 0 {
+			for b := byte('a'); b <= 'z'; b++ {
+				if c.Set.Has(b) || c.Set.Has(b-32) {
+					c.Set.Add(b)
+					c.Set.Add(b - 32)
+				}
+			}
+		}
+		n := c.Set.Len()
+		if n == 0 {
+			return fmt.Errorf("ahocorasick: pattern %d: empty class at offset %d", p.ID, c.Offset)
+		}
+		if paths *= n; paths > maxClassPaths {
+			return fmt.Errorf("ahocorasick: pattern %d: classes expand to more than %d paths", p.ID, maxClassPaths)
+		}
+		content[c.Offset] = c.Set.appendBytes(nil)[0]
+	}
+	p.Content, p.Classes = content, classes
+	p.indexClasses()
+	return nil
+}
+
+// indexClasses sets p.sets from p.Classes.
+func (p *Pattern) indexClasses() {
+	p.sets = nil
+	if len(p.Classes) == 0 {
+		return
+	}
+	p.sets = make([]*ByteSet, len(p.Content))
+	for i := range p.Classes {
+		p.sets[p.Classes[i].Offset] = &p.Classes[i].Set
+	}
+}
+
+// classAt returns the class at offset i of p, or nil.
+func (p *Pattern) classAt(i int) *ByteSet {
+	if p.sets == nil {
+		return nil
+	}
+	return p.sets[i]
+}
+
+// classCodes appends the distinct codes of the bytes of set.
+func (ac *ACKS) classCodes(dst []uint8, set *ByteSet) []uint8 {
+	for b := 0; b < 256; b++ {
+		if set.Has(byte(b)) {
+			if c := ac.translateTable[b]; !slices.Contains(dst, c) {
+				dst = append(dst, c)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,137 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// naiveMatches finds the patterns of ac at every end offset of text by
+// direct comparison, in the order the automaton reports them.
+func naiveMatches(ac *ACKS, text []byte) []Match {
+	var got []Match
+	for end := 1; end <= len(text); end++ {
+		var at []*Pattern
+		for _, p := range ac.patterns {
+			if p.strlen <= end && equalPattern(p, 0, text[end-p.strlen:end]) {
+				at = append(at, p)
+			}
+		}
+		sort.SliceStable(at, func(i, j int) bool { return at[i].strlen > at[j].strlen })
+		for _, p := range at {
+			got = append(got, newMatch(uint64(end-p.strlen), uint64(end), p))
+		}
+	}
+	return got
+}
+
+func TestParseClassPattern(t *testing.T) {
+	p, err := ParseClassPattern(`v[0-9].[^.\]][\x41-C-]\[`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Content) != "v0.\x00-[" || len(p.Classes) != 3 {
+		t.Fatalf("Unexpected pattern %q %v", p.Content, p.Classes)
+	}
+	for i, want := range []struct {
+		offset int
+		in     string
+		out    string
+	}{{1, "09", "/:a"}, {3, "a\x00\xff", ".]"}, {4, "ABC-", "D@"}} {
+		c := p.Classes[i]
+		if c.Offset != want.offset {
+			t.Errorf("class %d: Expected offset %d, got %d", i, want.offset, c.Offset)
+		}
+		for _, b := range []byte(want.in) {
+			if !c.Set.Has(b) {
+				t.Errorf("class %d: Expected %q in the set", i, b)
+			}
+		}
+		for _, b := range []byte(want.out) {
+			if c.Set.Has(b) {
+				t.Errorf("class %d: Unexpected %q in the set", i, b)
+			}
+		}
+	}
+	for _, expr := range []string{"[a-", "[z-a]", `a\`, `\x4`, `[^\x00-\xff]`} {
+		if _, err := ParseClassPattern(expr); err == nil {
+			t.Errorf("%q: Expected error", expr)
+		}
+	}
+}
+
+func TestACKS_CharClasses(t *testing.T) {
+	exprs := []string{"v[0-9].[0-9]", "[a-c]b", "x[yz]", "[0-9][0-9]x", "ab", "[aeiou]-[Ab]", "[xy][0-9]"}
+	optSets := [][]Option{nil, {WithLongPatternPrefix(2)}, {WithDenseStates(2)}, {WithByteClasses(DigitClass)}, {WithQuickReject()}}
+	r := uint32(7)
+	for _, opts := range optSets {
+		for _, n := range []int{2, len(exprs)} {
+			ac := NewACKS(opts...)
+			for i, expr := range exprs[:n] {
+				p, err := ParseClassPattern(expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.ID, p.Flags = uint(i+1), Flag(i%2)*Caseless
+				if err := ac.AddPattern(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := ac.Build(); err != nil {
+				t.Fatal(err)
+			}
+			if err := ac.Verify(); err != nil {
+				t.Fatal(err)
+			}
+			for k := 0; k < 300; k++ {
+				text := make([]byte, k%50)
+				for i := range text {
+					r = r*1103515245 + 12345
+					text[i] = "v0.9aBcxyZ-E1u"[r>>16%14]
+				}
+				want := naiveMatches(ac, text)
+				if got := ac.FindN(text, 0); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v, %d patterns, %q: Expected %v, got %v", opts, n, text, want, got)
+				}
+				if got := dfaMatches(ac, string(text)); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v, %d patterns, %q stream: Expected %v, got %v", opts, n, text, want, got)
+				}
+			}
+
+			var buf bytes.Buffer
+			ac.WriteTo(&buf)
+			loaded, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(ac, loaded) {
+				t.Fatalf("%v: loaded matcher differs", opts)
+			}
+			text := []byte("v1.2 cb AB-b xz 42x")
+			if got, want := loaded.FindN(text, 0), ac.FindN(text, 0); !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: Expected %v after loading, got %v", opts, want, got)
+			}
+		}
+	}
+}
+
+func TestACKS_CharClassErrors(t *testing.T) {
+	ac := NewACKS()
+	for _, p := range []Pattern{
+		{Content: []byte("ab"), Classes: []CharClass{{Offset: 2, Set: ByteRange('0', '9')}}},
+		{Content: []byte("ab"), Classes: []CharClass{{Offset: 0}}},
+		{Content: []byte("ab"), Classes: []CharClass{{Offset: 1, Set: ByteRange(0, 1)}, {Offset: 1, Set: ByteRange(0, 1)}}},
+		{Content: make([]byte, 3), Classes: []CharClass{{0, ByteRange(0, 99)}, {1, ByteRange(0, 99)}, {2, ByteRange(0, 99)}}},
+	} {
+		if err := ac.AddPattern(p); err == nil {
+			t.Errorf("%v: Expected error", p.Classes)
+		}
+	}
+
+	ac = NewACKS(WithNibbleAlphabet())
+	ac.AddPattern(Pattern{Content: []byte("a1"), Classes: []CharClass{{Offset: 1, Set: ByteRange('0', '9')}}})
+	if err := ac.Build(); err == nil {
+		t.Errorf("Expected error in nibble mode")
+	}
+}
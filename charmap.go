@@ -0,0 +1,88 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Charmap describes a single-byte encoding by the rune each byte decodes to.
+type Charmap [256]rune
+
+// Latin1 is ISO-8859-1, where every byte decodes to the code point of the
+// same value.
+var Latin1 = func() *Charmap {
+	var c Charmap
+	for i := range c {
+		c[i] = rune(i)
+	}
+	return &c
+}()
+
+// Windows1252 is Latin1 with printable characters in 0x80-0x9F. Bytes that
+// are undefined in Windows-1252 decode to the C1 control of the same value.
+var Windows1252 = func() *Charmap {
+	c := *Latin1
+	for b, r := range map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+	} {
+		c[b] = r
+	}
+	return &c
+}()
+
+// Encode converts UTF-8 text into the single-byte encoding, so patterns can be
+// matched directly against inputs in that encoding. It fails on runes the
+// encoding cannot represent.
+func (c *Charmap) Encode(s []byte) ([]byte, error) {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRune(s[i:])
+		b, ok := c.lookup(r)
+		if !ok {
+			return nil, fmt.Errorf("ahocorasick: rune %U at offset %d has no single-byte encoding", r, i)
+		}
+		out = append(out, b)
+		i += size
+	}
+	return out, nil
+}
+
+func (c *Charmap) lookup(r rune) (byte, bool) {
+	for b, cr := range c {
+		if cr == r {
+			return byte(b), true
+		}
+	}
+	return 0, false
+}
+
+// CharmapNormalizer decodes single-byte encoded text into UTF-8 before
+// matching, so patterns authored in UTF-8 match Latin-1 or Windows-1252 input
+// with offsets reported in the original bytes. It only applies to the text.
+type CharmapNormalizer struct {
+	Charmap *Charmap
+}
+
+func (CharmapNormalizer) TextOnly() bool { return true }
+
+func (n CharmapNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	for i, b := range src {
+		r := n.Charmap[b]
+		if r < utf8.RuneSelf {
+			dst = append(dst, byte(r))
+			offsets = append(offsets, i)
+			continue
+		}
+		start := len(dst)
+		dst = utf8.AppendRune(dst, r)
+		for j := start; j < len(dst); j++ {
+			offsets = append(offsets, i)
+		}
+	}
+	return dst, append(offsets, len(src))
+}
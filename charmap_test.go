@@ -0,0 +1,37 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCharmap_DecodeAndEncode(t *testing.T) {
+	// The pattern is authored in UTF-8 and the text is Windows-1252.
+	latin := NewACKS(WithNormalizers(CharmapNormalizer{Charmap: Windows1252}))
+	latin.AddPattern(mkPat("café €", 1, 0))
+	latin.Build()
+
+	var ends []uint64
+	err := latin.Scan([]byte("un caf\xe9 \x80!"), func(id uint, from, to uint64) error {
+		ends = append(ends, to)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if !reflect.DeepEqual(ends, []uint64{9}) {
+		t.Errorf("Expected match ending at 9, got %v", ends)
+	}
+
+	// The reverse direction: encode a UTF-8 pattern and scan Latin-1 bytes as is.
+	enc, err := Windows1252.Encode([]byte("café €"))
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if string(enc) != "caf\xe9 \x80" {
+		t.Errorf("Unexpected encoding %q", enc)
+	}
+	if _, err := Latin1.Encode([]byte("€")); err == nil {
+		t.Errorf("Expected error encoding € as Latin-1")
+	}
+}
@@ -0,0 +1,189 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+)
+
+// defaultCheckpointEvery is how many bytes ScanFileResumable scans between
+// checkpoints unless CheckpointEvery is given.
+const defaultCheckpointEvery = 64 << 20
+
+// CheckpointEvery sets how many bytes ScanFileResumable scans between
+// checkpoints.
+func CheckpointEvery(n int64) FileOption {
+	return func(c *fileConfig) {
+		c.checkpointEvery = n
+	}
+}
+
+// checkpointMagic starts every checkpoint record.
+const checkpointMagic = "ACKT"
+
+// ScanFileResumable scans the file at path like ScanFile, periodically
+// appending the scan position to checkpoint. If checkpoint already holds
+// records, as the file left by an interrupted run does, the scan resumes
+// from the last complete one instead of the start of the file; a torn
+// final record is ignored. Matches between the last checkpoint and an
+// interruption are reported again on resume. A final checkpoint is written
+// at the end of the file, so a finished scan resumes to nothing. The
+// checkpoint must come from the same matcher, and the file must not have
+// changed.
+func (ac *ACKS) ScanFileResumable(path string, checkpoint io.ReadWriter, m MatchedHandler, opts ...FileOption) error {
+	cfg := fileConfig{checkpointEvery: defaultCheckpointEvery}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	old, err := io.ReadAll(checkpoint)
+	if err != nil {
+		return err
+	}
+	tok, ok, err := ac.lastCheckpoint(old)
+	if err != nil {
+		return err
+	}
+	st := ac.NewStream()
+	if ok {
+		if err := st.ResumeFrom(tok); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if uint64(fi.Size()) < st.offset {
+		return fmt.Errorf("ahocorasick: %s is shorter than its checkpoint", path)
+	}
+	if cfg.chunkSize <= 0 {
+		cfg.chunkSize = chooseChunkSize(fi.Size())
+	}
+	if _, err := f.Seek(int64(st.offset), io.SeekStart); err != nil {
+		return err
+	}
+
+	save := func() error {
+		_, err := checkpoint.Write(ac.appendCheckpoint(nil, st.SaveState()))
+		return err
+	}
+	buf := make([]byte, cfg.chunkSize)
+	last := st.offset
+	h := handler{scan: m}
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			if err := ac.scanStream(st, buf[:n], &h); err != nil {
+				return err
+			}
+			if cfg.checkpointEvery > 0 && st.offset-last >= uint64(cfg.checkpointEvery) {
+				if err := save(); err != nil {
+					return err
+				}
+				last = st.offset
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			if err := ac.finishStream(st, &h); err != nil {
+				return err
+			}
+			return save()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// appendCheckpoint appends a record holding tok: the magic, the payload
+// length, the payload and its CRC-32.
+func (ac *ACKS) appendCheckpoint(b []byte, tok StateToken) []byte {
+	le := binary.LittleEndian
+	var p []byte
+	p = le.AppendUint32(p, uint32(ac.stateCount))
+	p = le.AppendUint32(p, uint32(len(ac.patterns)))
+	p = le.AppendUint32(p, uint32(tok.state))
+	p = le.AppendUint64(p, tok.offset)
+	p = le.AppendUint64(p, tok.base)
+	p = le.AppendUint32(p, uint32(len(tok.history)))
+	p = append(p, tok.history...)
+	p = le.AppendUint32(p, uint32(len(tok.pending)))
+	for _, t := range tok.pending {
+		p = le.AppendUint32(p, uint32(slices.Index(ac.patterns, t.pat)))
+		p = le.AppendUint32(p, uint32(t.done))
+		p = le.AppendUint64(p, t.end)
+	}
+	b = append(b, checkpointMagic...)
+	b = le.AppendUint32(b, uint32(len(p)))
+	b = append(b, p...)
+	return le.AppendUint32(b, crc32.ChecksumIEEE(p))
+}
+
+// lastCheckpoint decodes the last complete record in data. A damaged or
+// truncated record ends the search, as left by a write cut short.
+func (ac *ACKS) lastCheckpoint(data []byte) (StateToken, bool, error) {
+	le := binary.LittleEndian
+	var payload []byte
+	for len(data) >= 8 && string(data[:4]) == checkpointMagic {
+		n := uint64(le.Uint32(data[4:]))
+		if uint64(len(data)) < 12+n {
+			break
+		}
+		p := data[8 : 8+n]
+		if le.Uint32(data[8+n:]) != crc32.ChecksumIEEE(p) {
+			break
+		}
+		payload, data = p, data[12+n:]
+	}
+	if payload == nil {
+		return StateToken{}, false, nil
+	}
+	tok, err := ac.parseCheckpoint(payload)
+	return tok, err == nil, err
+}
+
+func (ac *ACKS) parseCheckpoint(p []byte) (StateToken, error) {
+	le := binary.LittleEndian
+	r := bytes.NewReader(p)
+	var head struct {
+		States, Patterns uint32
+		State            int32
+		Offset, Base     uint64
+		HistoryLen       uint32
+	}
+	if binary.Read(r, le, &head) != nil || int(head.States) != ac.stateCount ||
+		int(head.Patterns) != len(ac.patterns) || uint64(head.HistoryLen) > uint64(r.Len()) {
+		return StateToken{}, errForeignToken
+	}
+	tok := StateToken{state: head.State, offset: head.Offset, base: head.Base, history: make([]byte, head.HistoryLen)}
+	var count uint32
+	if _, err := io.ReadFull(r, tok.history); err != nil || binary.Read(r, le, &count) != nil {
+		return StateToken{}, errForeignToken
+	}
+	for i := uint32(0); i < count; i++ {
+		var rec struct {
+			Pattern, Done uint32
+			End           uint64
+		}
+		if binary.Read(r, le, &rec) != nil || int(rec.Pattern) >= len(ac.patterns) {
+			return StateToken{}, errForeignToken
+		}
+		pat := ac.patterns[rec.Pattern]
+		if int(rec.Done) > pat.strlen-pat.plen {
+			return StateToken{}, errForeignToken
+		}
+		tok.pending = append(tok.pending, pendingTail{pat: pat, done: int(rec.Done), end: rec.End})
+	}
+	return tok, nil
+}
@@ -0,0 +1,80 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanFileResumable(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "hers", "a long pattern"}, WithLongPatternPrefix(4))
+	text := strings.Repeat("ushers and a long pattern, ", 40)
+	path := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(path, []byte(text), 0o644)
+
+	var want []uint64
+	ac.Scan([]byte(text), func(id uint, from, to uint64) error {
+		want = append(want, to)
+		return nil
+	})
+
+	// Interrupt the scan after a while, then resume it.
+	stop := errors.New("interrupted")
+	var ckpt bytes.Buffer
+	var got []uint64
+	err := ac.ScanFileResumable(path, &ckpt, func(id uint, from, to uint64) error {
+		if to > 500 {
+			return stop
+		}
+		got = append(got, to)
+		return nil
+	}, FileChunkSize(7), CheckpointEvery(50))
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected the handler error, got %v", err)
+	}
+	// A torn record at the end is ignored.
+	ckpt.WriteString(checkpointMagic + "\x40\x00")
+
+	tok, _, _ := ac.lastCheckpoint(ckpt.Bytes())
+	got = got[:len(got)-countAfter(got, tok.Offset())]
+	resume := bytes.NewBuffer(ckpt.Bytes())
+	err = ac.ScanFileResumable(path, resume, func(id uint, from, to uint64) error {
+		got = append(got, to)
+		return nil
+	}, FileChunkSize(7), CheckpointEvery(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// A finished scan resumes to nothing.
+	done := bytes.NewBuffer(resume.Bytes())
+	if err := ac.ScanFileResumable(path, done, func(id uint, from, to uint64) error {
+		t.Errorf("Unexpected match at %d", to)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := buildWords([]string{"x"})
+	if err := other.ScanFileResumable(path, bytes.NewBuffer(ckpt.Bytes()), func(uint, uint64, uint64) error { return nil }); err == nil {
+		t.Errorf("Expected error for a checkpoint of another matcher")
+	}
+}
+
+// countAfter counts the offsets past off.
+func countAfter(offsets []uint64, off uint64) int {
+	n := 0
+	for _, o := range offsets {
+		if o > off {
+			n++
+		}
+	}
+	return n
+}
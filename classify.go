@@ -0,0 +1,45 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Threshold requires at least MinDistinct distinct pattern IDs of Category
+// to match for a document to be classified into it.
+type Threshold struct {
+	Category    string
+	MinDistinct int
+}
+
+// Verdict is the outcome of one Threshold.
+type Verdict struct {
+	Category string
+	Distinct int  // distinct IDs of the category found
+	Matched  bool // Distinct reached the threshold
+}
+
+// Classify scans text once and returns a verdict for each threshold, in the
+// same order. Repeated matches of an ID count once. A threshold naming a
+// category no pattern carries is an error.
+func (ac *ACKS) Classify(text []byte, thresholds []Threshold) ([]Verdict, error) {
+	cats := make([]int, len(thresholds))
+	for i, t := range thresholds {
+		if cats[i] = ac.categoryIndex(t.Category); cats[i] < 0 {
+			return nil, fmt.Errorf("ahocorasick: unknown category %q", t.Category)
+		}
+	}
+	found, err := ac.MatchSet(text)
+	if err != nil {
+		return nil, err
+	}
+	verdicts := make([]Verdict, len(thresholds))
+	for i, t := range thresholds {
+		n := 0
+		for j, w := range ac.categorySets[cats[i]].bits {
+			n += bits.OnesCount64(w & found.bits[j])
+		}
+		verdicts[i] = Verdict{Category: t.Category, Distinct: n, Matched: n >= t.MinDistinct}
+	}
+	return verdicts, nil
+}
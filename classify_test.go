@@ -0,0 +1,37 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	ac := NewACKS()
+	for i, p := range []struct {
+		word string
+		cat  string
+	}{
+		{"stock", "finance"}, {"bond", "finance"}, {"fund", "finance"},
+		{"goal", "sports"}, {"match", "sports"},
+	} {
+		pat := mkPat(p.word, uint(i+1), 0)
+		pat.Categories = []string{p.cat}
+		ac.AddPattern(pat)
+	}
+	ac.Build()
+
+	got, err := ac.Classify([]byte("stock stock stock bond, goal"), []Threshold{
+		{"finance", 3}, {"sports", 1},
+	})
+	if err != nil {
+		t.Fatalf("Classify failed: %v", err)
+	}
+	want := []Verdict{{"finance", 2, false}, {"sports", 1, true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := ac.Classify(nil, []Threshold{{"music", 1}}); err == nil {
+		t.Errorf("Expected an error for an unknown category")
+	}
+}
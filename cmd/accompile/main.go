@@ -0,0 +1,124 @@
+// Command accompile compiles pattern files into a serialized matcher
+// database that can be loaded with ahocorasick.Load.
+//
+// Usage:
+//
+//	accompile [flags] -o patterns.db file...
+//
+// Every file is read in the format given by -format. The matcher is built,
+// checked with Verify, and its statistics are printed to standard output.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "accompile:", err)
+		os.Exit(1)
+	}
+}
+
+var formats = map[string]ahocorasick.PatternFormat{
+	"lines": ahocorasick.FormatLines,
+	"csv":   ahocorasick.FormatCSV,
+	"hex":   ahocorasick.FormatHex,
+	"mods":  ahocorasick.FormatModifiers,
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fl := flag.NewFlagSet("accompile", flag.ContinueOnError)
+	fl.SetOutput(stderr)
+	out := fl.String("o", "", "write the compiled database to `file`")
+	format := fl.String("format", "lines", "pattern file format: lines, csv, hex or mods")
+	dense := fl.Int("dense", 0, "keep full rows for only the first `n` states")
+	nibble := fl.Bool("nibble", false, "match on 4-bit nibbles")
+	prefix := fl.Int("prefix", 0, "store only the first `n` bytes of long patterns")
+	minLen := fl.Int("min-len", 0, "reject patterns shorter than `n` bytes")
+	verify := fl.Bool("verify", true, "check the built matcher against its patterns")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	pf, ok := formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if fl.NArg() == 0 {
+		return errors.New("no pattern files given")
+	}
+
+	var opts []ahocorasick.Option
+	if *dense > 0 {
+		opts = append(opts, ahocorasick.WithDenseStates(*dense))
+	}
+	if *nibble {
+		opts = append(opts, ahocorasick.WithNibbleAlphabet())
+	}
+	if *prefix > 0 {
+		opts = append(opts, ahocorasick.WithLongPatternPrefix(*prefix))
+	}
+	if *minLen > 0 {
+		opts = append(opts, ahocorasick.WithMinPatternLen(*minLen, ahocorasick.ShortPatternReject))
+	}
+	ac := ahocorasick.NewACKS(opts...)
+	for _, name := range fl.Args() {
+		if err := addFile(ac, name, pf); err != nil {
+			return err
+		}
+	}
+	if err := ac.Build(); err != nil {
+		return err
+	}
+	for _, w := range ac.Warnings() {
+		fmt.Fprintln(stderr, "warning:", w.Message)
+	}
+	if *verify {
+		if err := ac.Verify(); err != nil {
+			return err
+		}
+	}
+
+	s := ac.Stats()
+	e := ac.Engine()
+	fmt.Fprintf(stdout, "patterns:  %d (%d bytes)\n", s.Patterns, s.PatternBytes)
+	fmt.Fprintf(stdout, "states:    %d (%d dense)\n", s.States, s.DenseStates)
+	fmt.Fprintf(stdout, "alphabet:  %d\n", s.AlphabetSize)
+	fmt.Fprintf(stdout, "tables:    %d bytes\n", s.TableBytes)
+	fmt.Fprintf(stdout, "engine:    %s/%s\n", e.Engine, e.Acceleration)
+
+	if *out == "" {
+		return nil
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	n, err := ac.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote:     %s (%d bytes)\n", *out, n)
+	return nil
+}
+
+func addFile(ac *ahocorasick.ACKS, name string, format ahocorasick.PatternFormat) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := ac.AddPatternsFromReader(f, format); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
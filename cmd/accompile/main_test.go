@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "words.csv")
+	os.WriteFile(in, []byte("1,none,he\n2,caseless,she\n3,none,hers\n"), 0o644)
+	out := filepath.Join(dir, "words.db")
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-format", "csv", "-o", out, in}, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "patterns:  3 (9 bytes)") {
+		t.Errorf("Unexpected output:\n%s", stdout.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ac, err := ahocorasick.Load(f)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if ids, _ := ac.Search([]byte("SHErs")); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Expected [2], got %v", ids)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-format", "xml", "x"}, &stdout, &stderr); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "short.txt")
+	os.WriteFile(in, []byte("a\nlonger\n"), 0o644)
+	if err := run([]string{"-min-len", "3", in}, &stdout, &stderr); err == nil {
+		t.Errorf("Expected short patterns to be rejected")
+	}
+}
@@ -0,0 +1,59 @@
+package ahocorasick
+
+import (
+	"unicode/utf8"
+)
+
+// Confusables maps characters commonly used to obfuscate words to the ASCII
+// letter they imitate: leet-speak digits and symbols plus Cyrillic and Greek
+// homoglyphs. The letters i and l and their look-alikes share a single
+// skeleton, because "1" and "|" are used for both.
+var Confusables = map[rune]byte{
+	// Leet-speak
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'l', '|': 'l', 'i': 'l', '+': 't',
+
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'l', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'А': 'a', 'В': 'b', 'Е': 'e', 'К': 'k', 'М': 'm', 'Н': 'h', 'О': 'o', 'Р': 'p',
+	'С': 'c', 'Т': 't', 'У': 'y', 'Х': 'x', 'І': 'l', 'Ј': 'j', 'Ѕ': 's',
+
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h',
+	'Ι': 'l', 'Κ': 'k', 'Μ': 'm', 'Ν': 'n', 'Ο': 'o', 'Ρ': 'p', 'Τ': 't', 'Υ': 'y',
+	'Χ': 'x',
+}
+
+// ConfusablesNormalizer folds text to lowercase ASCII skeletons using
+// Confusables, so "Sh1t", "$hit" and "ѕhіt" all normalize to "shlt". Use it on
+// both the patterns and the text, as WithNormalizers does.
+type ConfusablesNormalizer struct{}
+
+func (ConfusablesNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	for i := 0; i < len(src); {
+		r, size := rune(src[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(src[i:])
+		} else {
+			r = rune(toLower(byte(r)))
+		}
+		if c, ok := Confusables[r]; ok {
+			dst = append(dst, c)
+			offsets = append(offsets, i)
+		} else if r < utf8.RuneSelf {
+			dst = append(dst, byte(r))
+			offsets = append(offsets, i)
+		} else {
+			for j := 0; j < size; j++ {
+				dst = append(dst, src[i+j])
+				offsets = append(offsets, i)
+			}
+		}
+		i += size
+	}
+	return dst, append(offsets, len(src))
+}
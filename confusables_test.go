@@ -0,0 +1,22 @@
+package ahocorasick
+
+import (
+	"testing"
+)
+
+func TestConfusablesNormalizer(t *testing.T) {
+	ac := NewACKS(WithNormalizers(ConfusablesNormalizer{}))
+	ac.AddPattern(mkPat("spam", 1, 0))
+	ac.AddPattern(mkPat("idiot", 2, 0))
+	ac.Build()
+
+	for _, text := range []string{"SPAM", "$p4m", "ѕрам", "1d10t", "|d!0+", "ιdιοτ"} {
+		matches, err := ac.Search([]byte(text))
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(matches) != 1 {
+			t.Errorf("%q: Expected 1 match, got %v", text, matches)
+		}
+	}
+}
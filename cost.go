@@ -0,0 +1,48 @@
+package ahocorasick
+
+import "sort"
+
+// PatternCost is the share of one pattern in the built automaton.
+type PatternCost struct {
+	ID     uint
+	Source string
+	// States is the number of states the pattern added to the automaton.
+	States int
+	// TableBytes is the memory held by those states, counted as in
+	// Stats.TableBytes.
+	TableBytes int
+}
+
+// buildCosts charges every state but the root to owner[state], an index
+// into ac.patterns.
+func (ac *ACKS) buildCosts(owner []int32) {
+	ac.costs = make([]PatternCost, len(ac.patterns))
+	for k, p := range ac.patterns {
+		ac.costs[k] = PatternCost{ID: p.ID, Source: p.Source}
+	}
+	for s := 1; s < ac.stateCount; s++ {
+		c := &ac.costs[owner[s]]
+		c.States++
+		// failure, depth, minRemain, stateHasOutput and the output slice
+		c.TableBytes += 4 + 4 + 4 + 1 + 24 + 8*len(ac.outputTable[s])
+		if s < ac.denseStates {
+			c.TableBytes += 4 * ac.alphabetSize
+		} else {
+			row := s - ac.denseStates
+			c.TableBytes += 4 + 5*int(ac.sparseIndex[row+1]-ac.sparseIndex[row])
+		}
+	}
+}
+
+// PatternCosts returns what every pattern costs in the built automaton,
+// most expensive first. A state shared by several patterns is charged to
+// the first of them added, so a cost is roughly what removing the pattern
+// would save; a pattern that is a prefix of an earlier one costs nothing.
+// Matchers returned by Load have no costs.
+func (ac *ACKS) PatternCosts() []PatternCost {
+	costs := append([]PatternCost(nil), ac.costs...)
+	sort.SliceStable(costs, func(i, j int) bool {
+		return costs[i].TableBytes > costs[j].TableBytes
+	})
+	return costs
+}
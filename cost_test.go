@@ -0,0 +1,34 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_PatternCosts(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("he"), ID: 1})
+	ac.AddPattern(Pattern{Content: []byte("hers"), ID: 2})
+	ac.AddPattern(Pattern{Content: []byte("her"), ID: 3})
+	ac.AddPattern(Pattern{Content: []byte("xyz"), ID: 4, Flags: Caseless})
+	ac.Build()
+
+	costs := ac.PatternCosts()
+	states := map[uint]int{}
+	total := 0
+	for _, c := range costs {
+		states[c.ID] = c.States
+		total += c.TableBytes
+	}
+	if want := map[uint]int{1: 2, 2: 2, 3: 0, 4: 3}; !reflect.DeepEqual(states, want) {
+		t.Errorf("Expected states %v, got %v", want, states)
+	}
+	if costs[0].ID != 4 || costs[len(costs)-1].ID != 3 || costs[len(costs)-1].TableBytes != 0 {
+		t.Errorf("Unexpected order %+v", costs)
+	}
+	// Everything but the root is charged to some pattern.
+	root := ac.Stats().TableBytes - total
+	if root <= 0 || root > 64+4*ac.AlphabetSize() {
+		t.Errorf("Costs do not add up: %d bytes left for the root", root)
+	}
+}
@@ -0,0 +1,106 @@
+package ahocorasick
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// CSVMatch is a match inside one field of delimited data. The offsets of the
+// embedded Match are relative to the unquoted field.
+type CSVMatch struct {
+	Row    int // 1-based record number, counting any header
+	Line   int // 1-based line the field starts on
+	Column int // 0-based column index
+	Match
+}
+
+// CSVOption configures ScanCSV.
+type CSVOption func(*csvConfig)
+
+type csvConfig struct {
+	comma   rune
+	header  bool
+	columns []int
+	names   []string
+}
+
+// CSVComma sets the field delimiter, e.g. '\t' for TSV. The default is ','.
+func CSVComma(r rune) CSVOption {
+	return func(c *csvConfig) {
+		c.comma = r
+	}
+}
+
+// CSVColumns limits scanning to the columns with the given 0-based indexes.
+func CSVColumns(cols ...int) CSVOption {
+	return func(c *csvConfig) {
+		c.columns = append(c.columns, cols...)
+	}
+}
+
+// CSVHeader treats the first record as a header, which is not scanned.
+func CSVHeader() CSVOption {
+	return func(c *csvConfig) {
+		c.header = true
+	}
+}
+
+// CSVColumnNames limits scanning to the columns whose header matches one of
+// names. It implies CSVHeader.
+func CSVColumnNames(names ...string) CSVOption {
+	return func(c *csvConfig) {
+		c.header = true
+		c.names = append(c.names, names...)
+	}
+}
+
+// ScanCSV reads delimited records from r and scans the selected fields one
+// by one, so a match never spans two columns and irrelevant columns cause
+// no false positives. Without CSVColumns or CSVColumnNames every field is
+// scanned. Records may have varying numbers of fields.
+func (ac *ACKS) ScanCSV(r io.Reader, m func(CSVMatch) error, opts ...CSVOption) error {
+	cfg := csvConfig{comma: ','}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = cfg.comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	selected := cfg.columns
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ahocorasick: %w", err)
+		}
+		if row == 1 && cfg.header {
+			for i, name := range rec {
+				if slices.Contains(cfg.names, name) {
+					selected = append(selected, i)
+				}
+			}
+			if len(cfg.names) > 0 && len(selected) == len(cfg.columns) {
+				return fmt.Errorf("ahocorasick: no header column named %q", cfg.names)
+			}
+			continue
+		}
+		for col, field := range rec {
+			if (cfg.columns != nil || cfg.names != nil) && !slices.Contains(selected, col) {
+				continue
+			}
+			line, _ := cr.FieldPos(col)
+			err := ac.searchPatterns([]byte(field), &handler{fn: func(from, to uint64, ps *Pattern) error {
+				return m(CSVMatch{Row: row, Line: line, Column: col, Match: newMatch(from, to, ps)})
+			}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
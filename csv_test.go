@@ -0,0 +1,39 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanCSV(t *testing.T) {
+	ac := buildWords([]string{"alice", "ssn"})
+	data := "name\tnote\temail\nalice\tssn on file\talice@example.com\nbob\tno alice\tbob@example.com\n"
+
+	var got [][3]int
+	collect := func(m CSVMatch) error {
+		got = append(got, [3]int{m.Row, m.Column, int(m.ID)})
+		return nil
+	}
+	if err := ac.ScanCSV(strings.NewReader(data), collect, CSVComma('\t')); err != nil {
+		t.Fatalf("ScanCSV failed: %v", err)
+	}
+	want := [][3]int{{2, 0, 1}, {2, 1, 2}, {2, 2, 1}, {3, 1, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = nil
+	err := ac.ScanCSV(strings.NewReader(data), collect, CSVComma('\t'), CSVColumnNames("email"), CSVColumns(0))
+	if err != nil {
+		t.Fatalf("ScanCSV failed: %v", err)
+	}
+	want = [][3]int{{2, 0, 1}, {2, 2, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if err := ac.ScanCSV(strings.NewReader(data), collect, CSVComma('\t'), CSVColumnNames("phone")); err == nil {
+		t.Errorf("Expected error for a missing column name")
+	}
+}
@@ -0,0 +1,60 @@
+package ahocorasick
+
+// BoundaryDeduper removes the duplicate matches produced when input is split
+// into overlapping chunks outside the matcher, for example by fixed record
+// framing. A match that lies entirely in the overlap between two chunks is
+// found in both; the deduper keeps the first report only.
+//
+// Call StartChunk before scanning each chunk, in input order, and pass every
+// match of the chunk, in absolute offsets, to Keep.
+type BoundaryDeduper struct {
+	overlap uint64
+	// tailStart is the offset where the overlap with the next chunk begins.
+	tailStart uint64
+	// headEnd is the offset where the overlap with the previous chunk ends.
+	headEnd uint64
+	prev    map[dedupKey]struct{}
+	cur     map[dedupKey]struct{}
+}
+
+type dedupKey struct {
+	id       uint
+	from, to uint64
+}
+
+// NewBoundaryDeduper returns a deduper for chunks that overlap their
+// predecessor by overlap bytes.
+func NewBoundaryDeduper(overlap int) *BoundaryDeduper {
+	return &BoundaryDeduper{
+		overlap: uint64(overlap),
+		prev:    make(map[dedupKey]struct{}),
+		cur:     make(map[dedupKey]struct{}),
+	}
+}
+
+// StartChunk announces the next chunk, which covers length bytes starting at
+// the absolute offset off.
+func (d *BoundaryDeduper) StartChunk(off, length uint64) {
+	d.prev, d.cur = d.cur, d.prev
+	clear(d.cur)
+	d.headEnd = off + d.overlap
+	d.tailStart = off
+	if length > d.overlap {
+		d.tailStart = off + length - d.overlap
+	}
+}
+
+// Keep reports whether m should be kept, that is whether it was not already
+// reported by the previous chunk.
+func (d *BoundaryDeduper) Keep(m Match) bool {
+	k := dedupKey{id: m.ID, from: m.From, to: m.To}
+	if m.To <= d.headEnd {
+		if _, dup := d.prev[k]; dup {
+			return false
+		}
+	}
+	if m.From >= d.tailStart {
+		d.cur[k] = struct{}{}
+	}
+	return true
+}
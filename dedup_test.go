@@ -0,0 +1,37 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoundaryDeduper(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("abc", 1, 0))
+	ac.AddPattern(mkPat("bcd", 2, 0))
+	ac.Build()
+
+	text := []byte("xxabcdxxabcxabcdx")
+	want := ac.FindN(text, 0)
+
+	const chunkLen, overlap = 7, 3
+	d := NewBoundaryDeduper(overlap)
+	var got []Match
+	for off := 0; off < len(text); off += chunkLen - overlap {
+		end := min(off+chunkLen, len(text))
+		d.StartChunk(uint64(off), uint64(end-off))
+		for _, m := range ac.FindN(text[off:end], 0) {
+			m.From += uint64(off)
+			m.To += uint64(off)
+			if d.Keep(m) {
+				got = append(got, m)
+			}
+		}
+		if end == len(text) {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
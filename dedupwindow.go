@@ -0,0 +1,26 @@
+package ahocorasick
+
+// WithDedupWindow suppresses a match when another match with the same ID
+// was reported ending at most n bytes earlier. Noisy patterns are then
+// reported about once every n bytes instead of at every occurrence, which
+// sits between per-occurrence reporting and SingleMatch. The window carries
+// across the chunks of a stream.
+func WithDedupWindow(n int) Option {
+	return func(ac *ACKS) {
+		ac.dedupWindow = uint64(max(n, 0))
+	}
+}
+
+// suppress reports whether a match of the ID in slot ending at to falls in
+// the dedup window of the last one reported, and records it otherwise.
+func (ss *scanState) suppress(slot int, to uint64, ac *ACKS) bool {
+	if ss.lastEnd == nil {
+		ss.lastEnd = make([]uint64, len(ac.ids))
+	}
+	// lastEnd is stored plus one, so zero means no match yet.
+	if last := ss.lastEnd[slot]; last > 0 && to-(last-1) <= ac.dedupWindow {
+		return true
+	}
+	ss.lastEnd[slot] = to + 1
+	return false
+}
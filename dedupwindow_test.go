@@ -0,0 +1,34 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_DedupWindow(t *testing.T) {
+	ac := buildWords([]string{"err", "warn"}, WithDedupWindow(10))
+	text := []byte("err err warn err........err warn")
+	var got [][2]uint64
+	ac.Scan(text, func(id uint, from, to uint64) error {
+		got = append(got, [2]uint64{uint64(id), to})
+		return nil
+	})
+	// err ending at 7 is within 10 bytes of the one reported at 3; those
+	// ending at 16 and 27 are not.
+	want := [][2]uint64{{1, 3}, {2, 12}, {1, 16}, {1, 27}, {2, 32}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// The window carries across stream chunks and is cleared by Reset.
+	st := ac.NewStream()
+	var n int
+	count := func(id uint, from, to uint64) error { n++; return nil }
+	ac.ScanStream(st, []byte("err e"), count)
+	ac.ScanStream(st, []byte("rr"), count)
+	st.Reset()
+	ac.ScanStream(st, []byte("err"), count)
+	if n != 2 {
+		t.Errorf("Expected 2 stream matches, got %d", n)
+	}
+}
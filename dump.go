@@ -0,0 +1,84 @@
+package ahocorasick
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Equal reports whether a and b are built into identical automata: the same
+// patterns with the same fields in the same order, the same symbol mapping
+// and the same state tables. Two matchers built from the same input with the
+// same options are always Equal, so this can assert that a rebuilt database
+// is unchanged. Metadata and the scan options a database does not store,
+// such as WithDedupWindow, are not compared.
+func Equal(a, b *ACKS) bool {
+	if a.nibble != b.nibble || a.foldCase != b.foldCase || a.unusedCode != b.unusedCode ||
+		a.exactCase != b.exactCase || a.classMerged != b.classMerged ||
+		a.alphabetSize != b.alphabetSize || a.translateTable != b.translateTable ||
+		a.stateCount != b.stateCount || a.denseStates != b.denseStates || a.prefixLen != b.prefixLen {
+		return false
+	}
+	if len(a.patterns) != len(b.patterns) {
+		return false
+	}
+	for i, p := range a.patterns {
+		q := b.patterns[i]
+		if p.ID != q.ID || p.Flags != q.Flags || string(p.Content) != string(q.Content) ||
+			!slices.Equal(p.Classes, q.Classes) || p.plen != q.plen || p.Source != q.Source ||
+			p.Severity != q.Severity || p.SampleRate != q.SampleRate || !slices.Equal(p.Categories, q.Categories) {
+			return false
+		}
+	}
+	if !slices.Equal(a.stateTable, b.stateTable) || !slices.Equal(a.failure, b.failure) ||
+		!slices.Equal(a.sparseIndex, b.sparseIndex) || !slices.Equal(a.sparseChars, b.sparseChars) ||
+		!slices.Equal(a.sparseNext, b.sparseNext) {
+		return false
+	}
+	return slices.EqualFunc(a.outputTable, b.outputTable, slices.Equal)
+}
+
+// Dump writes a canonical text description of the built automaton to w. The
+// output only depends on the automaton, so it is suitable for golden files:
+// Equal matchers produce identical dumps.
+//
+// Every line starts with a keyword: the header, the symbol mapping, one line
+// per pattern and one line per state with its failure link, outputs (pattern
+// indexes) and non-root transitions as code:state pairs.
+func (ac *ACKS) Dump(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "acks alphabet=%d states=%d dense=%d nibble=%t foldcase=%t\n",
+		ac.alphabetSize, ac.stateCount, ac.denseStates, ac.nibble, ac.foldCase)
+	if !ac.nibble {
+		bw.WriteString("translate")
+		for b, code := range ac.translateTable {
+			if code != 0 {
+				fmt.Fprintf(bw, " %02x:%d", b, code)
+			}
+		}
+		bw.WriteString("\n")
+	}
+	for i, p := range ac.patterns {
+		fmt.Fprintf(bw, "pattern %d id=%d flags=%s content=%s\n", i, p.ID, p.Flags, hex.EncodeToString(p.Content))
+	}
+	for state := 0; state < ac.stateCount; state++ {
+		fmt.Fprintf(bw, "state %d fail=%d out=%v next=", state, ac.failure[state], ac.outputTable[state])
+		sep := ""
+		for code := 0; code < ac.alphabetSize; code++ {
+			var next int
+			if state < ac.denseStates {
+				next = int(ac.stateTable[state*ac.alphabetSize+code])
+			} else {
+				next = ac.next(state, uint8(code))
+			}
+			if next != 0 {
+				fmt.Fprintf(bw, "%s%d:%d", sep, code, next)
+				sep = ","
+			}
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
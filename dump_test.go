@@ -0,0 +1,89 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"testing"
+)
+
+func buildWords(words []string, opts ...Option) *ACKS {
+	ac := NewACKS(opts...)
+	for i, w := range words {
+		ac.AddPattern(mkPat(w, uint(i+1), 0))
+	}
+	ac.Build()
+	return ac
+}
+
+func TestEqualAndDump(t *testing.T) {
+	words := []string{"he", "she", "his", "hers"}
+	a := buildWords(words)
+	b := buildWords(words)
+	if !Equal(a, b) {
+		t.Fatalf("Expected rebuilt matchers to be equal")
+	}
+
+	var da, db bytes.Buffer
+	a.Dump(&da)
+	b.Dump(&db)
+	if da.String() != db.String() {
+		t.Errorf("Expected identical dumps:\n%s\n%s", da.String(), db.String())
+	}
+
+	golden := `acks alphabet=6 states=10 dense=10 nibble=false foldcase=false
+translate 65:1 68:2 69:3 72:4 73:5
+pattern 0 id=1 flags=none content=6865
+pattern 1 id=2 flags=none content=736865
+pattern 2 id=3 flags=none content=686973
+pattern 3 id=4 flags=none content=68657273
+state 0 fail=0 out=[] next=2:1,5:2
+state 1 fail=0 out=[] next=1:3,2:1,3:4,5:2
+state 2 fail=0 out=[] next=2:5,5:2
+state 3 fail=0 out=[0] next=2:1,4:6,5:2
+state 4 fail=0 out=[] next=2:1,5:7
+state 5 fail=1 out=[] next=1:8,2:1,3:4,5:2
+state 6 fail=0 out=[] next=2:1,5:9
+state 7 fail=2 out=[2] next=2:5,5:2
+state 8 fail=3 out=[1 0] next=2:1,4:6,5:2
+state 9 fail=2 out=[3] next=2:5,5:2
+`
+	if da.String() != golden {
+		t.Errorf("Unexpected dump:\n%s", da.String())
+	}
+
+	if Equal(a, buildWords([]string{"he", "she", "his"})) {
+		t.Errorf("Expected different pattern sets to differ")
+	}
+	if Equal(a, buildWords(words, WithDenseStates(4))) {
+		t.Errorf("Expected different table layouts to differ")
+	}
+}
+
+func TestEqual_PatternFields(t *testing.T) {
+	build := func(edit func(p *Pattern)) *ACKS {
+		ac := NewACKS()
+		p := mkPat("he", 1, 0)
+		p.Source, p.Severity, p.SampleRate, p.Categories = "web.txt:1", 2, 3, []string{"pii"}
+		edit(&p)
+		ac.AddPattern(p)
+		ac.Build()
+		return ac
+	}
+	base := build(func(*Pattern) {})
+	if !Equal(base, build(func(*Pattern) {})) {
+		t.Fatalf("Expected rebuilt matchers to be equal")
+	}
+	for name, edit := range map[string]func(p *Pattern){
+		"source":     func(p *Pattern) { p.Source = "web.txt:2" },
+		"severity":   func(p *Pattern) { p.Severity = 3 },
+		"samplerate": func(p *Pattern) { p.SampleRate = 4 },
+		"categories": func(p *Pattern) { p.Categories = []string{"pii", "auth"} },
+	} {
+		if Equal(base, build(edit)) {
+			t.Errorf("Expected a different %s to differ", name)
+		}
+	}
+	long := buildWords([]string{"hello"})
+	if Equal(long, buildWords([]string{"hello"}, WithLongPatternPrefix(2))) {
+		t.Errorf("Expected a different prefix length to differ")
+	}
+}
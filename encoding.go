@@ -0,0 +1,96 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// addEncodings adds p and each distinct encoded form of its content,
+// for the ExpandEncodings flag.
+func (ac *ACKS) addEncodings(p Pattern) error {
+	if len(p.Classes) > 0 {
+		return fmt.Errorf("ahocorasick: pattern %d: ExpandEncodings does not support character classes", p.ID)
+	}
+	forms := [][]byte{p.Content}
+	for _, enc := range [][]byte{
+		urlEncode(p.Content, "0123456789ABCDEF"),
+		urlEncode(p.Content, "0123456789abcdef"),
+		htmlEscape(p.Content),
+		utf16LE(p.Content),
+	} {
+		dup := false
+		for _, f := range forms {
+			dup = dup || bytes.Equal(f, enc)
+		}
+		if !dup {
+			forms = append(forms, enc)
+		}
+	}
+	for _, f := range forms {
+		q := p
+		q.Content = f
+		if err := ac.addPattern(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// urlEncode percent-encodes every byte of b but the unreserved characters
+// of RFC 3986, with the given hex digits.
+func urlEncode(b []byte, hex string) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			out = append(out, c)
+		default:
+			out = append(out, '%', hex[c>>4], hex[c&0x0f])
+		}
+	}
+	return out
+}
+
+// htmlEscape replaces the characters that HTML escapes with entities.
+func htmlEscape(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		switch c {
+		case '&':
+			out = append(out, "&amp;"...)
+		case '<':
+			out = append(out, "&lt;"...)
+		case '>':
+			out = append(out, "&gt;"...)
+		case '"':
+			out = append(out, "&quot;"...)
+		case '\'':
+			out = append(out, "&#39;"...)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// utf16LE re-encodes UTF-8 text as UTF-16LE. Bytes that are not valid
+// UTF-8 are widened as they are.
+func utf16LE(b []byte) []byte {
+	out := make([]byte, 0, 2*len(b))
+	for len(b) > 0 {
+		r, n := utf8.DecodeRune(b)
+		if r == utf8.RuneError && n == 1 {
+			r = rune(b[0])
+		}
+		b = b[n:]
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			out = append(out, byte(r1), byte(r1>>8), byte(r2), byte(r2>>8))
+			continue
+		}
+		out = append(out, byte(r), byte(r>>8))
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package ahocorasick
+
+import (
+	"testing"
+)
+
+func TestExpandEncodings(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("<script>", 7, ExpandEncodings))
+	ac.AddPattern(mkPat("café", 8, ExpandEncodings))
+	ac.Build()
+
+	for _, text := range []string{
+		"<script>",
+		"q=%3Cscript%3E",
+		"q=%3cscript%3e",
+		"&lt;script&gt;",
+		"<\x00s\x00c\x00r\x00i\x00p\x00t\x00>\x00",
+	} {
+		ids, _ := ac.Search([]byte(text))
+		if len(ids) != 1 || ids[0] != 7 {
+			t.Errorf("%q: Expected [7], got %v", text, ids)
+		}
+	}
+	for _, text := range []string{"caf%C3%A9", "c\x00a\x00f\x00\xe9\x00"} {
+		ids, _ := ac.Search([]byte(text))
+		if len(ids) != 1 || ids[0] != 8 {
+			t.Errorf("%q: Expected [8], got %v", text, ids)
+		}
+	}
+	// Forms that coincide are only added once.
+	if n := len(ac.patterns); n != 9 {
+		t.Errorf("Expected 9 patterns, got %d", n)
+	}
+
+	if f, err := ParseModifiers("/ie"); err != nil || f != Caseless|ExpandEncodings {
+		t.Errorf("Expected caseless|expandencodings, got %v, %v", f, err)
+	}
+}
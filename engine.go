@@ -0,0 +1,45 @@
+package ahocorasick
+
+// EngineInfo describes the scanning strategy a built matcher uses, for
+// debugging performance differences across builds and platforms.
+type EngineInfo struct {
+	// Engine is the automaton layout: "dfa" for a fully dense table,
+	// "hybrid-dfa" when deep states are sparse and "nibble-dfa" for the
+	// nibble alphabet. "literal" and "literal-set" mean complete inputs are
+	// searched for one or up to four patterns directly; streams still use
+	// the automaton.
+	Engine string
+	// Acceleration is the skip-ahead used while idle at the root:
+	// "indexbyte" when searching for a single start byte, "bytemap" when
+	// testing a start byte table, or "none". "rarebyte" means complete
+	// inputs are only scanned around bytes every match contains.
+	Acceleration string
+	// PureGo reports whether the package was built with the purego tag,
+	// which replaces every assembly-backed fast path of this package with
+	// portable Go loops.
+	PureGo bool
+}
+
+// Engine returns the scanning strategy selected by Build.
+func (ac *ACKS) Engine() EngineInfo {
+	info := EngineInfo{Engine: "dfa", Acceleration: "none", PureGo: pureGo}
+	switch {
+	case len(ac.literals) == 1:
+		info.Engine = "literal"
+	case len(ac.literals) > 1:
+		info.Engine = "literal-set"
+	case ac.nibble:
+		info.Engine = "nibble-dfa"
+	case ac.denseStates < ac.stateCount:
+		info.Engine = "hybrid-dfa"
+	}
+	switch {
+	case len(ac.rareBytes) > 0:
+		info.Acceleration = "rarebyte"
+	case ac.startCount == 1:
+		info.Acceleration = "indexbyte"
+	case ac.startCount > 1:
+		info.Acceleration = "bytemap"
+	}
+	return info
+}
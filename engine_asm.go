@@ -0,0 +1,21 @@
+//go:build !purego
+
+package ahocorasick
+
+import (
+	"bytes"
+)
+
+const pureGo = false
+
+// indexByte uses the assembly implementation of the standard library where
+// one exists.
+func indexByte(b []byte, c byte) int {
+	return bytes.IndexByte(b, c)
+}
+
+// index uses the assembly implementation of the standard library where one
+// exists.
+func index(b, sep []byte) int {
+	return bytes.Index(b, sep)
+}
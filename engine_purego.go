@@ -0,0 +1,33 @@
+//go:build purego
+
+package ahocorasick
+
+const pureGo = true
+
+// indexByte is a portable loop, so purego builds run no assembly.
+func indexByte(b []byte, c byte) int {
+	for i, x := range b {
+		if x == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// index finds sep by its first byte with indexByte, then compares the rest.
+func index(b, sep []byte) int {
+	if len(sep) == 0 {
+		return 0
+	}
+	for i := 0; i+len(sep) <= len(b); i++ {
+		j := indexByte(b[i:len(b)-len(sep)+1], sep[0])
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if string(b[i:i+len(sep)]) == string(sep) {
+			return i
+		}
+	}
+	return -1
+}
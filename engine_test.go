@@ -0,0 +1,43 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestACKS_Engine(t *testing.T) {
+	tests := []struct {
+		opts  []Option
+		words []string
+		want  EngineInfo
+	}{
+		{nil, []string{"foo", "bar", "baz", "qux", "quux"}, EngineInfo{Engine: "dfa", Acceleration: "bytemap"}},
+		{nil, []string{"#foo", "#fab", "#baz", "#qux", "#quux"}, EngineInfo{Engine: "dfa", Acceleration: "indexbyte"}},
+		{nil, []string{"foo", "bar"}, EngineInfo{Engine: "literal-set", Acceleration: "bytemap"}},
+		{[]Option{WithDenseStates(2)}, []string{"foo", "bar"}, EngineInfo{Engine: "hybrid-dfa", Acceleration: "bytemap"}},
+		{[]Option{WithNibbleAlphabet()}, []string{"foo"}, EngineInfo{Engine: "nibble-dfa", Acceleration: "none"}},
+	}
+	for _, tt := range tests {
+		ac := buildWords(tt.words, tt.opts...)
+		tt.want.PureGo = pureGo
+		if got := ac.Engine(); got != tt.want {
+			t.Errorf("%v: Expected %+v, got %+v", tt.words, tt.want, got)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	for _, tt := range []struct{ b, sep string }{
+		{"hello world", "world"},
+		{"hello world", "hello"},
+		{"aaab", "aab"},
+		{"abc", "abcd"},
+		{"abc", "x"},
+		{"abc", ""},
+		{"", "a"},
+	} {
+		if got, want := index([]byte(tt.b), []byte(tt.sep)), bytes.Index([]byte(tt.b), []byte(tt.sep)); got != want {
+			t.Errorf("index(%q, %q): Expected %d, got %d", tt.b, tt.sep, want, got)
+		}
+	}
+}
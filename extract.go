@@ -0,0 +1,66 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"slices"
+)
+
+// defaultExtractWindow is the number of bytes after a match handed to its
+// extractor unless WithExtractWindow is given.
+const defaultExtractWindow = 256
+
+// WithExtractWindow sets how many bytes following a match are included in
+// the window passed to extractors registered with OnMatchExtract; n <= 0
+// keeps the default of 256.
+func WithExtractWindow(n int) Option {
+	return func(ac *ACKS) {
+		ac.extractWindow = n
+	}
+}
+
+type extractor struct {
+	id uint
+	fn func(window []byte) (any, error)
+}
+
+// OnMatchExtract registers fn to run on every match of pattern id found by
+// ScanExtract. The window it receives starts with the matched bytes and
+// extends up to the extract window past them (256 bytes by default, see
+// WithExtractWindow), or to the end of the text; it aliases the text and
+// must not be retained. Whatever fn returns is delivered as Match.Value. A
+// later call for the same id replaces fn, and a nil fn removes it. It must
+// not be called during scans.
+func (ac *ACKS) OnMatchExtract(id uint, fn func(window []byte) (any, error)) {
+	i := slices.IndexFunc(ac.extractors, func(e extractor) bool { return e.id == id })
+	switch {
+	case i >= 0 && fn == nil:
+		ac.extractors = slices.Delete(ac.extractors, i, i+1)
+	case i >= 0:
+		ac.extractors[i].fn = fn
+	case fn != nil:
+		ac.extractors = append(ac.extractors, extractor{id: id, fn: fn})
+	}
+}
+
+// ScanExtract scans text and calls m with each match, its Value set by the
+// extractor of the pattern, if it has one. An extractor error ends the scan
+// and is returned.
+func (ac *ACKS) ScanExtract(text []byte, m func(match Match) error) error {
+	window := defaultExtractWindow
+	if ac.extractWindow > 0 {
+		window = ac.extractWindow
+	}
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		match := newMatch(from, to, ps)
+		if i := slices.IndexFunc(ac.extractors, func(e extractor) bool { return e.id == ps.ID }); i >= 0 {
+			end := min(uint64(len(text)), to+uint64(window))
+			v, err := ac.extractors[i].fn(text[from:end:end])
+			if err != nil {
+				return fmt.Errorf("ahocorasick: extract pattern %d: %w", ps.ID, err)
+			}
+			match.Value = v
+		}
+		return m(match)
+	}}
+	return ac.searchPatterns(text, &h)
+}
@@ -0,0 +1,44 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanExtract(t *testing.T) {
+	ac := NewACKS(WithExtractWindow(8))
+	ac.AddPattern(mkPat("user=", 1, 0))
+	ac.AddPattern(mkPat("id", 2, 0))
+	ac.Build()
+	ac.OnMatchExtract(1, func(window []byte) (any, error) {
+		v := window[len("user="):]
+		if i := bytes.IndexByte(v, '&'); i >= 0 {
+			v = v[:i]
+		}
+		return string(v), nil
+	})
+
+	var got []any
+	err := ac.ScanExtract([]byte("id=7&user=bob&user=averylongname"), func(m Match) error {
+		got = append(got, m.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []any{nil, "bob", "averylon"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	bad := errors.New("bad value")
+	ac.OnMatchExtract(2, func([]byte) (any, error) { return nil, bad })
+	if err := ac.ScanExtract([]byte("id"), func(Match) error { return nil }); !errors.Is(err, bad) {
+		t.Errorf("Expected the extractor error, got %v", err)
+	}
+	ac.OnMatchExtract(2, nil)
+	if err := ac.ScanExtract([]byte("id"), func(Match) error { return nil }); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package ahocorasick
+
+import (
+	"runtime"
+	"sync"
+)
+
+// FairOption configures ScanFair.
+type FairOption func(*fairConfig)
+
+type fairConfig struct {
+	every  int
+	yield  func(scanned uint64) error
+	meter  *TenantMeter
+	tenant string
+}
+
+const defaultYieldEvery = 64 * 1024
+
+// FairYieldEvery sets how many bytes are scanned between yields.
+// The default is 64 KiB.
+func FairYieldEvery(n int) FairOption {
+	return func(c *fairConfig) {
+		c.every = n
+	}
+}
+
+// FairYield replaces the default runtime.Gosched with fn, which is called
+// with the number of bytes scanned so far. An error from fn stops the scan
+// and is returned, which lets a scheduler preempt or cancel long scans.
+func FairYield(fn func(scanned uint64) error) FairOption {
+	return func(c *fairConfig) {
+		c.yield = fn
+	}
+}
+
+// FairTenant charges the scanned bytes to tenant in m as the scan proceeds.
+func FairTenant(m *TenantMeter, tenant string) FairOption {
+	return func(c *fairConfig) {
+		c.meter = m
+		c.tenant = tenant
+	}
+}
+
+// ScanFair scans text like Scan, but cooperatively: after every slice of
+// FairYieldEvery bytes it charges the slice to the tenant, if any, and
+// yields, so one huge buffer cannot monopolize a goroutine shared by
+// several tenants. Matches are reported exactly as Scan would report them.
+func (ac *ACKS) ScanFair(text []byte, m MatchedHandler, opts ...FairOption) error {
+	cfg := fairConfig{every: defaultYieldEvery}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.every <= 0 {
+		cfg.every = defaultYieldEvery
+	}
+
+	h := handler{scan: m}
+	if len(ac.normalizers) > 0 {
+		text, h.offsets = ac.normalize(text)
+	}
+	st := ac.NewStream()
+	st.ss.observe = false
+	var scanned uint64
+	for len(text) > 0 {
+		n := min(cfg.every, len(text))
+		chunk := text[:n]
+		text = text[n:]
+		st.ss.stream = len(text) > 0
+		if err := ac.searchText(&st.ss, chunk, &h); err != nil {
+			return err
+		}
+		st.remember(chunk)
+		st.ss.base += uint64(n)
+		scanned += uint64(n)
+		if cfg.meter != nil {
+			cfg.meter.Add(cfg.tenant, uint64(n))
+		}
+		if len(text) == 0 {
+			break
+		}
+		if cfg.yield != nil {
+			if err := cfg.yield(scanned); err != nil {
+				return err
+			}
+		} else {
+			runtime.Gosched()
+		}
+	}
+	return nil
+}
+
+// TenantMeter counts the bytes scanned on behalf of each tenant. It is safe
+// for concurrent use.
+type TenantMeter struct {
+	mu    sync.Mutex
+	bytes map[string]uint64
+}
+
+// NewTenantMeter returns an empty TenantMeter.
+func NewTenantMeter() *TenantMeter {
+	return &TenantMeter{bytes: make(map[string]uint64)}
+}
+
+// Add charges n bytes to tenant.
+func (m *TenantMeter) Add(tenant string, n uint64) {
+	m.mu.Lock()
+	m.bytes[tenant] += n
+	m.mu.Unlock()
+}
+
+// Bytes returns the bytes charged to tenant.
+func (m *TenantMeter) Bytes(tenant string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes[tenant]
+}
+
+// Snapshot returns a copy of the per-tenant byte counts.
+func (m *TenantMeter) Snapshot() map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]uint64, len(m.bytes))
+	for k, v := range m.bytes {
+		out[k] = v
+	}
+	return out
+}
+
+// Reset clears all counts, typically at the start of an accounting period.
+func (m *TenantMeter) Reset() {
+	m.mu.Lock()
+	clear(m.bytes)
+	m.mu.Unlock()
+}
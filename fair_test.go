@@ -0,0 +1,65 @@
+package ahocorasick
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanFair(t *testing.T) {
+	ac := buildWords([]string{"needle", "haystack"})
+	text := []byte(strings.Repeat("x", 10) + "needle" + strings.Repeat("y", 20) + "haystack")
+	want := collectScan(t, ac, text)
+
+	var got [][2]uint64
+	var yields []uint64
+	meter := NewTenantMeter()
+	err := ac.ScanFair(text, func(id uint, from, to uint64) error {
+		got = append(got, [2]uint64{uint64(id), to})
+		return nil
+	}, FairYieldEvery(7), FairTenant(meter, "acme"), FairYield(func(n uint64) error {
+		yields = append(yields, n)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("ScanFair failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if len(yields) != (len(text)-1)/7 || yields[0] != 7 {
+		t.Errorf("Unexpected yields %v", yields)
+	}
+	if meter.Bytes("acme") != uint64(len(text)) || meter.Bytes("other") != 0 {
+		t.Errorf("Unexpected accounting %v", meter.Snapshot())
+	}
+}
+
+func TestACKS_ScanFair_YieldStops(t *testing.T) {
+	ac := buildWords([]string{"needle"})
+	stop := errors.New("preempted")
+	calls := 0
+	err := ac.ScanFair([]byte(strings.Repeat("x", 100)+"needle"), func(id uint, from, to uint64) error {
+		t.Errorf("Unexpected match after preemption")
+		return nil
+	}, FairYieldEvery(10), FairYield(func(uint64) error {
+		calls++
+		return stop
+	}))
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected the yield error after one call, got %v after %d", err, calls)
+	}
+}
+
+func collectScan(t *testing.T, ac *ACKS, text []byte) [][2]uint64 {
+	t.Helper()
+	var out [][2]uint64
+	if err := ac.Scan(text, func(id uint, from, to uint64) error {
+		out = append(out, [2]uint64{uint64(id), to})
+		return nil
+	}); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	return out
+}
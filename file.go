@@ -0,0 +1,147 @@
+package ahocorasick
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// FileOption configures ScanFile and ScanFileResumable.
+type FileOption func(*fileConfig)
+
+type fileConfig struct {
+	chunkSize       int
+	checkpointEvery int64
+}
+
+// FileChunkSize overrides the automatically chosen read size.
+func FileChunkSize(n int) FileOption {
+	return func(c *fileConfig) {
+		c.chunkSize = n
+	}
+}
+
+const (
+	minFileChunk = 64 * 1024
+	maxFileChunk = 4 * 1024 * 1024
+)
+
+// chooseChunkSize picks a read size for a file of the given size: large
+// enough to amortize reads, small enough to keep memory flat.
+func chooseChunkSize(size int64) int {
+	n := size / 16
+	if n < minFileChunk {
+		return minFileChunk
+	}
+	if n > maxFileChunk {
+		return maxFileChunk
+	}
+	return int(n)
+}
+
+// ScanFile scans the file at path in chunks and reports matches with offsets
+// from the start of the file. The next chunk is read in the background while
+// the current one is scanned, so I/O and matching overlap. Memory use is
+// bounded by a few chunks regardless of the file size.
+func (ac *ACKS) ScanFile(path string, m MatchedHandler, opts ...FileOption) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var cfg fileConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.chunkSize <= 0 {
+		size := int64(0)
+		if fi, err := f.Stat(); err == nil {
+			size = fi.Size()
+		}
+		cfg.chunkSize = chooseChunkSize(size)
+	}
+	return ac.scanReader(f, cfg.chunkSize, ac.NewStream(), m)
+}
+
+// scanReader streams r through st with one chunk of readahead.
+func (ac *ACKS) scanReader(r io.Reader, chunkSize int, st *StreamState, m MatchedHandler) error {
+	type chunk struct {
+		buf []byte
+		err error
+	}
+	const buffers = 3
+	free := make(chan []byte, buffers)
+	for i := 0; i < buffers; i++ {
+		free <- make([]byte, chunkSize)
+	}
+	full := make(chan chunk, buffers)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(full)
+		for {
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-done:
+				return
+			}
+			n, err := io.ReadFull(r, buf)
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			if n > 0 || err != nil {
+				select {
+				case full <- chunk{buf: buf[:n], err: err}:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for c := range full {
+		if len(c.buf) > 0 {
+			if err := ac.ScanStream(st, c.buf, m); err != nil {
+				return err
+			}
+		}
+		if c.err != nil {
+			if errors.Is(c.err, io.EOF) {
+				return ac.FinishStream(st, m)
+			}
+			return c.err
+		}
+		free <- c.buf[:cap(c.buf)]
+	}
+	return nil
+}
+
+// streamChunk is the read size of scanAll.
+const streamChunk = 32 * 1024
+
+// scanAll streams r through a new stream without readahead, for helpers
+// that scan many small inputs in turn.
+func (ac *ACKS) scanAll(r io.Reader, h *handler) error {
+	st := ac.NewStream()
+	buf := make([]byte, streamChunk)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := ac.scanStream(st, buf[:n], h); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return ac.finishStream(st, h)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
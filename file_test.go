@@ -0,0 +1,40 @@
+package ahocorasick
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanFile(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("needle", 1, 0))
+	ac.Build()
+
+	text := strings.Repeat("hay", 50000) + "needle" + strings.Repeat("hay", 1000) + "needle"
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []uint64{150006, uint64(len(text))}
+	for _, opts := range [][]FileOption{nil, {FileChunkSize(5)}} {
+		var got []uint64
+		err := ac.ScanFile(path, func(id uint, from, to uint64) error {
+			got = append(got, to)
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("ScanFile failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+
+	if err := ac.ScanFile(filepath.Join(t.TempDir(), "missing"), nil); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
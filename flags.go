@@ -0,0 +1,117 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// flagNames lists the symbolic name and modifier letter of every flag, in
+// bit order.
+var flagNames = []struct {
+	flag     Flag
+	name     string
+	modifier byte
+}{
+	{Caseless, "caseless", 'i'},
+	{SingleMatch, "singlematch", 's'},
+	{LineAnchoredStart, "linestart", '^'},
+	{LineAnchoredEnd, "lineend", '$'},
+	{ExpandEncodings, "expandencodings", 'e'},
+	{WordBoundary, "wordboundary", 'w'},
+}
+
+// String formats f as its flag names joined by "|", e.g.
+// "caseless|singlematch". Unknown bits are formatted in hex and the zero
+// value is "none".
+func (f Flag) String() string {
+	if f == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, fn := range flagNames {
+		if f&fn.flag != 0 {
+			parts = append(parts, fn.name)
+			f &^= fn.flag
+		}
+	}
+	if f != 0 {
+		parts = append(parts, fmt.Sprintf("0x%x", uint(f)))
+	}
+	return strings.Join(parts, "|")
+}
+
+// ParseFlags parses flag names separated by "|", as produced by Flag.String.
+// Names are case-insensitive, and a plain integer is accepted as the raw
+// flag value, as is a modifier suffix such as "/is". The empty string and
+// "none" parse as no flags.
+func ParseFlags(s string) (Flag, error) {
+	var f Flag
+	for _, part := range strings.Split(s, "|") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" || part == "none" {
+			continue
+		}
+		if strings.HasPrefix(part, "/") {
+			m, err := ParseModifiers(part)
+			if err != nil {
+				return 0, err
+			}
+			f |= m
+			continue
+		}
+		if v, err := strconv.ParseUint(part, 0, 0); err == nil {
+			f |= Flag(v)
+			continue
+		}
+		found := false
+		for _, fn := range flagNames {
+			if part == fn.name {
+				f |= fn.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("ahocorasick: unknown flag %q", part)
+		}
+	}
+	return f, nil
+}
+
+// ParseModifiers parses a modifier suffix: a slash followed by one letter
+// per flag, as in "/is". The letters are i for Caseless, s for SingleMatch,
+// ^ for LineAnchoredStart, $ for LineAnchoredEnd, e for ExpandEncodings and
+// w for WordBoundary; a lone slash means no flags.
+func ParseModifiers(s string) (Flag, error) {
+	if !strings.HasPrefix(s, "/") {
+		return 0, fmt.Errorf("ahocorasick: modifiers %q must start with /", s)
+	}
+	var f Flag
+	for i := 1; i < len(s); i++ {
+		found := false
+		for _, fn := range flagNames {
+			if s[i] == fn.modifier {
+				f |= fn.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("ahocorasick: unknown modifier %q", s[i])
+		}
+	}
+	return f, nil
+}
+
+// Modifiers formats f as a modifier suffix, e.g. "/is", or "/" for no
+// flags. Flags without a modifier letter are left out.
+func (f Flag) Modifiers() string {
+	b := []byte{'/'}
+	for _, fn := range flagNames {
+		if f&fn.flag != 0 {
+			b = append(b, fn.modifier)
+		}
+	}
+	return string(b)
+}
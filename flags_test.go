@@ -0,0 +1,50 @@
+package ahocorasick
+
+import (
+	"testing"
+)
+
+func TestFlag_StringAndParse(t *testing.T) {
+	tests := []struct {
+		flag Flag
+		str  string
+	}{
+		{0, "none"},
+		{Caseless, "caseless"},
+		{Caseless | SingleMatch, "caseless|singlematch"},
+		{SingleMatch | 1<<20, "singlematch|0x100000"},
+	}
+	for _, tt := range tests {
+		if got := tt.flag.String(); got != tt.str {
+			t.Errorf("Flag(%d).String() = %q, want %q", uint(tt.flag), got, tt.str)
+		}
+		f, err := ParseFlags(tt.str)
+		if err != nil || f != tt.flag {
+			t.Errorf("ParseFlags(%q) = %v, %v, want %v", tt.str, f, err, tt.flag)
+		}
+	}
+
+	if f, err := ParseFlags(" Caseless | 2 "); err != nil || f != Caseless|SingleMatch {
+		t.Errorf("Unexpected result %v, %v", f, err)
+	}
+	if _, err := ParseFlags("caseless|bogus"); err == nil {
+		t.Errorf("Expected error for unknown flag")
+	}
+}
+
+func TestFlag_Modifiers(t *testing.T) {
+	for _, f := range []Flag{0, Caseless, SingleMatch, Caseless | SingleMatch} {
+		got, err := ParseModifiers(f.Modifiers())
+		if err != nil || got != f {
+			t.Errorf("ParseModifiers(%q) = %v, %v, want %v", f.Modifiers(), got, err, f)
+		}
+	}
+	if f, err := ParseFlags("/s|caseless"); err != nil || f != Caseless|SingleMatch {
+		t.Errorf("Unexpected result %v, %v", f, err)
+	}
+	for _, s := range []string{"i", "/iz"} {
+		if _, err := ParseModifiers(s); err == nil {
+			t.Errorf("%q: Expected error", s)
+		}
+	}
+}
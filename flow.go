@@ -0,0 +1,154 @@
+package ahocorasick
+
+import (
+	"container/list"
+	"net/netip"
+)
+
+// FlowKey identifies a TCP connection by its client and server endpoints.
+type FlowKey struct {
+	Client, Server netip.AddrPort
+}
+
+// Direction is the side of a connection a segment was sent by.
+type Direction uint8
+
+const (
+	ToServer Direction = iota // sent by the client
+	ToClient                  // sent by the server
+)
+
+// FlowMatch is a match in one direction of a TCP connection. Offsets are
+// relative to the first byte seen in that direction.
+type FlowMatch struct {
+	Flow FlowKey
+	Dir  Direction
+	Match
+}
+
+// FlowScanner scans reassembled TCP streams, keeping a StreamState for each
+// direction of every active connection so matches spanning segments are
+// found. At most maxFlows connections are tracked; beyond that the least
+// recently used one is dropped. A FlowScanner is not safe for concurrent
+// use.
+type FlowScanner struct {
+	ac       *ACKS
+	m        func(FlowMatch) error
+	maxFlows int
+	pool     *StreamPool
+	lru      *list.List // of *flowState, most recent first
+	flows    map[FlowKey]*list.Element
+}
+
+// flowState is the scanning state of one connection.
+type flowState struct {
+	key FlowKey
+	dir [2]flowDir
+}
+
+// flowDir follows the sequence numbers of one direction.
+type flowDir struct {
+	st      *StreamState
+	next    uint32 // next expected sequence number
+	pos     uint64 // stream offset of next
+	base    uint64 // stream offset where st was last reset
+	started bool
+}
+
+// NewFlowScanner returns a FlowScanner tracking up to maxFlows connections
+// and reporting matches to m.
+func (ac *ACKS) NewFlowScanner(maxFlows int, m func(FlowMatch) error) *FlowScanner {
+	return &FlowScanner{
+		ac:       ac,
+		m:        m,
+		maxFlows: max(maxFlows, 1),
+		pool:     NewStreamPool(),
+		lru:      list.New(),
+		flows:    make(map[FlowKey]*list.Element),
+	}
+}
+
+// Segment scans the payload of a TCP segment sent in direction dir of flow,
+// starting at sequence number seq. Segments must arrive in order, but
+// retransmitted bytes are skipped and a gap in the sequence numbers resets
+// the direction, so no match spans missing data.
+func (f *FlowScanner) Segment(flow FlowKey, dir Direction, seq uint32, payload []byte) error {
+	fs := f.lookup(flow)
+	d := &fs.dir[dir&1]
+	if d.st == nil {
+		d.st = f.pool.Get(f.ac)
+	}
+	if !d.started {
+		d.next, d.started = seq, true
+	}
+	switch delta := int32(seq - d.next); {
+	case delta < 0:
+		// Retransmission overlapping data already scanned.
+		if int(-delta) >= len(payload) {
+			return nil
+		}
+		payload = payload[-delta:]
+	case delta > 0:
+		d.pos += uint64(delta)
+		d.base = d.pos
+		d.st.Reset()
+	}
+	d.next += uint32(len(payload))
+	d.pos += uint64(len(payload))
+	return f.ac.scanStream(d.st, payload, f.handler(flow, dir, d.base))
+}
+
+// handler reports the matches of direction dir of flow, whose stream was
+// last reset at offset base.
+func (f *FlowScanner) handler(flow FlowKey, dir Direction, base uint64) *handler {
+	return &handler{fn: func(from, to uint64, ps *Pattern) error {
+		return f.m(FlowMatch{Flow: flow, Dir: dir, Match: newMatch(base+from, base+to, ps)})
+	}}
+}
+
+// Close ends both directions of flow, for example after a FIN or RST,
+// reporting the matches that waited for the byte after the last segment as
+// FinishStream does, and forgets it.
+func (f *FlowScanner) Close(flow FlowKey) error {
+	e, ok := f.flows[flow]
+	if !ok {
+		return nil
+	}
+	fs := e.Value.(*flowState)
+	var err error
+	for dir := range fs.dir {
+		if d := &fs.dir[dir]; d.st != nil && err == nil {
+			err = f.ac.finishStream(d.st, f.handler(flow, Direction(dir), d.base))
+		}
+	}
+	f.remove(e)
+	return err
+}
+
+// Len returns the number of connections tracked.
+func (f *FlowScanner) Len() int {
+	return len(f.flows)
+}
+
+func (f *FlowScanner) lookup(flow FlowKey) *flowState {
+	if e, ok := f.flows[flow]; ok {
+		f.lru.MoveToFront(e)
+		return e.Value.(*flowState)
+	}
+	if len(f.flows) >= f.maxFlows {
+		f.remove(f.lru.Back())
+	}
+	fs := &flowState{key: flow}
+	f.flows[flow] = f.lru.PushFront(fs)
+	return fs
+}
+
+func (f *FlowScanner) remove(e *list.Element) {
+	fs := f.lru.Remove(e).(*flowState)
+	delete(f.flows, fs.key)
+	for _, d := range fs.dir {
+		if d.st != nil {
+			f.pool.Put(d.st)
+		}
+	}
+}
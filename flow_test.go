@@ -0,0 +1,68 @@
+package ahocorasick
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestFlowScanner(t *testing.T) {
+	ac := buildWords([]string{"password", "200 OK"})
+	var got []FlowMatch
+	fs := ac.NewFlowScanner(2, func(m FlowMatch) error {
+		got = append(got, m)
+		return nil
+	})
+	a := FlowKey{netip.MustParseAddrPort("10.0.0.1:5000"), netip.MustParseAddrPort("10.0.0.2:80")}
+	b := FlowKey{netip.MustParseAddrPort("10.0.0.3:5000"), netip.MustParseAddrPort("10.0.0.2:80")}
+
+	fs.Segment(a, ToServer, 1000, []byte("user=x&pass"))
+	fs.Segment(b, ToServer, 1, []byte("GET /pass"))
+	fs.Segment(a, ToClient, 500, []byte("HTTP/1.1 200 OK"))
+	fs.Segment(a, ToServer, 1007, []byte("pass")) // retransmitted
+	fs.Segment(a, ToServer, 1011, []byte("word=1"))
+	fs.Segment(b, ToServer, 20, []byte("word")) // after a gap
+	want := []FlowMatch{
+		{a, ToClient, Match{ID: 2, From: 9, To: 15}},
+		{a, ToServer, Match{ID: 1, From: 7, To: 15}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	c := FlowKey{netip.MustParseAddrPort("10.0.0.4:5000"), netip.MustParseAddrPort("10.0.0.2:80")}
+	fs.Segment(c, ToServer, 1, []byte("x"))
+	if fs.Len() != 2 {
+		t.Errorf("Expected 2 flows, got %d", fs.Len())
+	}
+	// b was least recently used and evicted, so its stream restarts.
+	got = nil
+	fs.Segment(b, ToServer, 30, []byte("word"))
+	fs.Close(a)
+	if len(got) != 0 || fs.Len() != 2 {
+		t.Errorf("Unexpected state %v, %d flows", got, fs.Len())
+	}
+}
+
+func TestFlowScanner_CloseFinishes(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("QUIT", 1, LineAnchoredEnd))
+	ac.Build()
+	var got []FlowMatch
+	fs := ac.NewFlowScanner(2, func(m FlowMatch) error {
+		got = append(got, m)
+		return nil
+	})
+	a := FlowKey{netip.MustParseAddrPort("10.0.0.1:5000"), netip.MustParseAddrPort("10.0.0.2:25")}
+	fs.Segment(a, ToServer, 1, []byte("QUIT"))
+	if len(got) != 0 {
+		t.Fatalf("Expected the match to wait for the next byte, got %v", got)
+	}
+	if err := fs.Close(a); err != nil {
+		t.Fatal(err)
+	}
+	want := []FlowMatch{{a, ToServer, Match{ID: 1, From: 0, To: 4}}}
+	if !reflect.DeepEqual(got, want) || fs.Len() != 0 {
+		t.Errorf("Expected %v after Close, got %v", want, got)
+	}
+}
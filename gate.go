@@ -0,0 +1,49 @@
+package ahocorasick
+
+import "math"
+
+// buildMinRemain records, for every state, the fewest bytes that must
+// follow before any further match can complete: the distance to the nearest
+// output below the state or below any state on its failure chain. The root
+// needs the length of the shortest pattern, which bounds every other state.
+func (ac *ACKS) buildMinRemain() {
+	below := make([]int32, ac.stateCount)
+	// Children have larger indices than their parent, so a reverse walk
+	// sees them first.
+	for s := ac.stateCount - 1; s >= 0; s-- {
+		if ac.stateHasOutput[s] {
+			continue
+		}
+		best := int32(math.MaxInt32)
+		ac.forEachChild(s, func(n int) {
+			best = min(best, below[n]+1)
+		})
+		below[s] = best
+	}
+	// A failure link always points to a smaller index.
+	ac.minRemain = below
+	for s := 1; s < ac.stateCount; s++ {
+		ac.minRemain[s] = min(below[s], ac.minRemain[ac.failure[s]])
+	}
+	if ac.nibble {
+		for s, n := range ac.minRemain {
+			ac.minRemain[s] = (n + 1) / 2
+		}
+	}
+}
+
+// forEachChild calls fn with the goto transitions of state s.
+func (ac *ACKS) forEachChild(s int, fn func(n int)) {
+	if s >= ac.denseStates {
+		row := s - ac.denseStates
+		for _, n := range ac.sparseNext[ac.sparseIndex[row]:ac.sparseIndex[row+1]] {
+			fn(int(n))
+		}
+		return
+	}
+	for _, n := range ac.stateTable[s*ac.alphabetSize : (s+1)*ac.alphabetSize] {
+		if ac.depth[n] == ac.depth[s]+1 {
+			fn(int(n))
+		}
+	}
+}
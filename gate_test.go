@@ -0,0 +1,46 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestACKS_MinRemain(t *testing.T) {
+	ac := buildWords([]string{"abcd", "bc"})
+	for _, c := range []struct {
+		text string
+		want int32
+	}{{"", 2}, {"a", 2}, {"ab", 1}, {"abc", 0}, {"b", 1}} {
+		s := 0
+		for _, b := range []byte(c.text) {
+			s = ac.next(s, ac.translateTable[b])
+		}
+		if got := ac.minRemain[s]; got != c.want {
+			t.Errorf("After %q: Expected %d, got %d", c.text, c.want, got)
+		}
+	}
+}
+
+func TestACKS_MinRemainGate(t *testing.T) {
+	text := []byte("ushers said his hers, she said he sells shells")
+	key := func(ms []Match) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, fmt.Sprint(m.ID, m.To))
+		}
+		sort.Strings(out)
+		return out
+	}
+	for _, opts := range [][]Option{nil, {WithDenseStates(4)}, {WithNibbleAlphabet()}, {WithLongPatternPrefix(3)}} {
+		ac := buildWords([]string{"he", "she", "his", "hers", "said he", "shells"}, opts...)
+		// Cutting the text anywhere must not lose matches near its end.
+		for n := 0; n <= len(text); n++ {
+			got, want := key(ac.FindN(text[:n], 0)), key(naiveMatches(ac, text[:n]))
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%v at %d: Expected %v, got %v", opts, n, want, got)
+			}
+		}
+	}
+}
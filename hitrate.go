@@ -0,0 +1,81 @@
+package ahocorasick
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// hitRates tracks an exponentially weighted moving average of the hit rate
+// of each ID slot, sampled from the counters on every Tick.
+type hitRates struct {
+	mu       sync.Mutex
+	halfLife time.Duration
+	maxRate  float64 // auto-mute limit in hits per second, 0 if disabled
+	lastTick time.Time
+	last     []uint64
+	rates    []float64
+}
+
+// TrackRates enables per-pattern hit rates, averaged with the given
+// half-life. Rates are updated by Tick, which the caller runs periodically,
+// for example from a time.Ticker.
+func (a *Accumulator) TrackRates(halfLife time.Duration) {
+	a.rates = &hitRates{
+		halfLife: halfLife,
+		last:     make([]uint64, len(a.counts)),
+		rates:    make([]float64, len(a.counts)),
+	}
+}
+
+// SetAutoMute makes Tick mute every pattern ID whose rate exceeds maxRate
+// hits per second, catching rules that suddenly fire in a storm of false
+// positives. Zero disables it. TrackRates must be called first.
+func (a *Accumulator) SetAutoMute(maxRate float64) {
+	a.rates.mu.Lock()
+	a.rates.maxRate = maxRate
+	a.rates.mu.Unlock()
+}
+
+// Tick folds the hits counted since the previous tick into the rates and
+// returns the pattern IDs it auto-muted. The first tick only records a
+// starting point.
+func (a *Accumulator) Tick(now time.Time) []uint {
+	r := a.rates
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	first := r.lastTick.IsZero()
+	dt := now.Sub(r.lastTick).Seconds()
+	r.lastTick = now
+	var muted []uint
+	for slot := range a.counts {
+		n := a.counts[slot].Load()
+		delta := n - r.last[slot]
+		if n < r.last[slot] {
+			delta = n // the counters were reset
+		}
+		r.last[slot] = n
+		if first || dt <= 0 {
+			continue
+		}
+		alpha := 1 - math.Exp2(-dt/r.halfLife.Seconds())
+		r.rates[slot] += alpha * (float64(delta)/dt - r.rates[slot])
+		if r.maxRate > 0 && r.rates[slot] > r.maxRate && !a.ac.mutedSlot(slot) {
+			a.ac.Mute(a.ac.ids[slot])
+			muted = append(muted, a.ac.ids[slot])
+		}
+	}
+	return muted
+}
+
+// Rate returns the average hit rate of pattern id in hits per second, as of
+// the last Tick.
+func (a *Accumulator) Rate(id uint) float64 {
+	slot, ok := a.ac.slotOf(id)
+	if !ok || a.rates == nil {
+		return 0
+	}
+	a.rates.mu.Lock()
+	defer a.rates.mu.Unlock()
+	return a.rates.rates[slot]
+}
@@ -0,0 +1,38 @@
+package ahocorasick
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccumulator_Rates(t *testing.T) {
+	ac := buildWords([]string{"noise", "rare"})
+	acc := ac.EnableCounters()
+	acc.TrackRates(time.Second)
+	acc.SetAutoMute(50)
+
+	now := time.Unix(1000, 0)
+	acc.Tick(now)
+	ac.Search([]byte("noise rare"))
+
+	// One half-life later the average has moved halfway to the new rate.
+	now = now.Add(time.Second)
+	if muted := acc.Tick(now); len(muted) != 0 {
+		t.Errorf("Expected no muting yet, got %v", muted)
+	}
+	if r := acc.Rate(1); math.Abs(r-0.5) > 1e-9 {
+		t.Errorf("Expected rate 0.5, got %v", r)
+	}
+
+	ac.Search([]byte(strings.Repeat("noise ", 200) + "rare"))
+	now = now.Add(time.Second)
+	if muted := acc.Tick(now); !reflect.DeepEqual(muted, []uint{1}) || !ac.Muted(1) || ac.Muted(2) {
+		t.Errorf("Expected the spiking pattern to be muted, got %v", muted)
+	}
+	if got, _ := ac.Search([]byte("noise rare")); !reflect.DeepEqual(got, []uint{2}) {
+		t.Errorf("Expected muted pattern to be dropped, got %v", got)
+	}
+}
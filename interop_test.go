@@ -0,0 +1,99 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// TestCHeaderLayout keeps the constants in c/acks.h in step with the Go
+// writer.
+func TestCHeaderLayout(t *testing.T) {
+	src, err := os.ReadFile("c/acks.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := map[string]int{}
+	for _, m := range regexp.MustCompile(`#define (ACKS_\w+) (0x[0-9a-f]+|\d+)`).FindAllSubmatch(src, -1) {
+		v, _ := strconv.ParseInt(string(m[2]), 0, 64)
+		defs[string(m[1])] = int(v)
+	}
+	want := map[string]int{
+		"ACKS_VERSION":          dbVersion,
+		"ACKS_HEADER_SIZE":      dbHeaderSize,
+		"ACKS_PATTERN_SIZE":     dbPatternSize,
+		"ACKS_FLAG_NIBBLE":      dbNibble,
+		"ACKS_FLAG_FOLD_CASE":   dbFoldCase,
+		"ACKS_FLAG_UNUSED_CODE": dbUnusedCode,
+		"ACKS_FLAG_EXACT_CASE":  dbExactCase,
+		"ACKS_FLAG_VERIFY_ALL":  dbVerifyAll,
+		"ACKS_CASELESS":         int(Caseless),
+		"ACKS_SINGLE_MATCH":     int(SingleMatch),
+		"ACKS_LINE_START":       int(LineAnchoredStart),
+		"ACKS_LINE_END":         int(LineAnchoredEnd),
+		"ACKS_EXPAND_ENCODINGS": int(ExpandEncodings),
+		"ACKS_WORD_BOUNDARY":    int(WordBoundary),
+		"ACKS_KNOWN_FLAGS":      int(patternFlags),
+	}
+	for name, v := range want {
+		if defs[name] != v {
+			t.Errorf("%s = %d in acks.h, want %d", name, defs[name], v)
+		}
+	}
+}
+
+// TestCReader scans with the reference C reader, when a C compiler is
+// available, and compares its matches with the Go scanner.
+func TestCReader(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "example")
+	if out, err := exec.Command(cc, "-std=c99", "-o", bin, "c/acks.c", "c/example.c").CombinedOutput(); err != nil {
+		t.Skipf("cannot build the C reader: %v\n%s", err, out)
+	}
+
+	text := []byte("ushers say HELLO to his hers, ABC abc\nhe is\nhe")
+	for _, opts := range [][]Option{nil, {WithDenseStates(3)}, {WithNibbleAlphabet()}, {WithLongPatternPrefix(2)}, {WithByteClasses(" ,"), WithMetadata(Metadata{RuleSet: "web"})}} {
+		ac := buildWords([]string{"he", "she", "his", "hers", "abc", "s,", "o "}, opts...)
+		ac.AddPattern(mkPat("hello", 9, Caseless))
+		ac.AddPattern(mkPat("he", 12, LineAnchoredStart))
+		ac.AddPattern(mkPat("is", 13, LineAnchoredEnd))
+		ac.AddPattern(mkPat("ushers", 14, LineAnchoredStart|LineAnchoredEnd))
+		ac.AddPattern(mkPat("he", 15, WordBoundary))
+		if !ac.nibble {
+			for i, expr := range []string{"h[aeiou]s", "[a-c]B[c-d]"} {
+				p, _ := ParseClassPattern(expr)
+				p.ID, p.Flags = uint(10+i), Flag(i)*Caseless
+				ac.AddPattern(p)
+			}
+		}
+		ac.Build()
+		db := filepath.Join(dir, "db")
+		f, _ := os.Create(db)
+		ac.WriteTo(f)
+		f.Close()
+
+		var want bytes.Buffer
+		ac.ScanPatterns(text, func(p *Pattern, from, to uint64) error {
+			fmt.Fprintf(&want, "%d %d %d\n", p.ID, from, to)
+			return nil
+		})
+		cmd := exec.Command(bin, db)
+		cmd.Stdin = bytes.NewReader(text)
+		got, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("C reader failed: %v", err)
+		}
+		if string(got) != want.String() {
+			t.Errorf("Expected:\n%s\ngot:\n%s", want.String(), got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package ahocorasick
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONMatch is a match inside a string value of a JSON document. The offsets
+// of the embedded Match are relative to the decoded string.
+type JSONMatch struct {
+	Pointer string // RFC 6901 pointer to the string value, e.g. "/users/0/email"
+	Match
+}
+
+// JSONOption configures ScanJSON.
+type JSONOption func(*jsonConfig)
+
+type jsonConfig struct {
+	paths [][]string
+}
+
+// JSONPaths limits ScanJSON to string values at the given JSON pointers. A
+// "*" segment matches any object key or array index, so "/users/*/email"
+// selects the email of every user.
+func JSONPaths(pointers ...string) JSONOption {
+	return func(c *jsonConfig) {
+		for _, p := range pointers {
+			c.paths = append(c.paths, splitPointer(p))
+		}
+	}
+}
+
+// jsonFrame is an object or array being decoded.
+type jsonFrame struct {
+	array   bool
+	index   int    // index of the current array element
+	key     string // key of the current object member
+	wantKey bool   // the next string is an object key
+}
+
+// ScanJSON decodes the JSON document read from r token by token and scans
+// every string value, leaving keys and structure alone. Each match is
+// reported with the pointer of the value it was found in.
+func (ac *ACKS) ScanJSON(r io.Reader, m func(JSONMatch) error, opts ...JSONOption) error {
+	var cfg jsonConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var stack []jsonFrame
+	// enter and leave bracket every value, advancing the enclosing frame.
+	enter := func() {
+		if n := len(stack); n > 0 && stack[n-1].array {
+			stack[n-1].index++
+		}
+	}
+	leave := func() {
+		if n := len(stack); n > 0 && !stack[n-1].array {
+			stack[n-1].wantKey = true
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ahocorasick: %w", err)
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				enter()
+				stack = append(stack, jsonFrame{array: t == '[', index: -1, wantKey: t == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				leave()
+			}
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].wantKey {
+				stack[n-1].key, stack[n-1].wantKey = t, false
+				continue
+			}
+			enter()
+			if cfg.selected(stack) {
+				ptr := jsonPointer(stack)
+				err := ac.searchPatterns([]byte(t), &handler{fn: func(from, to uint64, ps *Pattern) error {
+					return m(JSONMatch{Pointer: ptr, Match: newMatch(from, to, ps)})
+				}})
+				if err != nil {
+					return err
+				}
+			}
+			leave()
+		default:
+			enter()
+			leave()
+		}
+	}
+}
+
+// selected reports whether the value at stack passes the JSONPaths filter.
+func (c *jsonConfig) selected(stack []jsonFrame) bool {
+	if c.paths == nil {
+		return true
+	}
+next:
+	for _, path := range c.paths {
+		if len(path) != len(stack) {
+			continue
+		}
+		for i, seg := range path {
+			if seg != "*" && seg != stack[i].segment() {
+				continue next
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (f *jsonFrame) segment() string {
+	if f.array {
+		return strconv.Itoa(f.index)
+	}
+	return f.key
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func jsonPointer(stack []jsonFrame) string {
+	var sb strings.Builder
+	for i := range stack {
+		sb.WriteByte('/')
+		pointerEscaper.WriteString(&sb, stack[i].segment())
+	}
+	return sb.String()
+}
+
+func splitPointer(p string) []string {
+	if p == "" {
+		return []string{}
+	}
+	segs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, s := range segs {
+		segs[i] = pointerUnescaper.Replace(s)
+	}
+	return segs
+}
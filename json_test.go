@@ -0,0 +1,42 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanJSON(t *testing.T) {
+	ac := buildWords([]string{"secret", "admin"})
+	doc := `{"secret": "x", "users": [
+		{"name": "admin", "note": "my secret", "n": 3},
+		{"name": "bob", "tags": ["admin", null]}
+	], "a/b": "secret"}`
+
+	var got []string
+	collect := func(m JSONMatch) error {
+		got = append(got, m.Pointer+":"+strings.Repeat("x", int(m.To-m.From)))
+		return nil
+	}
+	if err := ac.ScanJSON(strings.NewReader(doc), collect); err != nil {
+		t.Fatalf("ScanJSON failed: %v", err)
+	}
+	want := []string{"/users/0/name:xxxxx", "/users/0/note:xxxxxx", "/users/1/tags/0:xxxxx", "/a~1b:xxxxxx"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = nil
+	err := ac.ScanJSON(strings.NewReader(doc), collect, JSONPaths("/users/*/name", "/a~1b"))
+	if err != nil {
+		t.Fatalf("ScanJSON failed: %v", err)
+	}
+	want = []string{"/users/0/name:xxxxx", "/a~1b:xxxxxx"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if err := ac.ScanJSON(strings.NewReader(`{"a": [}`), collect); err == nil {
+		t.Errorf("Expected error for malformed JSON")
+	}
+}
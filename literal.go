@@ -0,0 +1,153 @@
+package ahocorasick
+
+// Matchers built from at most maxLiterals patterns scan complete inputs by
+// searching for each pattern directly, which avoids the per-byte cost of the
+// automaton. A single case-sensitive pattern is found with index, which is
+// bytes.Index unless built with purego; otherwise each pattern is located
+// by its rarest byte with indexByte and then compared. The automaton is
+// still built, for streams, profiling and tracing, which need its state.
+const maxLiterals = 4
+
+// literal is a pattern searched for directly.
+type literal struct {
+	pat *Pattern
+	// k is the offset of the anchor byte searched for; lo and up are its
+	// two cases, equal unless the pattern is Caseless and it is a letter.
+	k      int
+	lo, up byte
+	// index marks a lone case-sensitive pattern, found with index.
+	index bool
+}
+
+// buildLiterals selects the literal engines when they apply. An explicit
+// table layout from WithDenseStates or WithNibbleAlphabet keeps the
+// automaton, and so do line anchors, which are checked by it.
+func (ac *ACKS) buildLiterals() {
+	ac.literals = ac.literals[:0]
+	if len(ac.patterns) > maxLiterals || ac.nibble || ac.maxDenseStates > 0 || ac.hasAnchors {
+		return
+	}
+	for _, p := range ac.patterns {
+		if len(p.Content) == 0 {
+			ac.literals = ac.literals[:0]
+			return
+		}
+		l := literal{pat: p, index: len(ac.patterns) == 1 && p.Flags&Caseless == 0 && p.sets == nil}
+		if l.k = rarestByte(p, ac.frequencies()); l.k < 0 {
+			ac.literals = ac.literals[:0]
+			return
+		}
+		l.lo, l.up = p.Content[l.k], p.Content[l.k]
+		if p.Flags&Caseless != 0 {
+			l.lo, l.up = toLower(l.lo), toUpper(l.lo)
+		}
+		ac.literals = append(ac.literals, l)
+	}
+}
+
+// rarestByte returns the offset of the byte of p least frequent in text
+// following freq, counting both cases of letters in Caseless patterns.
+// Class positions are skipped; it returns -1 if there is no other.
+func rarestByte(p *Pattern, freq *[256]float64) int {
+	best, bestFreq := -1, 0.0
+	for k, b := range p.Content {
+		if p.classAt(k) != nil {
+			continue
+		}
+		f := freq[b]
+		if p.Flags&Caseless != 0 && toLower(b) != toUpper(b) {
+			f = freq[toLower(b)] + freq[toUpper(b)]
+		}
+		if best < 0 || f < bestFreq {
+			best, bestFreq = k, f
+		}
+	}
+	return best
+}
+
+// find returns the offset of the first occurrence of the pattern in text at
+// or after from, or -1.
+func (l *literal) find(text []byte, from int) int {
+	if l.index {
+		if j := index(text[from:], l.pat.Content); j >= 0 {
+			return from + j
+		}
+		return -1
+	}
+	n := len(l.pat.Content)
+	for i := from; i+n <= len(text); i++ {
+		// The anchor of a match starting at i or later lies in seg.
+		seg := text[i+l.k : len(text)-n+l.k+1]
+		j := indexByte(seg, l.lo)
+		if l.up != l.lo {
+			end := len(seg)
+			if j >= 0 {
+				end = j
+			}
+			if j2 := indexByte(seg[:end], l.up); j2 >= 0 {
+				j = j2
+			}
+		}
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if equalPattern(l.pat, 0, text[i:i+n]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// searchLiterals reports every occurrence of the literal patterns in text,
+// overlapping ones included, in the order the automaton would: by end
+// offset, then longest first, then in the order the patterns were added.
+func (ac *ACKS) searchLiterals(ss *scanState, text []byte, h *handler) error {
+	var next [maxLiterals]int
+	lits := ac.literals
+	for i := range lits {
+		next[i] = lits[i].find(text, 0)
+	}
+	for {
+		best, bestEnd := -1, 0
+		for i := range lits {
+			if next[i] < 0 {
+				continue
+			}
+			end := next[i] + len(lits[i].pat.Content)
+			if best < 0 || end < bestEnd || end == bestEnd && len(lits[i].pat.Content) > len(lits[best].pat.Content) {
+				best, bestEnd = i, end
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		if err := ac.emit(ss, h, lits[best].pat, ss.base+uint64(bestEnd)); err != nil {
+			return err
+		}
+		next[best] = lits[best].find(text, next[best]+1)
+	}
+}
+
+// defaultByteFrequency is a rough model of byte frequencies in mixed text
+// and binary data, used unless WithByteFrequencyModel is given.
+var defaultByteFrequency = func() [256]float64 {
+	var f [256]float64
+	for i := range f {
+		f[i] = 1 // high and control bytes
+	}
+	for b := '!'; b <= '~'; b++ {
+		f[b] = 8 // punctuation
+	}
+	for b := '0'; b <= '9'; b++ {
+		f[b] = 20
+	}
+	// Letters by their frequency in English text, capitals much rarer.
+	for i, b := range []byte("zqxjkvbpygfwmucldrhsnioate") {
+		f[b] = float64(10 + 8*i)
+		f[toUpper(b)] = float64(2 + i)
+	}
+	f[' '], f['\n'], f['\t'], f['\r'] = 250, 40, 20, 20
+	f[0x00], f[0xff] = 60, 10
+	return f
+}()
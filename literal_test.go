@@ -0,0 +1,64 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dfaMatches scans text with the automaton, through a stream, for
+// comparison with the literal engines.
+func dfaMatches(ac *ACKS, text string) []Match {
+	var got []Match
+	ac.scanStream(ac.NewStream(), []byte(text), &handler{fn: func(from, to uint64, ps *Pattern) error {
+		got = append(got, newMatch(from, to, ps))
+		return nil
+	}})
+	return got
+}
+
+func TestACKS_SingleLiteral(t *testing.T) {
+	for _, p := range []Pattern{mkPat("aa", 1, 0), mkPat("xY", 2, Caseless), mkPat("-Q", 3, Caseless)} {
+		ac := NewACKS()
+		ac.AddPattern(p)
+		ac.Build()
+		if got := ac.Engine().Engine; got != "literal" {
+			t.Fatalf("Expected the literal engine, got %q", got)
+		}
+		for _, text := range []string{"", "aaaa", "xy XY xY Xy x", "-q--Q-", "baaxyb-"} {
+			got := ac.FindN([]byte(text), 0)
+			if want := dfaMatches(ac, text); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q in %q: Expected %v, got %v", p.Content, text, want, got)
+			}
+		}
+	}
+}
+
+func TestACKS_LiteralSet(t *testing.T) {
+	sets := [][]Pattern{
+		{mkPat("ab", 1, 0), mkPat("b", 2, 0)},
+		{mkPat("abc", 1, 0), mkPat("bc", 2, Caseless), mkPat("abc", 3, Caseless), mkPat("ca", 4, 0)},
+		{mkPat("aa", 1, 0), mkPat("aaa", 2, 0), mkPat("Ba", 3, Caseless)},
+	}
+	r := uint32(1)
+	for _, pats := range sets {
+		ac := NewACKS()
+		for _, p := range pats {
+			ac.AddPattern(p)
+		}
+		ac.Build()
+		if got := ac.Engine().Engine; got != "literal-set" {
+			t.Fatalf("Expected the literal-set engine, got %q", got)
+		}
+		for n := 0; n < 200; n++ {
+			text := make([]byte, n%40)
+			for i := range text {
+				r = r*1103515245 + 12345
+				text[i] = "abcABC"[r>>16%6]
+			}
+			got := ac.FindN(text, 0)
+			if want := dfaMatches(ac, string(text)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q: Expected %v, got %v", text, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ahocorasick
+
+// WithLongPatternPrefix stores only the first n bytes of longer patterns in
+// the automaton. The rest of such a pattern is compared directly against the
+// text when its prefix is found. Signature sets with multi-kilobyte byte
+// sequences then need a few states per pattern instead of thousands.
+//
+// A long pattern is reported as soon as its tail is verified, which may be
+// before shorter matches that end earlier in the text.
+func WithLongPatternPrefix(n int) Option {
+	return func(ac *ACKS) {
+		ac.prefixLen = n
+	}
+}
+
+// pendingTail is a long pattern whose prefix matched near the end of a
+// stream chunk and whose tail continues into the following chunks.
+type pendingTail struct {
+	pat *Pattern
+	// done is the number of tail bytes already verified.
+	done int
+	// end is the absolute offset where the match will end.
+	end uint64
+}
+
+// verifyTail compares the tail of pat, which starts at text[start], with the
+// text. If the text ends before the tail in a stream, the verified part is
+// recorded as pending and false is returned for now.
+func (ac *ACKS) verifyTail(ss *scanState, pat *Pattern, text []byte, start int) bool {
+	tail := pat.Content[pat.plen:]
+	avail := text[start:]
+	if len(avail) >= len(tail) {
+		return equalPattern(pat, pat.plen, avail[:len(tail)])
+	}
+	if !ss.stream || !equalPattern(pat, pat.plen, avail) {
+		return false
+	}
+	ss.pending = append(ss.pending, pendingTail{
+		pat:  pat,
+		done: len(avail),
+		end:  ss.base + uint64(start+len(tail)),
+	})
+	return false
+}
+
+// resumePending continues verifying pending tails with the next chunk.
+func (ac *ACKS) resumePending(ss *scanState, text []byte, h *handler) error {
+	kept := ss.pending[:0]
+	for j, p := range ss.pending {
+		rest := p.pat.Content[p.pat.plen+p.done:]
+		n := min(len(rest), len(text))
+		if !equalPattern(p.pat, p.pat.plen+p.done, text[:n]) {
+			continue
+		}
+		if n < len(rest) {
+			p.done += n
+			kept = append(kept, p)
+			continue
+		}
+		if p.pat.Flags&(LineAnchoredEnd|WordBoundary) != 0 {
+			ok, wait := ss.atEnd(p.pat, text, n)
+			if wait {
+				p.done += n
+				kept = append(kept, p)
+			}
+			if !ok {
+				continue
+			}
+		}
+		if err := ac.emit(ss, h, p.pat, p.end); err != nil {
+			ss.pending = append(kept, ss.pending[j+1:]...)
+			return err
+		}
+	}
+	ss.pending = kept
+	return nil
+}
+
+// flushPending reports the pending matches that only wait for the byte
+// after the end of the input, which ends a line and a word. Tails still
+// being verified are kept.
+func (ac *ACKS) flushPending(ss *scanState, h *handler) error {
+	kept := ss.pending[:0]
+	for j, p := range ss.pending {
+		if p.done < p.pat.strlen-p.pat.plen {
+			kept = append(kept, p)
+			continue
+		}
+		if err := ac.emit(ss, h, p.pat, p.end); err != nil {
+			ss.pending = append(kept, ss.pending[j+1:]...)
+			return err
+		}
+	}
+	ss.pending = kept
+	return nil
+}
+
+// equalPattern compares the pattern bytes starting at offset off with text,
+// ignoring ASCII case for Caseless patterns and accepting any byte of a
+// class at its offset.
+func equalPattern(pat *Pattern, off int, text []byte) bool {
+	content := pat.Content[off : off+len(text)]
+	if pat.sets == nil && pat.Flags&Caseless == 0 {
+		return string(content) == string(text)
+	}
+	for i, b := range content {
+		if set := pat.classAt(off + i); set != nil {
+			if !set.Has(text[i]) {
+				return false
+			}
+		} else if b != text[i] && (pat.Flags&Caseless == 0 || toLower(b) != toLower(text[i])) {
+			return false
+		}
+	}
+	return true
+}
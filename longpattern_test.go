@@ -0,0 +1,56 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestACKS_LongPatternPrefix(t *testing.T) {
+	long := strings.Repeat("0123456789", 10)
+	build := func(opts ...Option) *ACKS {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat(long, 1, 0))
+		ac.AddPattern(mkPat("ABCDEFGHIJ", 2, Caseless))
+		ac.AddPattern(mkPat("789", 3, 0))
+		ac.Build()
+		return ac
+	}
+	full := build()
+	short := build(WithLongPatternPrefix(4))
+	if short.stateCount >= full.stateCount/4 {
+		t.Fatalf("Expected far fewer states, got %d vs %d", short.stateCount, full.stateCount)
+	}
+
+	text := "x" + long + " abcdefghij " + long[:50] + "y abcdefghiX"
+	sortMatches := func(ms []Match) []Match {
+		sort.Slice(ms, func(i, j int) bool {
+			return ms[i].To < ms[j].To || ms[i].To == ms[j].To && ms[i].ID < ms[j].ID
+		})
+		return ms
+	}
+	want := sortMatches(full.FindN([]byte(text), 0))
+	got := sortMatches(short.FindN([]byte(text), 0))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	// In a stream the tail may continue over several chunks.
+	for _, chunk := range []int{1, 3, 7, 64} {
+		st := short.NewStream()
+		var ends []uint64
+		h := func(id uint, from, to uint64) error {
+			if id == 1 {
+				ends = append(ends, to)
+			}
+			return nil
+		}
+		for i := 0; i < len(text); i += chunk {
+			short.ScanStream(st, []byte(text[i:min(i+chunk, len(text))]), h)
+		}
+		if !reflect.DeepEqual(ends, []uint64{uint64(1 + len(long))}) {
+			t.Errorf("chunk %d: Expected long match ending at %d, got %v", chunk, 1+len(long), ends)
+		}
+	}
+}
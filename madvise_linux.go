@@ -0,0 +1,10 @@
+//go:build linux
+
+package ahocorasick
+
+import "syscall"
+
+// adviseWillNeed tells the kernel that the mapped b will be read soon.
+func adviseWillNeed(b []byte) {
+	_ = syscall.Madvise(b, syscall.MADV_WILLNEED)
+}
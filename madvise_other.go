@@ -0,0 +1,6 @@
+//go:build !linux
+
+package ahocorasick
+
+// adviseWillNeed is a no-op where madvise is not available.
+func adviseWillNeed(b []byte) {}
@@ -0,0 +1,146 @@
+package ahocorasick
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// Match describes one occurrence of a pattern in the scanned text.
+type Match struct {
+	ID     uint
+	From   uint64 // offset of the first byte of the match
+	To     uint64 // offset just past the last byte of the match
+	Source string // Source of the matched pattern
+	// Value is what the extractor of the pattern returned, for matches
+	// found by ScanExtract; see OnMatchExtract.
+	Value any
+}
+
+func newMatch(from, to uint64, ps *Pattern) Match {
+	return Match{ID: ps.ID, From: from, To: to, Source: ps.Source}
+}
+
+// errStopScan is returned by internal handlers to end a scan early.
+var errStopScan = errors.New("ahocorasick: scan stopped")
+
+// FindN returns the first n matches in text, in the order they end, and
+// stops scanning as soon as n matches are found. n <= 0 returns all matches.
+func (ac *ACKS) FindN(text []byte, n int) []Match {
+	var matches []Match
+	if n > 0 {
+		matches = make([]Match, 0, min(n, 64))
+	}
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		matches = append(matches, newMatch(from, to, ps))
+		if len(matches) == n {
+			return errStopScan
+		}
+		return nil
+	}}
+	_ = ac.searchPatterns(text, &h)
+	return matches
+}
+
+// MatchesEndingAt returns the matches that end exactly at offset pos of
+// text, longest first, as an editor showing what matched at the cursor
+// would ask. Only the bytes such a match can span are scanned, unless
+// normalizers are set. Each call is an independent query: SingleMatch,
+// dedup and sampling do not apply, and hit rates are not counted.
+func (ac *ACKS) MatchesEndingAt(text []byte, pos int) []Match {
+	if pos < 0 || pos > len(text) || len(ac.patterns) == 0 {
+		return nil
+	}
+	start := 0
+	if len(ac.normalizers) == 0 {
+		// One more byte decides a line start anchor.
+		start = max(0, pos-ac.maxPatternLen-1)
+	}
+	// The byte after pos decides a line end anchor.
+	end := min(pos+1, len(text))
+	var matches []Match
+	ss := scanState{base: uint64(start), probe: true}
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		if to == uint64(pos) {
+			matches = append(matches, newMatch(from, to, ps))
+		}
+		return nil
+	}}
+	_ = ac.searchWith(&ss, text[start:end], &h)
+	return matches
+}
+
+// Contains reports whether any pattern occurs in text, stopping the scan at
+// the first match.
+func (ac *ACKS) Contains(text []byte) bool {
+	if len(ac.patterns) == 0 {
+		return false
+	}
+	found := false
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		found = true
+		return errStopScan
+	}}
+	_ = ac.searchPatterns(text, &h)
+	return found
+}
+
+// MatchesChan scans text in a new goroutine and delivers its matches on the
+// returned channel, which has a buffer of buf and is closed when the scan
+// ends. The scan blocks while the channel is full and stops early when ctx is
+// cancelled, so consumers may simply stop reading after cancelling. ctx is
+// also checked between chunks of text, so a long scan without matches stops
+// soon after cancellation too.
+func (ac *ACKS) MatchesChan(ctx context.Context, text []byte, buf int) <-chan Match {
+	ch := make(chan Match, buf)
+	go func() {
+		defer close(ch)
+		h := handler{fn: func(from, to uint64, ps *Pattern) error {
+			select {
+			case ch <- newMatch(from, to, ps):
+				return nil
+			case <-ctx.Done():
+				return errStopScan
+			}
+		}}
+		st := ac.NewStream()
+		for len(text) > 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			n := min(len(text), streamChunk)
+			if ac.scanStream(st, text[:n], &h) != nil {
+				return
+			}
+			text = text[n:]
+		}
+		if ctx.Err() == nil {
+			_ = ac.finishStream(st, &h)
+		}
+	}()
+	return ch
+}
+
+// FindAllIndex returns the [start, end) offsets of the matches in text in the
+// shape of regexp.FindAllIndex, so it can stand in for a regexp alternation
+// of literals. Unlike regexp, overlapping matches are all reported. Matches
+// are ordered by start and then end offset; n >= 0 limits the result to the
+// first n, and nil is returned when nothing matches.
+func (ac *ACKS) FindAllIndex(text []byte, n int) [][]int {
+	if n == 0 {
+		return nil
+	}
+	var idx [][]int
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		idx = append(idx, []int{int(from), int(to)})
+		return nil
+	}}
+	_ = ac.searchPatterns(text, &h)
+	sort.SliceStable(idx, func(i, j int) bool {
+		return idx[i][0] < idx[j][0] || idx[i][0] == idx[j][0] && idx[i][1] < idx[j][1]
+	})
+	if n > 0 && len(idx) > n {
+		idx = idx[:n]
+	}
+	return idx
+}
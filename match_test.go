@@ -0,0 +1,151 @@
+package ahocorasick
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestACKS_FindN(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("he", 1, 0))
+	ac.AddPattern(mkPat("she", 2, 0))
+	ac.Build()
+
+	text := []byte("ushers " + strings.Repeat("she ", 1000))
+	got := ac.FindN(text, 3)
+	expected := []Match{{ID: 2, From: 1, To: 4}, {ID: 1, From: 2, To: 4}, {ID: 2, From: 7, To: 10}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if all := ac.FindN(text, 0); len(all) != 2002 {
+		t.Errorf("Expected 2002 matches, got %d", len(all))
+	}
+}
+
+func TestACKS_MatchesChan(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("ab", 1, 0))
+	ac.Build()
+
+	text := []byte(strings.Repeat("ab", 100))
+	count := 0
+	for m := range ac.MatchesChan(context.Background(), text, 4) {
+		if m.ID != 1 || m.To-m.From != 2 {
+			t.Fatalf("Unexpected match %+v", m)
+		}
+		count++
+	}
+	if count != 100 {
+		t.Errorf("Expected 100 matches, got %d", count)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := ac.MatchesChan(ctx, text, 0)
+	<-ch
+	cancel()
+	// The producer must notice the cancellation and close the channel.
+	for range ch {
+	}
+
+	// A cancelled scan stops between chunks even while matches could be
+	// delivered without blocking.
+	text = append(make([]byte, 4*streamChunk), "ab"...)
+	for i := 0; i < 20; i++ {
+		for m := range ac.MatchesChan(ctx, text, 1) {
+			t.Fatalf("Unexpected match %+v after cancellation", m)
+		}
+	}
+}
+
+func TestACKS_FindN_Source(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("evil"), ID: 1, Source: "rules/web.txt:12"})
+	ac.Build()
+
+	got := ac.FindN([]byte("an evil plan"), 1)
+	expected := []Match{{ID: 1, From: 3, To: 7, Source: "rules/web.txt:12"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestACKS_FindAllIndex(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("cat", 1, 0))
+	ac.AddPattern(mkPat("dog", 2, 0))
+	ac.Build()
+
+	// Without overlaps the output is identical to the regexp alternation.
+	text := []byte("a cat, a dog and a catdog")
+	re := regexp.MustCompile("cat|dog")
+	if got, want := ac.FindAllIndex(text, -1), re.FindAllIndex(text, -1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got, want := ac.FindAllIndex(text, 2), re.FindAllIndex(text, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := ac.FindAllIndex([]byte("none"), -1); got != nil {
+		t.Errorf("Expected nil, got %v", got)
+	}
+}
+
+func TestACKS_Contains(t *testing.T) {
+	ac := buildWords([]string{"he", "she"})
+	if !ac.Contains([]byte("ushers")) || ac.Contains([]byte("xyz")) {
+		t.Errorf("Contains gave wrong results")
+	}
+}
+
+func TestACKS_EmptyPatternSet(t *testing.T) {
+	ac := NewACKS()
+	if err := ac.Build(); err != nil {
+		t.Fatal(err)
+	}
+	text := []byte("nothing to see here")
+	if ac.Contains(text) || ac.MayMatch(text) {
+		t.Errorf("Expected no match from an empty pattern set")
+	}
+	ids, err := ac.Search(text)
+	if err != nil || ids == nil || len(ids) != 0 {
+		t.Errorf("Expected an empty result, got %v, %v", ids, err)
+	}
+	m := func(id uint, from, to uint64) error { return nil }
+	allocs := testing.AllocsPerRun(100, func() {
+		ac.Contains(text)
+		ac.Search(text)
+		ac.Scan(text, m)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestACKS_MatchesEndingAt(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("he", 1, SingleMatch))
+	ac.AddPattern(mkPat("she", 2, 0))
+	ac.AddPattern(mkPat("hers", 3, 0))
+	ac.AddPattern(mkPat("she", 4, LineAnchoredStart|LineAnchoredEnd))
+	ac.Build()
+
+	text := []byte("he said she\nshe\nhers")
+	for _, c := range []struct {
+		pos  int
+		want []Match
+	}{
+		{2, []Match{{ID: 1, From: 0, To: 2}}},
+		{11, []Match{{ID: 2, From: 8, To: 11}, {ID: 1, From: 9, To: 11}}},
+		{15, []Match{{ID: 2, From: 12, To: 15}, {ID: 4, From: 12, To: 15}, {ID: 1, From: 13, To: 15}}},
+		{20, []Match{{ID: 3, From: 16, To: 20}}},
+		{3, nil},
+		{21, nil},
+	} {
+		if got := ac.MatchesEndingAt(text, c.pos); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("At %d: Expected %v, got %v", c.pos, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ahocorasick
+
+import (
+	"math/bits"
+)
+
+// MatchSet is a set of pattern IDs stored as a bitset over the matcher's
+// dense ID slots, for boolean logic over scan results without maps. Sets
+// combined with each other must come from the same matcher.
+type MatchSet struct {
+	ac   *ACKS
+	bits []uint64
+}
+
+// NewMatchSet returns an empty set for the built matcher, sized for all
+// its pattern IDs.
+func (ac *ACKS) NewMatchSet() *MatchSet {
+	return &MatchSet{ac: ac, bits: make([]uint64, (len(ac.ids)+63)/64)}
+}
+
+// MatchSet scans text and returns the set of pattern IDs found.
+func (ac *ACKS) MatchSet(text []byte) (*MatchSet, error) {
+	s := ac.NewMatchSet()
+	return s, ac.ScanSet(text, s)
+}
+
+// ScanSet clears s and fills it with the pattern IDs found in text. Reusing
+// s across scans avoids allocating a new bitset.
+func (ac *ACKS) ScanSet(text []byte, s *MatchSet) error {
+	s.Clear()
+	return ac.searchPatterns(text, &handler{fn: func(from, to uint64, ps *Pattern) error {
+		s.bits[ps.slot/64] |= 1 << (ps.slot % 64)
+		return nil
+	}})
+}
+
+// clone returns a copy of s.
+func (s *MatchSet) clone() *MatchSet {
+	return &MatchSet{ac: s.ac, bits: append([]uint64(nil), s.bits...)}
+}
+
+// Add inserts id, if the matcher has a pattern with that ID.
+func (s *MatchSet) Add(id uint) {
+	if slot, ok := s.ac.slotOf(id); ok {
+		s.bits[slot/64] |= 1 << (slot % 64)
+	}
+}
+
+// Contains reports whether id is in the set.
+func (s *MatchSet) Contains(id uint) bool {
+	slot, ok := s.ac.slotOf(id)
+	return ok && s.bits[slot/64]&(1<<(slot%64)) != 0
+}
+
+// Union returns the IDs in s or o.
+func (s *MatchSet) Union(o *MatchSet) *MatchSet {
+	r := s.ac.NewMatchSet()
+	for i := range r.bits {
+		r.bits[i] = s.bits[i] | o.bits[i]
+	}
+	return r
+}
+
+// Intersect returns the IDs in both s and o.
+func (s *MatchSet) Intersect(o *MatchSet) *MatchSet {
+	r := s.ac.NewMatchSet()
+	for i := range r.bits {
+		r.bits[i] = s.bits[i] & o.bits[i]
+	}
+	return r
+}
+
+// Intersects reports whether s and o have an ID in common, without
+// allocating.
+func (s *MatchSet) Intersects(o *MatchSet) bool {
+	for i := range s.bits {
+		if s.bits[i]&o.bits[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of IDs in the set.
+func (s *MatchSet) Len() int {
+	n := 0
+	for _, w := range s.bits {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// IDs returns the IDs in the set, in order of first appearance among the
+// patterns.
+func (s *MatchSet) IDs() []uint {
+	var ids []uint
+	for i, w := range s.bits {
+		for w != 0 {
+			slot := i*64 + bits.TrailingZeros64(w)
+			ids = append(ids, s.ac.ids[slot])
+			w &= w - 1
+		}
+	}
+	return ids
+}
+
+// Clear removes every ID.
+func (s *MatchSet) Clear() {
+	clear(s.bits)
+}
@@ -0,0 +1,38 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchSet(t *testing.T) {
+	ac := buildWords([]string{"stock", "bond", "goal", "match", "fund"})
+	finance := ac.NewMatchSet()
+	for _, id := range []uint{1, 2, 5} {
+		finance.Add(id)
+	}
+	sports := ac.NewMatchSet()
+	sports.Add(3)
+	sports.Add(4)
+
+	doc, err := ac.MatchSet([]byte("the fund bought stock after the match"))
+	if err != nil {
+		t.Fatalf("MatchSet failed: %v", err)
+	}
+	if !reflect.DeepEqual(doc.IDs(), []uint{1, 4, 5}) || doc.Len() != 3 {
+		t.Errorf("Unexpected set %v", doc.IDs())
+	}
+	if got := doc.Intersect(finance).IDs(); !reflect.DeepEqual(got, []uint{1, 5}) {
+		t.Errorf("Expected [1 5], got %v", got)
+	}
+	if !doc.Intersects(sports) || finance.Intersects(sports) {
+		t.Errorf("Unexpected Intersects results")
+	}
+	if u := finance.Union(sports); u.Len() != 5 || !u.Contains(3) || u.Contains(9) {
+		t.Errorf("Unexpected union %v", u.IDs())
+	}
+
+	if err := ac.ScanSet([]byte("bond goal"), doc); err != nil || !reflect.DeepEqual(doc.IDs(), []uint{2, 3}) {
+		t.Errorf("Expected the reused set to be cleared, got %v", doc.IDs())
+	}
+}
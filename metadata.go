@@ -0,0 +1,178 @@
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metadata describes the rule build a compiled database came from. It is
+// stored in the image written by WriteTo, so a service can report which
+// build it loaded.
+type Metadata struct {
+	// BuiltAt is stamped by Build when left zero.
+	BuiltAt        time.Time
+	RuleSet        string
+	RuleSetVersion string
+	// Builder names the compiler; Build fills in this module and its
+	// version when left empty.
+	Builder string
+	// Extra holds free-form pairs. Keys starting with "acks." are
+	// reserved, and Build fails if one is used.
+	Extra map[string]string
+}
+
+// modulePath is the path of this module, for Metadata.Builder.
+const modulePath = "github.com/yanlinLiu0424/ahocorasick"
+
+// Keys of the Metadata fields in the image. Extra keys are stored as they
+// are and may not use metaPrefix, which Build rejects.
+const (
+	metaPrefix         = "acks."
+	metaBuiltAt        = "acks.built_at"
+	metaRuleSet        = "acks.ruleset"
+	metaRuleSetVersion = "acks.ruleset_version"
+	metaBuilder        = "acks.builder"
+)
+
+// WithMetadata attaches m to the matcher and to the databases it writes.
+func WithMetadata(m Metadata) Option {
+	return func(ac *ACKS) {
+		m.Extra = maps.Clone(m.Extra)
+		ac.meta = &m
+	}
+}
+
+// Metadata returns the metadata given to WithMetadata or loaded with the
+// database, and false if there is none.
+func (ac *ACKS) Metadata() (Metadata, bool) {
+	if ac.meta == nil {
+		return Metadata{}, false
+	}
+	m := *ac.meta
+	m.Extra = maps.Clone(m.Extra)
+	return m, true
+}
+
+// stampMetadata checks the Extra keys and fills in the fields Build is
+// responsible for.
+func (ac *ACKS) stampMetadata() error {
+	if ac.meta == nil {
+		return nil
+	}
+	for k := range ac.meta.Extra {
+		if strings.HasPrefix(k, metaPrefix) {
+			return fmt.Errorf("ahocorasick: metadata key %q uses the reserved %q prefix", k, metaPrefix)
+		}
+	}
+	if ac.meta.BuiltAt.IsZero() {
+		ac.meta.BuiltAt = time.Now().UTC()
+	}
+	if ac.meta.Builder == "" {
+		ac.meta.Builder = modulePath + "@" + moduleVersion()
+	}
+	return nil
+}
+
+// moduleVersion returns the version of this module in the running binary.
+func moduleVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Path == modulePath {
+			return bi.Main.Version
+		}
+		for _, d := range bi.Deps {
+			if d.Path == modulePath {
+				return d.Version
+			}
+		}
+	}
+	return "(unknown)"
+}
+
+// appendMetadata encodes the metadata as a count of key/value pairs, each
+// string prefixed by its length, with Extra in key order. No metadata
+// encodes as nothing.
+func (ac *ACKS) appendMetadata(b []byte) []byte {
+	m := ac.meta
+	if m == nil {
+		return b
+	}
+	type kv struct{ k, v string }
+	var pairs []kv
+	if !m.BuiltAt.IsZero() {
+		pairs = append(pairs, kv{metaBuiltAt, m.BuiltAt.Format(time.RFC3339Nano)})
+	}
+	for _, p := range []kv{{metaRuleSet, m.RuleSet}, {metaRuleSetVersion, m.RuleSetVersion}, {metaBuilder, m.Builder}} {
+		if p.v != "" {
+			pairs = append(pairs, p)
+		}
+	}
+	keys := make([]string, 0, len(m.Extra))
+	for k := range m.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pairs = append(pairs, kv{k, m.Extra[k]})
+	}
+	le := binary.LittleEndian
+	b = le.AppendUint32(b, uint32(len(pairs)))
+	for _, p := range pairs {
+		b = le.AppendUint32(b, uint32(len(p.k)))
+		b = append(b, p.k...)
+		b = le.AppendUint32(b, uint32(len(p.v)))
+		b = append(b, p.v...)
+	}
+	return b
+}
+
+// parseMetadata decodes a section written by appendMetadata.
+func parseMetadata(b []byte) (*Metadata, bool) {
+	le := binary.LittleEndian
+	str := func() (string, bool) {
+		if len(b) < 4 || uint64(le.Uint32(b)) > uint64(len(b)-4) {
+			return "", false
+		}
+		n := le.Uint32(b)
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+	if len(b) < 4 {
+		return nil, false
+	}
+	count := le.Uint32(b)
+	b = b[4:]
+	m := &Metadata{}
+	for i := uint32(0); i < count; i++ {
+		k, ok1 := str()
+		v, ok2 := str()
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		switch k {
+		case metaBuiltAt:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, false
+			}
+			m.BuiltAt = t
+		case metaRuleSet:
+			m.RuleSet = v
+		case metaRuleSetVersion:
+			m.RuleSetVersion = v
+		case metaBuilder:
+			m.Builder = v
+		default:
+			if m.Extra == nil {
+				m.Extra = make(map[string]string)
+			}
+			m.Extra[k] = v
+		}
+	}
+	return m, len(b) == 0
+}
@@ -0,0 +1,61 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestACKS_Metadata(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 5, time.UTC)
+	ac := buildWords([]string{"he", "she"}, WithMetadata(Metadata{
+		BuiltAt:        at,
+		RuleSet:        "web",
+		RuleSetVersion: "2026.03",
+		Extra:          map[string]string{"commit": "abc123", "owner": "secops"},
+	}))
+	var buf bytes.Buffer
+	if _, err := ac.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ac.Metadata()
+	got, ok := loaded.Metadata()
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if !strings.HasPrefix(got.Builder, modulePath+"@") || !got.BuiltAt.Equal(at) {
+		t.Errorf("Unexpected builder %q or time %v", got.Builder, got.BuiltAt)
+	}
+
+	plain := buildWords([]string{"he"})
+	buf.Reset()
+	plain.WriteTo(&buf)
+	if loaded, err := Load(&buf); err != nil {
+		t.Fatal(err)
+	} else if _, ok := loaded.Metadata(); ok {
+		t.Errorf("Expected no metadata")
+	}
+}
+
+func TestACKS_MetadataBuiltAt(t *testing.T) {
+	before := time.Now()
+	ac := buildWords([]string{"he"}, WithMetadata(Metadata{RuleSet: "web"}))
+	m, _ := ac.Metadata()
+	if m.BuiltAt.Before(before.Add(-time.Second)) || m.BuiltAt.After(time.Now()) {
+		t.Errorf("Expected Build to stamp the time, got %v", m.BuiltAt)
+	}
+}
+
+func TestACKS_MetadataReservedKey(t *testing.T) {
+	ac := NewACKS(WithMetadata(Metadata{Extra: map[string]string{"acks.ruleset": "web"}}))
+	ac.AddPattern(mkPat("he", 1, 0))
+	if err := ac.Build(); err == nil || !strings.Contains(err.Error(), "acks.ruleset") {
+		t.Errorf("Expected the reserved key to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package ahocorasick
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// MIMEPart identifies the message part a match was found in.
+type MIMEPart struct {
+	// Path numbers the part as IMAP does: "1" for the body of a simple
+	// message, "2.1" for the first part inside the second part.
+	Path        string
+	ContentType string
+	Filename    string // from Content-Disposition or the Content-Type name
+}
+
+// ScanMessage reads an RFC 5322 message from r, walks its multipart tree and
+// scans the body of every leaf part after undoing its base64 or
+// quoted-printable transfer encoding. Each part is streamed through the
+// matcher, so large attachments are not held in memory. Match offsets are
+// relative to the decoded part. Headers are not scanned.
+func (ac *ACKS) ScanMessage(r io.Reader, m func(part MIMEPart, match Match) error) error {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return fmt.Errorf("ahocorasick: %w", err)
+	}
+	return ac.scanEntity(textproto.MIMEHeader(msg.Header), msg.Body, "", m)
+}
+
+func (ac *ACKS) scanEntity(h textproto.MIMEHeader, body io.Reader, path string, m func(MIMEPart, Match) error) error {
+	ctype, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		ctype, params = "text/plain", nil
+	}
+	if strings.HasPrefix(ctype, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for i := 1; ; i++ {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("ahocorasick: part %s: %w", subPart(path, i), err)
+			}
+			if err := ac.scanEntity(p.Header, p, subPart(path, i), m); err != nil {
+				return err
+			}
+		}
+	}
+
+	part := MIMEPart{Path: path, ContentType: ctype, Filename: params["name"]}
+	if part.Path == "" {
+		part.Path = "1"
+	}
+	if _, dp, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil && dp["filename"] != "" {
+		part.Filename = dp["filename"]
+	}
+	switch strings.ToLower(strings.TrimSpace(h.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	return ac.scanAll(body, &handler{fn: func(from, to uint64, ps *Pattern) error {
+		return m(part, newMatch(from, to, ps))
+	}})
+}
+
+func subPart(path string, i int) string {
+	if path == "" {
+		return strconv.Itoa(i)
+	}
+	return path + "." + strconv.Itoa(i)
+}
@@ -0,0 +1,54 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ScanMessage(t *testing.T) {
+	ac := buildWords([]string{"password", "confidential"})
+	msg := strings.ReplaceAll(`From: a@example.com
+Subject: password
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain
+Content-Transfer-Encoding: quoted-printable
+
+my pass=
+word is here
+--inner
+Content-Type: text/html
+
+<b>no match</b>
+--inner--
+--outer
+Content-Type: application/octet-stream
+Content-Disposition: attachment; filename="plan.txt"
+Content-Transfer-Encoding: base64
+
+c3RyaWN0bHkgY29uZmlkZW50aWFs
+--outer--
+`, "\n", "\r\n")
+
+	var got []string
+	err := ac.ScanMessage(strings.NewReader(msg), func(p MIMEPart, m Match) error {
+		got = append(got, p.Path+" "+p.ContentType+" "+p.Filename)
+		if p.Path == "1.1" && (m.From != 3 || m.To != 11) {
+			t.Errorf("Unexpected offsets %d-%d", m.From, m.To)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanMessage failed: %v", err)
+	}
+	want := []string{"1.1 text/plain ", "2 application/octet-stream plan.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
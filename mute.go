@@ -0,0 +1,53 @@
+package ahocorasick
+
+// Muting silences pattern IDs at report time without rebuilding or swapping
+// the matcher. Muted hits are still counted by an Accumulator but are
+// otherwise dropped, so they do not use up SingleMatch, dedup windows or
+// samples. Muting is safe while scans run and takes effect on the next hit.
+
+// Mute silences pattern id. It returns false if the matcher has no such
+// pattern.
+func (ac *ACKS) Mute(id uint) bool {
+	slot, ok := ac.slotOf(id)
+	if ok {
+		ac.muted[slot/64].Or(1 << (slot % 64))
+	}
+	return ok
+}
+
+// Unmute reverses Mute.
+func (ac *ACKS) Unmute(id uint) bool {
+	slot, ok := ac.slotOf(id)
+	if ok {
+		ac.muted[slot/64].And(^uint64(1 << (slot % 64)))
+	}
+	return ok
+}
+
+// Muted reports whether pattern id is muted.
+func (ac *ACKS) Muted(id uint) bool {
+	slot, ok := ac.slotOf(id)
+	return ok && ac.mutedSlot(slot)
+}
+
+// MuteAllExcept turns the overlay into an allow-list: every pattern ID is
+// muted except those in allow.
+func (ac *ACKS) MuteAllExcept(allow ...uint) {
+	for i := range ac.muted {
+		ac.muted[i].Store(^uint64(0))
+	}
+	for _, id := range allow {
+		ac.Unmute(id)
+	}
+}
+
+// UnmuteAll clears the overlay.
+func (ac *ACKS) UnmuteAll() {
+	for i := range ac.muted {
+		ac.muted[i].Store(0)
+	}
+}
+
+func (ac *ACKS) mutedSlot(slot int) bool {
+	return ac.muted[slot/64].Load()&(1<<(slot%64)) != 0
+}
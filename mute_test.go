@@ -0,0 +1,32 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMute(t *testing.T) {
+	ac := buildWords([]string{"foo", "bar", "baz"})
+	acc := ac.EnableCounters()
+	text := []byte("foo bar baz foo")
+
+	if !ac.Mute(1) || ac.Mute(9) || !ac.Muted(1) {
+		t.Fatalf("Unexpected Mute results")
+	}
+	if got, _ := ac.Search(text); !reflect.DeepEqual(got, []uint{2, 3}) {
+		t.Errorf("Expected muted 1 to be dropped, got %v", got)
+	}
+	if acc.Count(1) != 2 {
+		t.Errorf("Expected muted hits to be counted, got %d", acc.Count(1))
+	}
+
+	ac.MuteAllExcept(3)
+	if got, _ := ac.Search(text); !reflect.DeepEqual(got, []uint{3}) {
+		t.Errorf("Expected only 3 allowed, got %v", got)
+	}
+
+	ac.UnmuteAll()
+	if got, _ := ac.Search(text); !reflect.DeepEqual(got, []uint{1, 2, 3, 1}) || ac.Muted(1) {
+		t.Errorf("Expected every match after UnmuteAll, got %v", got)
+	}
+}
@@ -0,0 +1,242 @@
+package ahocorasick
+
+import (
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalizer rewrites text before it is matched.
+//
+// Transform writes the normalized form of src into dst[:0] and returns it
+// together with an offset map. The map has len(out)+1 entries: offsets[i] is
+// the index in src of the first byte that produced out[i], and
+// offsets[len(out)] is len(src). A match covering out[a:b] therefore covers
+// src[offsets[a]:offsets[b]].
+type Normalizer interface {
+	Transform(dst, src []byte) ([]byte, []int)
+}
+
+// TextOnlyNormalizer is implemented by normalizers that only apply to the
+// scanned text. Patterns skip them when TextOnly reports true, for example
+// because they are already authored in the normalized form.
+type TextOnlyNormalizer interface {
+	Normalizer
+	TextOnly() bool
+}
+
+// WithNormalizers applies a chain of normalizers, in order, to every pattern
+// added to the matcher and to every scanned text. Matches are reported with
+// positions in the original text.
+func WithNormalizers(n ...Normalizer) Option {
+	return func(ac *ACKS) {
+		ac.normalizers = append(ac.normalizers, n...)
+	}
+}
+
+// normalize runs text through the normalizer chain and returns the composed
+// offset map back into text.
+func (ac *ACKS) normalize(text []byte) ([]byte, []int) {
+	return ac.runNormalizers(text, false)
+}
+
+// normalizePattern runs pattern content through the normalizers that apply
+// to patterns.
+func (ac *ACKS) normalizePattern(content []byte) []byte {
+	out, _ := ac.runNormalizers(content, true)
+	return out
+}
+
+func (ac *ACKS) runNormalizers(text []byte, pattern bool) ([]byte, []int) {
+	var offsets []int
+	out := text
+	for _, n := range ac.normalizers {
+		if t, ok := n.(TextOnlyNormalizer); ok && pattern && t.TextOnly() {
+			continue
+		}
+		var next []int
+		out, next = n.Transform(nil, out)
+		if offsets != nil {
+			for i, off := range next {
+				next[i] = offsets[off]
+			}
+		}
+		offsets = next
+	}
+	if offsets == nil {
+		offsets = make([]int, len(out)+1)
+		for i := range offsets {
+			offsets[i] = i
+		}
+	}
+	return out, offsets
+}
+
+// CaseFoldNormalizer folds ASCII letters to lowercase.
+type CaseFoldNormalizer struct{}
+
+func (CaseFoldNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	for i, b := range src {
+		dst = append(dst, toLower(b))
+		offsets = append(offsets, i)
+	}
+	return dst, append(offsets, len(src))
+}
+
+// EntityNormalizer decodes HTML character references such as "&amp;",
+// "&#60;" and "&#x3c;". Unknown or malformed references are kept verbatim.
+// "&nbsp;" decodes to U+00A0 like "&#160;"; follow with WhitespaceNormalizer
+// to match it as a space.
+type EntityNormalizer struct{}
+
+var htmlEntities = map[string]string{
+	"amp":  "&",
+	"lt":   "<",
+	"gt":   ">",
+	"quot": "\"",
+	"apos": "'",
+	"nbsp": "\u00a0",
+}
+
+func (EntityNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	for i := 0; i < len(src); {
+		if src[i] == '&' {
+			if decoded, n := decodeEntity(src[i:]); n > 0 {
+				for j := 0; j < len(decoded); j++ {
+					dst = append(dst, decoded[j])
+					offsets = append(offsets, i)
+				}
+				i += n
+				continue
+			}
+		}
+		dst = append(dst, src[i])
+		offsets = append(offsets, i)
+		i++
+	}
+	return dst, append(offsets, len(src))
+}
+
+// decodeEntity decodes the character reference at the start of b and returns
+// the replacement text and the number of bytes consumed, or 0 if b does not
+// start with a valid reference.
+func decodeEntity(b []byte) (string, int) {
+	const maxEntityLen = 10
+	end := -1
+	for i := 1; i < len(b) && i <= maxEntityLen; i++ {
+		if b[i] == ';' {
+			end = i
+			break
+		}
+	}
+	if end < 2 {
+		return "", 0
+	}
+	name := string(b[1:end])
+	if name[0] == '#' {
+		var v uint64
+		var err error
+		if len(name) > 1 && (name[1] == 'x' || name[1] == 'X') {
+			v, err = strconv.ParseUint(name[2:], 16, 32)
+		} else {
+			v, err = strconv.ParseUint(name[1:], 10, 32)
+		}
+		if err != nil || v > 0x10ffff {
+			return "", 0
+		}
+		return string(rune(v)), end + 1
+	}
+	if s, ok := htmlEntities[name]; ok {
+		return s, end + 1
+	}
+	return "", 0
+}
+
+// WhitespaceNormalizer collapses every run of whitespace into a single space
+// and drops zero-width and other invisible characters, so "f  o\to" and
+// "f\u200bo\u200bo" normalize to "f o o" and "foo". With Strip set,
+// whitespace is removed entirely and "f o o" becomes "foo".
+type WhitespaceNormalizer struct {
+	Strip bool
+}
+
+func (n WhitespaceNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	inSpace := false
+	for i := 0; i < len(src); {
+		r, size := rune(src[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(src[i:])
+		}
+		switch {
+		case isInvisible(r):
+			// Dropped without breaking the surrounding run.
+		case isSpace(r):
+			if !inSpace && !n.Strip {
+				dst = append(dst, ' ')
+				offsets = append(offsets, i)
+			}
+			inSpace = true
+		default:
+			inSpace = false
+			for j := 0; j < size; j++ {
+				dst = append(dst, src[i+j])
+				offsets = append(offsets, i)
+			}
+		}
+		i += size
+	}
+	return dst, append(offsets, len(src))
+}
+
+func isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\v', '\f', '\r', 0x85, 0xa0, 0x3000:
+		return true
+	}
+	return r >= 0x2000 && r <= 0x200a
+}
+
+func isInvisible(r rune) bool {
+	switch r {
+	case 0xad, 0x034f, 0x180e, 0x200b, 0x200c, 0x200d, 0x2060, 0x2061, 0x2062, 0x2063, 0x2064, 0xfeff:
+		return true
+	}
+	return false
+}
+
+// TurkishCaseFoldNormalizer lowercases text with the Turkish and Azeri
+// casing rules, where "I" folds to dotless "ı" and dotted "İ" folds to "i".
+// ASCII folding gets both wrong for these languages. Every pattern is folded
+// as well, so all patterns match caselessly.
+type TurkishCaseFoldNormalizer struct{}
+
+func (TurkishCaseFoldNormalizer) Transform(dst, src []byte) ([]byte, []int) {
+	dst = dst[:0]
+	offsets := make([]int, 0, len(src)+1)
+	for i := 0; i < len(src); {
+		r, size := rune(src[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(src[i:])
+			if r == utf8.RuneError && size == 1 {
+				// Keep invalid bytes as they are.
+				dst = append(dst, src[i])
+				offsets = append(offsets, i)
+				i++
+				continue
+			}
+		}
+		start := len(dst)
+		dst = utf8.AppendRune(dst, unicode.TurkishCase.ToLower(r))
+		for j := start; j < len(dst); j++ {
+			offsets = append(offsets, i)
+		}
+		i += size
+	}
+	return dst, append(offsets, len(src))
+}
@@ -0,0 +1,122 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_Normalizers_OriginalOffsets(t *testing.T) {
+	ac := NewACKS(WithNormalizers(EntityNormalizer{}, CaseFoldNormalizer{}))
+	ac.AddPattern(mkPat("<script>", 1, 0))
+	ac.Build()
+
+	text := []byte("x&LT;SCRIPT&#x3e;y")
+	var got [][2]uint64
+	err := ac.Scan(text, func(id uint, from, to uint64) error {
+		got = append(got, [2]uint64{uint64(id), to})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	// &LT; is not a known entity (names are case-sensitive), so only the
+	// lowercase form matches.
+	if len(got) != 0 {
+		t.Fatalf("Expected no matches, got %v", got)
+	}
+
+	text = []byte("x&lt;SCRIPT&#x3e;y")
+	err = ac.Scan(text, func(id uint, from, to uint64) error {
+		got = append(got, [2]uint64{uint64(id), to})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	expected := [][2]uint64{{1, uint64(len(text) - 1)}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestEntityNormalizer_Offsets(t *testing.T) {
+	out, offsets := EntityNormalizer{}.Transform(nil, []byte("a&amp;b&bogus;"))
+	if string(out) != "a&b&bogus;" {
+		t.Fatalf("Unexpected output %q", out)
+	}
+	expected := []int{0, 1, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+	if !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("Expected %v, got %v", expected, offsets)
+	}
+}
+
+func TestEntityNormalizer_Nbsp(t *testing.T) {
+	out, _ := EntityNormalizer{}.Transform(nil, []byte("a&nbsp;b&#160;c"))
+	if string(out) != "a\u00a0b\u00a0c" {
+		t.Fatalf("Unexpected output %q", out)
+	}
+
+	ac := NewACKS(WithNormalizers(EntityNormalizer{}, WhitespaceNormalizer{}))
+	ac.AddPattern(mkPat("drop table", 1, 0))
+	ac.Build()
+	if got := ac.FindAllIndex([]byte("drop&nbsp;table"), -1); !reflect.DeepEqual(got, [][]int{{0, 15}}) {
+		t.Errorf("Expected a match over the entity, got %v", got)
+	}
+}
+
+func TestWhitespaceNormalizer_Evasions(t *testing.T) {
+	ac := NewACKS(WithNormalizers(WhitespaceNormalizer{Strip: true}))
+	ac.AddPattern(mkPat("foo", 1, 0))
+	ac.Build()
+
+	for _, text := range []string{"f o  o", "f\u200bo\u200bo", "f\t o\r\no"} {
+		var ends []uint64
+		err := ac.Scan([]byte(text), func(id uint, from, to uint64) error {
+			ends = append(ends, to)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(ends) != 1 || ends[0] != uint64(len(text)) {
+			t.Errorf("%q: Expected one match ending at %d, got %v", text, len(text), ends)
+		}
+	}
+
+	out, _ := WhitespaceNormalizer{}.Transform(nil, []byte("a \t b\u200b\u200bc"))
+	if string(out) != "a bc" {
+		t.Errorf("Expected collapsed output %q, got %q", "a bc", out)
+	}
+}
+
+func TestTurkishCaseFoldNormalizer(t *testing.T) {
+	ac := NewACKS(WithNormalizers(TurkishCaseFoldNormalizer{}))
+	ac.AddPattern(mkPat("İSTANBUL", 1, 0))
+	ac.AddPattern(mkPat("ılık", 2, 0))
+	ac.Build()
+
+	tests := []struct {
+		text string
+		want []uint
+	}{
+		{"istanbul", []uint{1}},
+		{"İstanbul", []uint{1}},
+		// Plain ASCII "I" is dotless in Turkish.
+		{"Istanbul", nil},
+		{"ILIK", []uint{2}},
+		{"ilik", nil},
+	}
+	for _, tt := range tests {
+		var got []uint
+		ac.Scan([]byte(tt.text), func(id uint, from, to uint64) error {
+			if to != uint64(len(tt.text)) {
+				t.Errorf("%q: Expected match ending at %d, got %d", tt.text, len(tt.text), to)
+			}
+			got = append(got, id)
+			return nil
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// OffloadOutput is set in an OffloadTables transition whose target state
+// has outputs, so a kernel can skip the output lookup on most bytes.
+const OffloadOutput = 1 << 31
+
+// OffloadTables is the automaton rewritten for upload to an OpenCL or CUDA
+// kernel or an FPGA scanner. Table is row-major: the transition of state s
+// on symbol c is Table[s*Stride+c] with OffloadOutput masked off, and the
+// pattern IDs ending in state s are Outputs[OutputIndex[s]:OutputIndex[s+1]].
+// Bytes map to symbols through Translate, as in Tables; matches of patterns
+// for which Tables reports Verify are only candidates.
+type OffloadTables struct {
+	States      int
+	Alphabet    int
+	Stride      int
+	Translate   [256]uint8
+	Table       []uint32
+	OutputIndex []uint32
+	Outputs     []uint32
+}
+
+// ExportOffload returns the automaton as OffloadTables. Rows are padded
+// with zeros to a multiple of rowAlign entries, and each array starts at an
+// address that is a multiple of align bytes and is zero-padded to a multiple
+// of align bytes, ready for a single DMA or buffer copy. align must be a
+// power of two of at least 4. Nibble mode is not supported.
+func (ac *ACKS) ExportOffload(rowAlign, align int) (*OffloadTables, error) {
+	if ac.nibble {
+		return nil, errors.New("ahocorasick: offload export does not support nibble mode")
+	}
+	if rowAlign < 1 {
+		return nil, fmt.Errorf("ahocorasick: invalid row alignment %d", rowAlign)
+	}
+	if align < 4 || align&(align-1) != 0 {
+		return nil, fmt.Errorf("ahocorasick: alignment %d is not a power of two of at least 4", align)
+	}
+	if ac.stateCount >= OffloadOutput {
+		return nil, fmt.Errorf("ahocorasick: %d states do not fit an offload table", ac.stateCount)
+	}
+	t := ac.Tables()
+	o := &OffloadTables{
+		States:    t.States,
+		Alphabet:  t.Alphabet,
+		Stride:    (t.Alphabet + rowAlign - 1) / rowAlign * rowAlign,
+		Translate: t.Translate,
+	}
+	o.Table = alignedUint32s(t.States*o.Stride, align)
+	for s := 0; s < t.States; s++ {
+		for c := 0; c < t.Alphabet; c++ {
+			next := t.Next[s*t.Alphabet+c]
+			e := uint32(next)
+			if t.OutputIndex[next] != t.OutputIndex[next+1] {
+				e |= OffloadOutput
+			}
+			o.Table[s*o.Stride+c] = e
+		}
+	}
+	o.OutputIndex = alignedUint32s(len(t.OutputIndex), align)
+	copy(o.OutputIndex, t.OutputIndex)
+	o.Outputs = alignedUint32s(len(t.Outputs), align)
+	for i, k := range t.Outputs {
+		if t.IDs[k] > math.MaxUint32 {
+			return nil, fmt.Errorf("ahocorasick: pattern ID %d does not fit an offload table", t.IDs[k])
+		}
+		o.Outputs[i] = uint32(t.IDs[k])
+	}
+	return o, nil
+}
+
+// alignedUint32s returns n zeroed values starting at a multiple of align
+// bytes, with a zeroed capacity rounded up to a multiple of align bytes.
+// The returned length is n.
+func alignedUint32s(n, align int) []uint32 {
+	per := align / 4
+	padded := (n + per - 1) / per * per
+	buf := make([]uint32, padded+per)
+	skip := 0
+	for uintptr(unsafe.Pointer(&buf[skip]))%uintptr(align) != 0 {
+		skip++
+	}
+	return buf[skip : skip+n : skip+padded]
+}
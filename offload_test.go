@@ -0,0 +1,44 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestACKS_ExportOffload(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers"})
+	o, err := ac.ExportOffload(16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Stride%16 != 0 || o.Stride < o.Alphabet {
+		t.Fatalf("Unexpected stride %d for alphabet %d", o.Stride, o.Alphabet)
+	}
+	for _, a := range [][]uint32{o.Table, o.OutputIndex, o.Outputs} {
+		if uintptr(unsafe.Pointer(&a[:1][0]))%64 != 0 || cap(a)*4%64 != 0 {
+			t.Fatalf("Array of %d entries is not aligned", len(a))
+		}
+	}
+
+	// Run the table as a kernel would.
+	var got []uint
+	s := uint32(0)
+	for _, b := range []byte("ushers") {
+		e := o.Table[int(s)*o.Stride+int(o.Translate[b])]
+		s = e &^ OffloadOutput
+		if e&OffloadOutput != 0 {
+			for _, id := range o.Outputs[o.OutputIndex[s]:o.OutputIndex[s+1]] {
+				got = append(got, uint(id))
+			}
+		}
+	}
+	want, _ := ac.Search([]byte("ushers"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := ac.ExportOffload(1, 6); err == nil {
+		t.Errorf("Expected error for a bad alignment")
+	}
+}
@@ -0,0 +1,74 @@
+package ahocorasick
+
+// packetScratch is the state ScanPackets reuses between payloads.
+type packetScratch struct {
+	ss  scanState
+	h   handler
+	gen uint64 // ACKS.gen the state was made for
+}
+
+// ScanPackets scans every payload as a separate input and sets verdicts[i]
+// to the highest Verdict among the rules that hold on payloads[i], or 0 if
+// none does. verdicts must be at least as long as payloads. The scan state
+// is recycled between calls, so a polling loop over packet batches does not
+// allocate unless normalizers are set. It is safe for concurrent use.
+func (ac *ACKS) ScanPackets(payloads [][]byte, verdicts []uint8) {
+	ps := ac.getPacketScratch()
+	verdicts = verdicts[:len(payloads)]
+	for i, p := range payloads {
+		ps.ss.reset()
+		// The empty handler never fails.
+		_ = ac.searchWith(&ps.ss, p, &ps.h)
+		var v uint8
+		for _, r := range ac.rules {
+			if r.Verdict > v && r.expr.eval(ps.ss.rules) {
+				v = r.Verdict
+			}
+		}
+		verdicts[i] = v
+	}
+	ac.packets.Put(ps)
+}
+
+func (ac *ACKS) getPacketScratch() *packetScratch {
+	ps, _ := ac.packets.Get().(*packetScratch)
+	if ps == nil || ps.gen != ac.gen {
+		ps = &packetScratch{ss: scanState{record: ac.newMatchRecord(), rules: ac.newRuleState()}, gen: ac.gen}
+	}
+	return ps
+}
+
+// ScanResult summarizes the matches of a scan by ScanInto.
+type ScanResult struct {
+	// Count is the number of matches; the other fields are only set when
+	// it is not 0.
+	Count uint64
+	// FirstID is the ID of the first match to end.
+	FirstID uint
+	// MaxSeverity is the highest Severity among the matched patterns.
+	MaxSeverity int
+}
+
+func (r *ScanResult) add(ps *Pattern) {
+	if r.Count == 0 || ps.Severity > r.MaxSeverity {
+		r.MaxSeverity = ps.Severity
+	}
+	if r.Count == 0 {
+		r.FirstID = ps.ID
+	}
+	r.Count++
+}
+
+// ScanInto scans text and stores a summary of its matches in r instead of
+// calling a handler, for fast paths that only need a verdict. Like
+// ScanPackets it does not allocate unless normalizers are set.
+func (ac *ACKS) ScanInto(text []byte, r *ScanResult) {
+	*r = ScanResult{}
+	ps := ac.getPacketScratch()
+	ps.ss.reset()
+	ps.h.result = r
+	// Filling r never fails.
+	_ = ac.searchWith(&ps.ss, text, &ps.h)
+	ps.h.result = nil
+	ac.packets.Put(ps)
+}
@@ -0,0 +1,70 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanPackets(t *testing.T) {
+	ac := buildWords([]string{"GET", "/etc/passwd", "cmd.exe", "HTTP"})
+	ac.AddRule(Rule{Name: "http", Condition: "$1 and $4", Verdict: 1})
+	ac.AddRule(Rule{Name: "traversal", Condition: "$2 or $3", Verdict: 3})
+	ac.AddRule(Rule{Name: "log-only", Condition: "$1"})
+
+	payloads := [][]byte{
+		[]byte("GET / HTTP/1.1"),
+		[]byte("GET /../../etc/passwd HTTP/1.1"),
+		[]byte("\x00\x01binary"),
+		[]byte("GET cmd.exe"),
+	}
+	verdicts := make([]uint8, len(payloads))
+	ac.ScanPackets(payloads, verdicts)
+	if want := []uint8{1, 3, 0, 3}; !reflect.DeepEqual(verdicts, want) {
+		t.Errorf("Expected %v, got %v", want, verdicts)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ac.ScanPackets(payloads, verdicts)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestACKS_ScanPackets_AddRule(t *testing.T) {
+	ac := buildWords([]string{"GET", "HTTP"})
+	ac.AddRule(Rule{Name: "http", Condition: "$1 and $2", Verdict: 1})
+	payloads := [][]byte{[]byte("GET GET HTTP")}
+	verdicts := make([]uint8, 1)
+	ac.ScanPackets(payloads, verdicts)
+
+	// The recycled state has no window for the new rule.
+	ac.AddRule(Rule{Name: "burst", Condition: "#1 >= 2 within 10", Verdict: 2})
+	ac.ScanPackets(payloads, verdicts)
+	if verdicts[0] != 2 {
+		t.Errorf("Expected verdict 2, got %d", verdicts[0])
+	}
+}
+
+func TestACKS_ScanInto(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("select"), ID: 1, Flags: Caseless, Severity: 2})
+	ac.AddPattern(Pattern{Content: []byte("drop table"), ID: 2, Flags: Caseless, Severity: 9})
+	ac.AddPattern(Pattern{Content: []byte("union"), ID: 3, Flags: Caseless, Severity: 5})
+	ac.Build()
+
+	var r ScanResult
+	ac.ScanInto([]byte("SELECT 1; DROP TABLE t; select 2"), &r)
+	if want := (ScanResult{Count: 3, FirstID: 1, MaxSeverity: 9}); r != want {
+		t.Errorf("Expected %+v, got %+v", want, r)
+	}
+	ac.ScanInto([]byte("nothing here"), &r)
+	if r != (ScanResult{}) {
+		t.Errorf("Expected an empty result, got %+v", r)
+	}
+
+	text := []byte("a union of selects")
+	if allocs := testing.AllocsPerRun(100, func() { ac.ScanInto(text, &r) }); allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
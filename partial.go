@@ -0,0 +1,69 @@
+package ahocorasick
+
+// Partial describes the pattern prefixes still live at the end of a buffer,
+// which more input could complete into matches.
+type Partial struct {
+	// Matched is the length of the longest live prefix: the number of
+	// bytes at the end of the buffer a caller doing its own framing must
+	// keep for the next read. It is 0 when no match can span the end.
+	Matched int
+	// IDs lists the patterns that the longest live prefix begins, in the
+	// order they were added.
+	IDs []uint
+}
+
+// ScanPartial scans text like Scan and also reports which patterns are live
+// prefixes at its end, so callers can retain just the bytes that matter
+// rather than MaxPatternLen-1. It always runs the automaton, and finding the
+// IDs costs time proportional to the number of patterns.
+func (ac *ACKS) ScanPartial(text []byte, m MatchedHandler) (Partial, error) {
+	h := handler{scan: m}
+	norm := text
+	if len(ac.normalizers) > 0 {
+		norm, h.offsets = ac.normalize(text)
+	}
+	ss := scanState{record: ac.newMatchRecord(), stream: true}
+	if err := ac.searchText(&ss, norm, &h); err != nil {
+		return Partial{}, err
+	}
+	// As in Scan, the end of text ends a line and a word.
+	if err := ac.flushPending(&ss, &h); err != nil {
+		return Partial{}, err
+	}
+
+	depth := ac.liveDepth(ss.state)
+	for _, p := range ss.pending {
+		depth = max(depth, p.pat.plen+p.done)
+	}
+	if depth == 0 {
+		return Partial{}, nil
+	}
+	part := Partial{Matched: depth}
+	if h.offsets != nil {
+		part.Matched = len(text) - h.offsets[len(norm)-depth]
+	}
+	tail := norm[len(norm)-depth:]
+	for _, p := range ac.patterns {
+		if p.strlen > depth && equalPattern(p, 0, tail) {
+			part.IDs = append(part.IDs, p.ID)
+		}
+	}
+	return part, nil
+}
+
+// liveDepth returns the length in bytes of the longest suffix along the
+// failure chain of state that the trie can still extend.
+func (ac *ACKS) liveDepth(state int) int {
+	for ; state > 0; state = int(ac.failure[state]) {
+		d := ac.depth[state]
+		for c := 0; c < ac.alphabetSize; c++ {
+			if ac.depth[ac.next(state, uint8(c))] == d+1 {
+				if ac.nibble {
+					return int(d) / 2
+				}
+				return int(d)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanPartial(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithNibbleAlphabet()}, {WithDenseStates(2)}, {WithLongPatternPrefix(3)}} {
+		ac := NewACKS(opts...)
+		for i, w := range []string{"he", "she", "hers", "his", "ushering"} {
+			ac.AddPattern(mkPat(w, uint(i+1), 0))
+		}
+		ac.Build()
+		for _, tt := range []struct {
+			text    string
+			matched int
+			ids     []uint
+		}{
+			{"", 0, nil},
+			{"the", 2, []uint{3}},
+			{"rush", 3, []uint{5}},
+			{"push", 3, []uint{5}},
+			{"wish", 2, []uint{2}},
+			{"ushe", 4, []uint{5}},
+			{"usheri", 6, []uint{5}},
+			{"hers", 1, []uint{2}},
+			{"herb", 0, nil},
+			{"xyz", 0, nil},
+		} {
+			n := 0
+			part, err := ac.ScanPartial([]byte(tt.text), func(id uint, from, to uint64) error {
+				n++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if part.Matched != tt.matched || !reflect.DeepEqual(part.IDs, tt.ids) {
+				t.Errorf("%v %q: Expected %d %v, got %+v", opts, tt.text, tt.matched, tt.ids, part)
+			}
+			if want := len(ac.FindN([]byte(tt.text), 0)); n != want {
+				t.Errorf("%v %q: Expected %d matches, got %d", opts, tt.text, want, n)
+			}
+		}
+	}
+}
+
+func TestACKS_ScanPartialLineEnd(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithLongPatternPrefix(3)}} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("ERROR", 1, LineAnchoredEnd))
+		ac.Build()
+		var got []uint64
+		part, err := ac.ScanPartial([]byte("x ERROR"), func(id uint, from, to uint64) error {
+			got = append(got, to)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, []uint64{7}) || part.Matched != 0 || part.IDs != nil {
+			t.Errorf("%v: Expected one match ending at 7 and no partial, got %v %+v", opts, got, part)
+		}
+	}
+}
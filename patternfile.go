@@ -0,0 +1,119 @@
+package ahocorasick
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PatternFormat selects the syntax read by AddPatternsFromReader.
+type PatternFormat int
+
+const (
+	// FormatLines reads one literal pattern per line. Empty lines are skipped
+	// and each pattern's ID is its 1-based line number.
+	FormatLines PatternFormat = iota
+	// FormatCSV reads "id,flags,pattern" records. Flags are parsed with
+	// ParseFlags, so both "caseless|singlematch" and "3" are accepted.
+	FormatCSV
+	// FormatHex reads one hex-encoded pattern per line. Empty lines are
+	// skipped and each pattern's ID is its 1-based line number.
+	FormatHex
+	// FormatModifiers reads one literal pattern per line, ending in a
+	// modifier suffix parsed with ParseModifiers: "foo/is" is a Caseless,
+	// SingleMatch pattern. The suffix starts at the last slash, so a pattern
+	// containing a slash needs one, even if empty, as in "and/or/". Empty
+	// lines are skipped and each pattern's ID is its 1-based line number.
+	FormatModifiers
+)
+
+// maxPatternLine bounds the length of a single line in a pattern file.
+const maxPatternLine = 64 * 1024 * 1024
+
+// AddPatternsFromReader adds every pattern read from r and returns how many
+// were added. Input is parsed one record at a time, so large dictionaries
+// are not held in memory twice.
+func (ac *ACKS) AddPatternsFromReader(r io.Reader, format PatternFormat) (int, error) {
+	switch format {
+	case FormatLines, FormatHex, FormatModifiers:
+		return ac.addPatternLines(r, format)
+	case FormatCSV:
+		return ac.addPatternCSV(r)
+	}
+	return 0, fmt.Errorf("ahocorasick: unknown pattern format %d", format)
+}
+
+func (ac *ACKS) addPatternLines(r io.Reader, format PatternFormat) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxPatternLine)
+	n := 0
+	for line := 1; sc.Scan(); line++ {
+		b := sc.Bytes()
+		if len(b) > 0 && b[len(b)-1] == '\r' {
+			b = b[:len(b)-1]
+		}
+		if len(b) == 0 {
+			continue
+		}
+		var content []byte
+		var flags Flag
+		switch format {
+		case FormatHex:
+			content = make([]byte, hex.DecodedLen(len(b)))
+			if _, err := hex.Decode(content, b); err != nil {
+				return n, fmt.Errorf("ahocorasick: line %d: %w", line, err)
+			}
+		case FormatModifiers:
+			if i := bytes.LastIndexByte(b, '/'); i >= 0 {
+				f, err := ParseModifiers(string(b[i:]))
+				if err != nil {
+					return n, fmt.Errorf("ahocorasick: line %d: %w", line, err)
+				}
+				b, flags = b[:i], f
+			}
+			fallthrough
+		default:
+			content = append([]byte(nil), b...)
+		}
+		if err := ac.AddPattern(Pattern{Content: content, ID: uint(line), Flags: flags}); err != nil {
+			return n, fmt.Errorf("ahocorasick: line %d: %w", line, err)
+		}
+		n++
+	}
+	return n, sc.Err()
+}
+
+func (ac *ACKS) addPatternCSV(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.ReuseRecord = true
+	n := 0
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("ahocorasick: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		id, err := strconv.ParseUint(rec[0], 10, 0)
+		if err != nil {
+			return n, fmt.Errorf("ahocorasick: line %d: invalid id %q", line, rec[0])
+		}
+		flags, err := ParseFlags(rec[1])
+		if err != nil {
+			return n, fmt.Errorf("ahocorasick: line %d: %w", line, err)
+		}
+		p := Pattern{Content: []byte(rec[2]), ID: uint(id), Flags: flags}
+		if err := ac.AddPattern(p); err != nil {
+			return n, fmt.Errorf("ahocorasick: line %d: %w", line, err)
+		}
+		n++
+	}
+}
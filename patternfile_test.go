@@ -0,0 +1,54 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_AddPatternsFromReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		format PatternFormat
+		input  string
+		count  int
+		want   []uint
+	}{
+		{"lines", FormatLines, "foo\r\n\nbar\n", 2, []uint{1, 3}},
+		{"csv", FormatCSV, "7,caseless,FOO\n9,0,\"b,r\"\n", 2, []uint{7}},
+		{"hex", FormatHex, "666f6f\n\n626172\n", 2, []uint{1, 3}},
+		{"modifiers", FormatModifiers, "FOO/i\nBAR\no b/\n", 3, []uint{1, 3}},
+	}
+	for _, tt := range tests {
+		ac := NewACKS()
+		n, err := ac.AddPatternsFromReader(strings.NewReader(tt.input), tt.format)
+		if err != nil {
+			t.Fatalf("%s: AddPatternsFromReader failed: %v", tt.name, err)
+		}
+		if n != tt.count {
+			t.Errorf("%s: Expected %d patterns, got %d", tt.name, tt.count, n)
+		}
+		ac.Build()
+		matches, _ := ac.Search([]byte("foo bar"))
+		if !reflect.DeepEqual(matches, tt.want) {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, matches)
+		}
+	}
+}
+
+func TestACKS_AddPatternsFromReader_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		format PatternFormat
+		input  string
+	}{
+		{FormatHex, "666f6f\nzz\n"},
+		{FormatCSV, "1,0,foo\nx,0,bar\n"},
+		{FormatCSV, "1,0\n"},
+		{FormatModifiers, "and/or\n"},
+	} {
+		ac := NewACKS()
+		if _, err := ac.AddPatternsFromReader(strings.NewReader(tt.input), tt.format); err == nil {
+			t.Errorf("%q: Expected error", tt.input)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package ahocorasick
+
+import (
+	"fmt"
+)
+
+// WarningCode identifies the kind of a build Warning.
+type WarningCode int
+
+const (
+	// WarnShortPattern reports patterns shorter than the configured minimum.
+	WarnShortPattern WarningCode = iota + 1
+	// WarnDenseAlphabet reports that the patterns use so many distinct
+	// bytes that alphabet compression barely shrinks the transition table.
+	WarnDenseAlphabet
+)
+
+// denseAlphabet is the alphabet size from which WarnDenseAlphabet is
+// reported: every dense row is then at least three quarters of its
+// uncompressed size.
+const denseAlphabet = 192
+
+// Warning describes a problem found by Build that does not prevent the
+// matcher from working, such as a pattern likely to hurt throughput.
+type Warning struct {
+	Code    WarningCode
+	Message string
+	IDs     []uint // IDs of the offending patterns, if any
+}
+
+// ShortPatternPolicy selects what Build does with patterns shorter than the
+// minimum set by WithMinPatternLen.
+type ShortPatternPolicy int
+
+const (
+	// ShortPatternWarn builds the matcher and records a WarnShortPattern
+	// warning.
+	ShortPatternWarn ShortPatternPolicy = iota
+	// ShortPatternReject makes Build fail with a *ShortPatternError.
+	ShortPatternReject
+)
+
+// ShortPatternError is returned by Build when patterns are shorter than the
+// minimum length and the policy is ShortPatternReject.
+type ShortPatternError struct {
+	MinLen int
+	IDs    []uint
+}
+
+func (e *ShortPatternError) Error() string {
+	return fmt.Sprintf("ahocorasick: %d patterns shorter than %d bytes: ids %v", len(e.IDs), e.MinLen, e.IDs)
+}
+
+// WithMinPatternLen sets a minimum pattern length, checked by Build. One- and
+// two-byte patterns fire on a large share of input positions and dominate
+// output processing; the policy decides whether they are rejected or only
+// reported through Warnings.
+func WithMinPatternLen(n int, policy ShortPatternPolicy) Option {
+	return func(ac *ACKS) {
+		ac.minPatternLen = n
+		ac.shortPolicy = policy
+	}
+}
+
+// Warnings returns the warnings recorded by the last Build.
+func (ac *ACKS) Warnings() []Warning {
+	return ac.warnings
+}
+
+// checkPolicy applies the build policies to the added patterns.
+func (ac *ACKS) checkPolicy() error {
+	ac.warnings = nil
+	if ac.minPatternLen > 0 {
+		var ids []uint
+		for _, p := range ac.patterns {
+			if p.strlen < ac.minPatternLen {
+				ids = append(ids, p.ID)
+			}
+		}
+		if len(ids) > 0 {
+			if ac.shortPolicy == ShortPatternReject {
+				return &ShortPatternError{MinLen: ac.minPatternLen, IDs: ids}
+			}
+			ac.warnings = append(ac.warnings, Warning{
+				Code:    WarnShortPattern,
+				Message: fmt.Sprintf("%d patterns shorter than %d bytes", len(ids), ac.minPatternLen),
+				IDs:     ids,
+			})
+		}
+	}
+	return nil
+}
+
+// checkAlphabet warns when alphabet compression was ineffective.
+func (ac *ACKS) checkAlphabet() {
+	if ac.nibble || ac.alphabetSize < denseAlphabet {
+		return
+	}
+	ac.warnings = append(ac.warnings, Warning{
+		Code: WarnDenseAlphabet,
+		Message: fmt.Sprintf("patterns use %d of 256 byte codes, so the transition table is barely compressed; "+
+			"consider WithDenseStates, WithNibbleAlphabet or splitting the pattern set", ac.alphabetSize),
+	})
+}
@@ -0,0 +1,54 @@
+package ahocorasick
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_Build_MinPatternLen(t *testing.T) {
+	add := func(ac *ACKS) {
+		ac.AddPattern(mkPat("a", 1, 0))
+		ac.AddPattern(mkPat("abc", 2, 0))
+		ac.AddPattern(mkPat("ab", 3, 0))
+	}
+
+	reject := NewACKS(WithMinPatternLen(3, ShortPatternReject))
+	add(reject)
+	err := reject.Build()
+	var spe *ShortPatternError
+	if !errors.As(err, &spe) || !reflect.DeepEqual(spe.IDs, []uint{1, 3}) {
+		t.Fatalf("Expected ShortPatternError for ids [1 3], got %v", err)
+	}
+
+	warn := NewACKS(WithMinPatternLen(3, ShortPatternWarn))
+	add(warn)
+	if err := warn.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	w := warn.Warnings()
+	if len(w) != 1 || w[0].Code != WarnShortPattern || !reflect.DeepEqual(w[0].IDs, []uint{1, 3}) {
+		t.Errorf("Unexpected warnings %+v", w)
+	}
+	if matches, _ := warn.Search([]byte("abc")); len(matches) != 3 {
+		t.Errorf("Expected the matcher to still work, got %v", matches)
+	}
+}
+
+func TestACKS_Build_DenseAlphabetWarning(t *testing.T) {
+	wide := make([]byte, 200)
+	for i := range wide {
+		wide[i] = byte(i)
+	}
+	ac := buildWords([]string{string(wide)})
+	w := ac.Warnings()
+	if len(w) != 1 || w[0].Code != WarnDenseAlphabet {
+		t.Errorf("Expected a dense alphabet warning, got %+v", w)
+	}
+	if r := ac.Stats().AlphabetRatio; r < 0.75 {
+		t.Errorf("Expected a high alphabet ratio, got %v", r)
+	}
+	if w := buildWords([]string{"he", "she"}).Warnings(); len(w) != 0 {
+		t.Errorf("Unexpected warnings %+v", w)
+	}
+}
@@ -0,0 +1,48 @@
+package ahocorasick
+
+import (
+	"os"
+	"unsafe"
+)
+
+// prefaultSink keeps the reads of Prefault from being optimized away.
+var prefaultSink byte
+
+// Prefault touches every page of the matcher's tables, so the first scan
+// after loading a large database, in particular one mapped by OpenShared,
+// does not stall on page faults in a latency-sensitive path. Where the
+// platform supports it, a mapped database is first advised to the kernel as
+// needed soon, so its pages are read in bulk.
+func (ac *ACKS) Prefault() {
+	var sum byte
+	if ac.image != nil {
+		adviseWillNeed(ac.image)
+		sum += touchPages(ac.image)
+	}
+	sum += touchPages(ac.stateTable)
+	sum += touchPages(ac.failure)
+	sum += touchPages(ac.depth)
+	sum += touchPages(ac.minRemain)
+	sum += touchPages(ac.sparseIndex)
+	sum += touchPages(ac.sparseChars)
+	sum += touchPages(ac.sparseNext)
+	sum += touchPages(ac.stateHasOutput)
+	for _, p := range ac.patterns {
+		sum += touchPages(p.Content)
+	}
+	prefaultSink = sum
+}
+
+// touchPages reads one byte from every page s spans.
+func touchPages[T any](s []T) byte {
+	if len(s) == 0 {
+		return 0
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*int(unsafe.Sizeof(s[0])))
+	var sum byte
+	page := os.Getpagesize()
+	for i := 0; i < len(b); i += page {
+		sum += b[i]
+	}
+	return sum + b[len(b)-1]
+}
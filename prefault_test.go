@@ -0,0 +1,30 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_Prefault(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers"}, WithDenseStates(3))
+	var buf bytes.Buffer
+	ac.WriteTo(&buf)
+	mapped, err := loadImage(buf.Bytes(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ac.Search([]byte("ushers"))
+	for _, m := range []*ACKS{ac, mapped, NewACKS()} {
+		m.Prefault()
+		if m.stateCount == 0 {
+			continue
+		}
+		if got, _ := m.Search([]byte("ushers")); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+	if mapped.image == nil {
+		t.Errorf("Expected the in-place matcher to keep its image")
+	}
+}
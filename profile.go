@@ -0,0 +1,95 @@
+package ahocorasick
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Profile accumulates where a matcher spends its scanning effort: how often
+// each byte value and state is visited and how often each pattern fires.
+// Patterns that fire constantly, such as one- or two-byte patterns, stand
+// out in its report as candidates for pruning.
+type Profile struct {
+	Bytes       uint64          // bytes scanned
+	Matches     uint64          // matches reported
+	ByteCounts  [256]uint64     // occurrences of each byte value
+	StateVisits []uint64        // visits per state
+	PatternHits map[uint]uint64 // matches per pattern ID
+
+	ac *ACKS
+}
+
+// NewProfile returns an empty Profile for ac. The matcher must be built.
+func (ac *ACKS) NewProfile() *Profile {
+	return &Profile{
+		StateVisits: make([]uint64, ac.stateCount),
+		PatternHits: make(map[uint]uint64),
+		ac:          ac,
+	}
+}
+
+// ProfileScan scans text like Scan, additionally accumulating statistics
+// into p. It is much slower than Scan and meant for offline analysis.
+func (ac *ACKS) ProfileScan(text []byte, p *Profile, m MatchedHandler) error {
+	ss := scanState{record: ac.newMatchRecord(), observe: true, profile: p}
+	h := handler{fn: func(from, to uint64, ps *Pattern) error {
+		p.Matches++
+		p.PatternHits[ps.ID]++
+		if m == nil {
+			return nil
+		}
+		return m(ps.ID, 0, to)
+	}}
+	return ac.searchWith(&ss, text, &h)
+}
+
+// WriteReport writes a human-readable summary of the top entries of p.
+func (p *Profile) WriteReport(w io.Writer, top int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "scanned %d bytes, %d matches\n", p.Bytes, p.Matches)
+
+	ids := make([]uint, 0, len(p.PatternHits))
+	for id := range p.PatternHits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		hi, hj := p.PatternHits[ids[i]], p.PatternHits[ids[j]]
+		return hi > hj || hi == hj && ids[i] < ids[j]
+	})
+	fmt.Fprintf(bw, "hot patterns:\n")
+	for _, id := range ids[:min(top, len(ids))] {
+		hits := p.PatternHits[id]
+		fmt.Fprintf(bw, "  id=%d hits=%d per-KB=%.2f\n", id, hits, float64(hits)*1024/float64(max(p.Bytes, 1)))
+	}
+
+	states := make([]int, len(p.StateVisits))
+	for i := range states {
+		states[i] = i
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return p.StateVisits[states[i]] > p.StateVisits[states[j]]
+	})
+	fmt.Fprintf(bw, "hot states:\n")
+	for _, s := range states[:min(top, len(states))] {
+		fmt.Fprintf(bw, "  state=%d depth=%d visits=%d outputs=%d\n",
+			s, p.ac.depth[s], p.StateVisits[s], len(p.ac.outputTable[s]))
+	}
+
+	byteVals := make([]int, 256)
+	for i := range byteVals {
+		byteVals[i] = i
+	}
+	sort.SliceStable(byteVals, func(i, j int) bool {
+		return p.ByteCounts[byteVals[i]] > p.ByteCounts[byteVals[j]]
+	})
+	fmt.Fprintf(bw, "hot bytes:\n")
+	for _, b := range byteVals[:min(top, 256)] {
+		if p.ByteCounts[b] == 0 {
+			break
+		}
+		fmt.Fprintf(bw, "  byte=%q count=%d\n", byte(b), p.ByteCounts[b])
+	}
+	return bw.Flush()
+}
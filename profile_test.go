@@ -0,0 +1,40 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestACKS_ProfileScan(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("e", 1, 0))
+	ac.AddPattern(mkPat("needle", 2, 0))
+	ac.Build()
+
+	p := ac.NewProfile()
+	text := []byte("a needle in the haystack, eh")
+	count := 0
+	err := ac.ProfileScan(text, p, func(id uint, from, to uint64) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProfileScan failed: %v", err)
+	}
+	if p.Bytes != uint64(len(text)) || p.Matches != uint64(count) {
+		t.Errorf("Unexpected totals: %d bytes, %d matches", p.Bytes, p.Matches)
+	}
+	if p.PatternHits[1] != 5 || p.PatternHits[2] != 1 {
+		t.Errorf("Unexpected pattern hits %v", p.PatternHits)
+	}
+	if p.StateVisits[0] == 0 || p.ByteCounts['e'] != 5 {
+		t.Errorf("Unexpected visit counts")
+	}
+
+	var report bytes.Buffer
+	p.WriteReport(&report, 1)
+	if !strings.Contains(report.String(), "hot patterns:\n  id=1 hits=5") {
+		t.Errorf("Unexpected report:\n%s", report.String())
+	}
+}
@@ -0,0 +1,84 @@
+package ahocorasick
+
+// WithQuickReject builds a small Bloom filter over the first four bytes of
+// every pattern. Whole-buffer scans first check each 4-byte window of the
+// text against it and skip the automaton when none can start a pattern,
+// which is much cheaper for pipelines where most buffers match nothing.
+// The filter is only built when every pattern is at least four bytes long;
+// streams do not use it.
+func WithQuickReject() Option {
+	return func(ac *ACKS) {
+		ac.quickReject = true
+	}
+}
+
+const (
+	gramFilterBits = 1 << 16
+	gramFilterMask = gramFilterBits - 1
+)
+
+// gramFilter is a Bloom filter with two hash functions over 4-grams, with
+// ASCII letters folded so it is safe for Caseless patterns.
+type gramFilter [gramFilterBits / 64]uint64
+
+func gramKey(b []byte) uint32 {
+	return uint32(toLower(b[0])) | uint32(toLower(b[1]))<<8 |
+		uint32(toLower(b[2]))<<16 | uint32(toLower(b[3]))<<24
+}
+
+func gramHashes(key uint32) (uint32, uint32) {
+	h := key * 0x9e3779b1
+	return h >> 16, (key * 0x85ebca6b) >> 16 & gramFilterMask
+}
+
+func (f *gramFilter) add(key uint32) {
+	h1, h2 := gramHashes(key)
+	f[h1/64] |= 1 << (h1 % 64)
+	f[h2/64] |= 1 << (h2 % 64)
+}
+
+func (f *gramFilter) has(key uint32) bool {
+	h1, h2 := gramHashes(key)
+	return f[h1/64]&(1<<(h1%64)) != 0 && f[h2/64]&(1<<(h2%64)) != 0
+}
+
+// buildGramFilter sets up the quick-reject filter, if it was requested and
+// every pattern is long enough to contribute a 4-gram without classes.
+func (ac *ACKS) buildGramFilter() {
+	ac.grams = nil
+	if !ac.quickReject || len(ac.patterns) == 0 {
+		return
+	}
+	f := new(gramFilter)
+	for _, p := range ac.patterns {
+		if len(p.Content) < 4 {
+			return
+		}
+		for i := 0; i < 4; i++ {
+			if p.classAt(i) != nil {
+				return
+			}
+		}
+		f.add(gramKey(p.Content))
+	}
+	ac.grams = f
+}
+
+// MayMatch reports whether text may contain a pattern according to the
+// quick-reject filter. False means no pattern occurs in text; true means a
+// full scan is needed. Without the filter it always returns true, unless
+// there are no patterns. Normalizers are not applied.
+func (ac *ACKS) MayMatch(text []byte) bool {
+	if len(ac.patterns) == 0 {
+		return false
+	}
+	if ac.grams == nil {
+		return true
+	}
+	for i := 0; i+4 <= len(text); i++ {
+		if ac.grams.has(gramKey(text[i:])) {
+			return true
+		}
+	}
+	return false
+}
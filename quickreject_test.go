@@ -0,0 +1,44 @@
+package ahocorasick
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_QuickReject(t *testing.T) {
+	words := []string{"needle", "HAYSTACK", "pins"}
+	ac := buildWords(words, WithQuickReject())
+	ac.AddPattern(mkPat("Caseless", 4, Caseless))
+	ac.Build()
+	if ac.grams == nil {
+		t.Fatalf("Expected the filter to be built")
+	}
+	if ac.MayMatch([]byte("nothing to see here")) {
+		t.Errorf("Expected the filter to reject clean text")
+	}
+	plain := buildWords(words)
+	plain.AddPattern(mkPat("Caseless", 4, Caseless))
+	plain.Build()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		const alphabet = "acdeilnpsCASELESShaystack"
+		text := make([]byte, 40)
+		for j := range text {
+			text[j] = alphabet[rng.Intn(len(alphabet))]
+		}
+		if i%10 == 0 {
+			text = append(text, "a CASELESS needle"...)
+		}
+		want, _ := plain.Search(text)
+		got, _ := ac.Search(text)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: Expected %v, got %v", text, want, got)
+		}
+	}
+
+	short := buildWords([]string{"abc", "longer"}, WithQuickReject())
+	if short.grams != nil || !short.MayMatch(nil) {
+		t.Errorf("Expected no filter with a pattern shorter than four bytes")
+	}
+}
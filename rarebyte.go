@@ -0,0 +1,127 @@
+package ahocorasick
+
+// Sets too large for the literal engines can still be prefiltered when every
+// pattern contains a byte that is rare in the text: the scan jumps between
+// occurrences of those bytes with IndexByte and runs the automaton only over
+// the windows around them, where a match could be. The windows are merged
+// before scanning, so no match is found twice or cut short.
+
+// maxRareBytes bounds the distinct anchor bytes searched for, as each one
+// needs its own IndexByte pass.
+const maxRareBytes = 3
+
+// WithByteFrequencyModel sets the relative frequency of each byte value in
+// the texts to be scanned. It decides which byte of a pattern is searched
+// for by the literal engines and whether the rare-byte prefilter pays off.
+// The default model suits mixed text; binary corpora should supply their
+// own, for example byte counts from a Profile.
+func WithByteFrequencyModel(freq [256]float64) Option {
+	return func(ac *ACKS) {
+		ac.byteFreq = &freq
+	}
+}
+
+func (ac *ACKS) frequencies() *[256]float64 {
+	if ac.byteFreq != nil {
+		return ac.byteFreq
+	}
+	return &defaultByteFrequency
+}
+
+// buildRareBytes selects the rare-byte prefilter when the anchor bytes of
+// the patterns are few and much rarer than the bytes matches start with.
+func (ac *ACKS) buildRareBytes() {
+	ac.rareBytes = ac.rareBytes[:0]
+	if len(ac.literals) > 0 || len(ac.patterns) == 0 || ac.hasAnchors {
+		return
+	}
+	freq := ac.frequencies()
+	var anchors [256]bool
+	var rare []byte
+	before, after := 0, 0
+	for _, p := range ac.patterns {
+		if len(p.Content) == 0 {
+			return
+		}
+		k := rarestByte(p, freq)
+		if k < 0 {
+			return
+		}
+		before, after = max(before, k), max(after, len(p.Content)-k)
+		for _, b := range ac.caseForms(p, k) {
+			if !anchors[b] {
+				anchors[b] = true
+				rare = append(rare, b)
+			}
+		}
+		if len(rare) > maxRareBytes {
+			return
+		}
+	}
+	var total, anchor, start float64
+	starts := ac.StartBytes()
+	for b, f := range freq {
+		total += f
+		if anchors[b] {
+			anchor += f
+		}
+		if starts[b] {
+			start += f
+		}
+	}
+	if total <= 0 || anchor*4 >= start {
+		return
+	}
+	ac.rareBytes = append(ac.rareBytes, rare...)
+	ac.rareBefore, ac.rareAfter = before, after
+}
+
+// searchRare scans the windows of text around anchor bytes.
+func (ac *ACKS) searchRare(ss *scanState, text []byte, h *handler) error {
+	var next [maxRareBytes]int
+	for i, b := range ac.rareBytes {
+		next[i] = indexByte(text, b)
+	}
+	base := ss.base
+	defer func() { ss.base, ss.state = base, 0 }()
+	scan := func(s, e int) error {
+		ss.base, ss.state = base+uint64(s), 0
+		return ac.searchText(ss, text[s:e], h)
+	}
+	segStart, segEnd := 0, -1
+	for {
+		pos := -1
+		for i := range ac.rareBytes {
+			if next[i] >= 0 && (pos < 0 || next[i] < pos) {
+				pos = next[i]
+			}
+		}
+		if pos < 0 {
+			break
+		}
+		for i, b := range ac.rareBytes {
+			if next[i] == pos {
+				if j := indexByte(text[pos+1:], b); j >= 0 {
+					next[i] = pos + 1 + j
+				} else {
+					next[i] = -1
+				}
+			}
+		}
+		s, e := max(pos-ac.rareBefore, 0), min(pos+ac.rareAfter, len(text))
+		if segEnd >= 0 && s <= segEnd {
+			segEnd = max(segEnd, e)
+			continue
+		}
+		if segEnd >= 0 {
+			if err := scan(segStart, segEnd); err != nil {
+				return err
+			}
+		}
+		segStart, segEnd = s, e
+	}
+	if segEnd >= 0 {
+		return scan(segStart, segEnd)
+	}
+	return nil
+}
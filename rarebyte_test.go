@@ -0,0 +1,41 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_RareBytes(t *testing.T) {
+	words := []string{"ab#cd", "#x", "foo#", "bar@baz", "q@", "hello~world", "a~"}
+	ac := buildWords(words)
+	if got := ac.Engine().Acceleration; got != "rarebyte" {
+		t.Fatalf("Expected the rare-byte prefilter, got %q", got)
+	}
+	r := uint32(7)
+	for n := 0; n < 300; n++ {
+		text := make([]byte, n)
+		for i := range text {
+			r = r*1103515245 + 12345
+			text[i] = "abcdfoqrxzhelwo#@~ "[r>>16%19]
+		}
+		// Plant a few whole words.
+		if n > 20 {
+			copy(text[n/3:], words[n%len(words)])
+		}
+		got := ac.FindN(text, 0)
+		if want := dfaMatches(ac, string(text)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: Expected %v, got %v", text, want, got)
+		}
+	}
+
+	// A model where the punctuation is common leaves the plain start byte
+	// table in use.
+	var freq [256]float64
+	for i := range freq {
+		freq[i] = 1
+	}
+	freq['#'], freq['@'], freq['~'] = 100, 100, 100
+	if got := buildWords(words, WithByteFrequencyModel(freq)).Engine().Acceleration; got != "bytemap" {
+		t.Errorf("Expected bytemap with the custom model, got %q", got)
+	}
+}
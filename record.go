@@ -0,0 +1,100 @@
+package ahocorasick
+
+import (
+	"bytes"
+)
+
+// WithRecordDelimiter sets the delimiter that ends each record for
+// ScanRecords, ScanRecordGroups and MatchRecordRules. It defaults to "\n".
+func WithRecordDelimiter(delim string) Option {
+	return func(ac *ACKS) {
+		ac.recordDelim = []byte(delim)
+	}
+}
+
+// forEachRecord calls fn with the index, start offset and bytes of every
+// record of text, delimiters excluded. An empty final record is skipped.
+func (ac *ACKS) forEachRecord(text []byte, fn func(idx uint64, start int, rec []byte) error) error {
+	delim := ac.recordDelim
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+	var idx uint64
+	for start := 0; start < len(text); idx++ {
+		end := len(text)
+		next := end
+		if j := bytes.Index(text[start:], delim); j >= 0 {
+			end = start + j
+			next = end + len(delim)
+		}
+		if err := fn(idx, start, text[start:end]); err != nil {
+			return err
+		}
+		start = next
+	}
+	return nil
+}
+
+// ScanRecords scans text one record at a time and calls m with the record
+// index, from 0, and each match. Every record is scanned as a separate
+// input: matches never span a delimiter, and SingleMatch, dedup windows and
+// sampling start afresh in each record. Offsets are relative to the start
+// of text.
+func (ac *ACKS) ScanRecords(text []byte, m func(record uint64, id uint, from, to uint64) error) error {
+	ss := scanState{record: ac.newMatchRecord()}
+	return ac.forEachRecord(text, func(idx uint64, start int, rec []byte) error {
+		ss.reset()
+		base := uint64(start)
+		return ac.searchWith(&ss, rec, &handler{fn: func(from, to uint64, ps *Pattern) error {
+			return m(idx, ps.ID, base+from, base+to)
+		}})
+	})
+}
+
+// MatchRecordRules evaluates the rules over each record of text separately,
+// as MatchRules would over the record alone, and calls m with the index of
+// every record where some rule holds and the names of those rules. The
+// names slice is reused between calls.
+func (ac *ACKS) MatchRecordRules(text []byte, m func(record uint64, rules []string) error) error {
+	rs := ac.newRuleState()
+	ss := scanState{record: ac.newMatchRecord(), rules: rs}
+	var names []string
+	return ac.forEachRecord(text, func(idx uint64, _ int, rec []byte) error {
+		ss.reset()
+		if err := ac.searchWith(&ss, rec, &handler{}); err != nil {
+			return err
+		}
+		names = names[:0]
+		for _, r := range ac.rules {
+			if r.expr.eval(rs) {
+				names = append(names, r.Name)
+			}
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		return m(idx, names)
+	})
+}
+
+// ScanRecordGroups scans text like ScanRecords but calls m once per record
+// that has matches, with the record index, the record bytes without the
+// delimiter and its matches in the order they end. Offsets are relative to
+// the start of text. The matches slice is reused between calls.
+func (ac *ACKS) ScanRecordGroups(text []byte, m func(recordIdx uint64, record []byte, matches []Match) error) error {
+	ss := scanState{record: ac.newMatchRecord()}
+	var matches []Match
+	return ac.forEachRecord(text, func(idx uint64, start int, rec []byte) error {
+		ss.reset()
+		matches = matches[:0]
+		base := uint64(start)
+		err := ac.searchWith(&ss, rec, &handler{fn: func(from, to uint64, ps *Pattern) error {
+			matches = append(matches, newMatch(base+from, base+to, ps))
+			return nil
+		}})
+		if err != nil || len(matches) == 0 {
+			return err
+		}
+		return m(idx, rec, matches)
+	})
+}
@@ -0,0 +1,63 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanRecords(t *testing.T) {
+	ac := NewACKS(WithRecordDelimiter("\r\n"))
+	ac.AddPattern(mkPat("err", 1, SingleMatch))
+	ac.AddPattern(mkPat("r\r\ne", 2, 0))
+	ac.AddPattern(mkPat("disk", 3, 0))
+	ac.Build()
+
+	var got []string
+	err := ac.ScanRecords([]byte("err err\r\nerr disk\r\n\r\nok\r\n"), func(rec uint64, id uint, from, to uint64) error {
+		got = append(got, fmt.Sprintf("%d:%d@%d", rec, id, to))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SingleMatch restarts per record and nothing spans the delimiter.
+	if want := []string{"0:1@3", "1:1@12", "1:3@17"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestACKS_MatchRecordRules(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("GET", 1, 0))
+	ac.AddPattern(mkPat("admin", 2, 0))
+	ac.AddPattern(mkPat("404", 3, 0))
+	ac.Build()
+	ac.AddRule(Rule{Name: "admin-probe", Condition: "$1 and $2 and $3"})
+	ac.AddRule(Rule{Name: "many-404", Condition: "#3 >= 2"})
+
+	log := "GET /admin 200\nGET /x 404\nGET /admin 404\n404 404\nPOST /admin 404"
+	var got []string
+	ac.MatchRecordRules([]byte(log), func(rec uint64, rules []string) error {
+		got = append(got, fmt.Sprint(rec, rules))
+		return nil
+	})
+	if want := []string{"2 [admin-probe]", "3 [many-404]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestACKS_ScanRecordGroups(t *testing.T) {
+	ac := buildWords([]string{"warn", "error"})
+	var got []string
+	err := ac.ScanRecordGroups([]byte("ok\nwarn: error\nfine\nerror"), func(idx uint64, rec []byte, ms []Match) error {
+		got = append(got, fmt.Sprintf("%d %q %d-%d", idx, rec, len(ms), ms[0].From))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`1 "warn: error" 2-3`, `3 "error" 1-20`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,72 @@
+package ahocorasick
+
+import (
+	"errors"
+	"slices"
+)
+
+// Edit describes a change to a text: Deleted bytes at Offset were replaced
+// with Inserted.
+type Edit struct {
+	Offset   int
+	Deleted  int
+	Inserted []byte
+}
+
+// Rescan updates the matches of a text after an edit, as an editor
+// underlining keywords in a large document would on every keystroke. prev
+// holds the matches of the text before the edit and text is the text after
+// it. Only the window the edit can affect, up to MaxPatternLen bytes on
+// either side, is scanned again; the other matches are kept, shifted past
+// the edit. The result is ordered like FindN. As with MatchesEndingAt, every
+// match is seen: SingleMatch, dedup and sampling do not apply, so prev
+// should come from a previous Rescan or a matcher without them. With
+// normalizers the whole text is rescanned.
+func (ac *ACKS) Rescan(text []byte, prev []Match, e Edit) ([]Match, error) {
+	if e.Offset < 0 || e.Deleted < 0 || e.Offset+len(e.Inserted) > len(text) {
+		return nil, errors.New("ahocorasick: edit does not fit the text")
+	}
+	delta := len(e.Inserted) - e.Deleted
+	// One more byte on each side decides line anchors.
+	lo, hi := 0, len(text)
+	if len(ac.normalizers) == 0 {
+		lo = max(0, e.Offset-ac.maxPatternLen-1)
+		hi = min(len(text), e.Offset+len(e.Inserted)+ac.maxPatternLen+1)
+	}
+
+	var matches []Match
+	for _, m := range prev {
+		switch {
+		case int(m.From) < lo:
+			matches = append(matches, m)
+		case int(m.To)+delta > hi:
+			m.From = uint64(int(m.From) + delta)
+			m.To = uint64(int(m.To) + delta)
+			matches = append(matches, m)
+		}
+	}
+	if len(ac.patterns) > 0 {
+		ss := scanState{base: uint64(lo), probe: true}
+		if lo > 0 {
+			ss.history = text[lo-1 : lo]
+		}
+		h := handler{fn: func(from, to uint64, ps *Pattern) error {
+			if to <= uint64(hi) {
+				matches = append(matches, newMatch(from, to, ps))
+			}
+			return nil
+		}}
+		// The byte after the window decides a line end anchor.
+		_ = ac.searchWith(&ss, text[lo:min(hi+1, len(text))], &h)
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.To < b.To:
+			return -1
+		case a.To > b.To:
+			return 1
+		}
+		return 0
+	})
+	return matches, nil
+}
@@ -0,0 +1,46 @@
+package ahocorasick
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestACKS_Rescan(t *testing.T) {
+	ac := NewACKS()
+	for i, w := range []string{"he", "she", "his", "hers", "ab\nc"} {
+		ac.AddPattern(mkPat(w, uint(i+1), 0))
+	}
+	ac.AddPattern(mkPat("is", 6, LineAnchoredStart))
+	ac.AddPattern(mkPat("he", 7, LineAnchoredEnd|Caseless))
+	ac.Build()
+
+	r := rand.New(rand.NewSource(1))
+	alphabet := []byte("hesirab\n ")
+	word := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return b
+	}
+	text := word(400)
+	matches := ac.FindN(text, 0)
+	for i := 0; i < 500; i++ {
+		off := r.Intn(len(text) + 1)
+		e := Edit{Offset: off, Deleted: r.Intn(min(6, len(text)-off) + 1), Inserted: word(r.Intn(6))}
+		text = slices.Concat(text[:off], e.Inserted, text[off+e.Deleted:])
+		var err error
+		if matches, err = ac.Rescan(text, matches, e); err != nil {
+			t.Fatal(err)
+		}
+		if want := ac.FindN(text, 0); !reflect.DeepEqual(matches, want) {
+			t.Fatalf("Edit %d %+v: Expected %v, got %v", i, e, want, matches)
+		}
+	}
+
+	if _, err := ac.Rescan([]byte("ab"), nil, Edit{Offset: 1, Inserted: []byte("xyz")}); err == nil {
+		t.Errorf("Expected error for an edit past the end")
+	}
+}
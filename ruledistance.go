@@ -0,0 +1,94 @@
+package ahocorasick
+
+// distanceSpec is a "$b within n after $a" condition: a match of b starts
+// at most n bytes after the end of a match of a. It is evaluated online from
+// the ends of the recent matches of a. Matches of a may overlap a later
+// match of b, so the end that counts is the latest one before b starts.
+type distanceSpec struct {
+	idx  int // index into ACKS.distances and ruleState.distances
+	a, b uint
+	n    uint64
+}
+
+type distanceState struct {
+	ends []uint64 // ends of the recent matches of a, in order
+	hit  bool
+}
+
+// record adds the end of a match of a. Matches arrive in order of their
+// end, so a later match of b starts at most span bytes before to: ends
+// further back than that are only needed if no later end precedes b, and
+// ends more than n bytes before that can never be within range.
+func (d *distanceState) record(to, n, span uint64) {
+	ends := d.ends
+	for j := len(ends) - 1; j > 0; j-- {
+		if ends[j]+span <= to {
+			ends = ends[j:]
+			break
+		}
+	}
+	for len(ends) > 0 && ends[0]+span+n < to {
+		ends = ends[1:]
+	}
+	d.ends = append(append(d.ends[:0], ends...), to)
+}
+
+type distanceExpr struct{ spec *distanceSpec }
+
+func (e distanceExpr) eval(rs *ruleState) bool { return rs.distances[e.spec.idx].hit }
+
+func (ac *ACKS) addDistances(specs []*distanceSpec) {
+	for _, s := range specs {
+		s.idx = len(ac.distances)
+		ac.distances = append(ac.distances, s)
+	}
+}
+
+// observeDistances feeds a match of pat to the distance conditions
+// watching it. A pattern may be both ends of a condition, so it is first
+// checked as b against earlier matches, then recorded as a.
+func (rs *ruleState) observeDistances(pat *Pattern, from, to uint64) {
+	for _, i := range rs.distWatch[pat.slot] {
+		spec, d := rs.ac.distances[i], &rs.distances[i]
+		if spec.b != pat.ID {
+			continue
+		}
+		for _, end := range d.ends {
+			if end <= from && from-end <= spec.n {
+				d.hit = true
+				break
+			}
+		}
+	}
+	span := uint64(rs.ac.maxPatternLen)
+	for _, i := range rs.distWatch[pat.slot] {
+		if spec := rs.ac.distances[i]; spec.a == pat.ID {
+			rs.distances[i].record(to, spec.n, span)
+		}
+	}
+}
+
+func (rs *ruleState) newDistanceStates() {
+	ac := rs.ac
+	if len(ac.distances) == 0 {
+		return
+	}
+	rs.distances = make([]distanceState, len(ac.distances))
+	rs.distWatch = make([][]int, len(ac.ids))
+	for i, spec := range ac.distances {
+		for _, id := range []uint{spec.a, spec.b} {
+			if slot, ok := ac.slotOf(id); ok && !containsInt(rs.distWatch[slot], i) {
+				rs.distWatch[slot] = append(rs.distWatch[slot], i)
+			}
+		}
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
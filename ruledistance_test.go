@@ -0,0 +1,68 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_MatchRules_Distance(t *testing.T) {
+	ac := buildWords([]string{"HELO", "MAIL", "X"})
+	for _, r := range []Rule{
+		{Name: "smtp", Condition: "$mail within 5 after $helo", Strings: map[string]uint{"helo": 1, "mail": 2}},
+		{Name: "xx", Condition: "$3 within 0 after $3"},
+	} {
+		if err := ac.AddRule(r); err != nil {
+			t.Fatalf("AddRule(%q) failed: %v", r.Name, err)
+		}
+	}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"HELO a\r\nMAIL", []string{"smtp"}},
+		{"HELO a very long line\r\nMAIL", nil},
+		{"MAIL HELO", nil},
+		{"HELO ... HELO MAIL", []string{"smtp"}},
+		{"X X XX", []string{"xx"}},
+	}
+	for _, tt := range tests {
+		got, err := ac.MatchRules([]byte(tt.text))
+		if err != nil {
+			t.Fatalf("MatchRules failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+	if err := ac.AddRule(Rule{Name: "bad", Condition: "$1 within 5 before $2"}); err == nil {
+		t.Errorf("Expected a malformed distance to be rejected")
+	}
+}
+
+func TestACKS_MatchRules_DistanceOverlap(t *testing.T) {
+	// Matches of a overlap the match of b, so the a ending last is not the
+	// one that precedes it.
+	ac := buildWords([]string{"aXa", "Xa!", "aXaXaXaXa"})
+	if err := ac.AddRule(Rule{Name: "near", Condition: "$2 within 0 after $1"}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"aXaXa!", []string{"near"}},
+		{"aXaXaXaXa!", []string{"near"}},
+		{"aXa Xa!", nil},
+		{"Xa! aXa", nil},
+	}
+	for _, tt := range tests {
+		got, err := ac.MatchRules([]byte(tt.text))
+		if err != nil {
+			t.Fatalf("MatchRules failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+}
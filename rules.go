@@ -0,0 +1,504 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule is a named condition over the patterns found in a text, written in a
+// small subset of the YARA condition language:
+//
+//	$a                    pattern a was found
+//	#a >= 3               pattern a was found at least 3 times (==, !=, <, <=, >, >=)
+//	#a >= 3 within 100    3 matches of a fit in some 100-byte window (>= or >)
+//	$b within 100 after $a  b starts at most 100 bytes after the end of an a
+//	2 of them             at least 2 of the rule's patterns were found
+//	any of ($a, $b*)      also "all of"; $b* matches every name starting with b
+//	not, and, or, ( )     boolean logic
+//	true, false
+//
+// Strings maps the variable names used in the condition, without the "$",
+// to pattern IDs. If Strings is nil, variables are pattern IDs written in
+// decimal, as in "$12 and not $13", and "them" means every pattern.
+//
+// Conditions count every hit of a pattern, including those that SingleMatch,
+// WithDedupWindow or a SampleRate keep from being reported.
+type Rule struct {
+	Name      string
+	Condition string
+	Strings   map[string]uint
+	// Verdict is the code ScanPackets reports for a payload the rule holds
+	// on. The highest verdict wins; 0 means no verdict.
+	Verdict uint8
+
+	expr ruleExpr
+}
+
+// AddRule parses r and adds it to the matcher. Rules are evaluated by
+// MatchRules after the matcher is built.
+func (ac *ACKS) AddRule(r Rule) error {
+	p := ruleParser{rule: &r}
+	if err := p.tokenize(r.Condition); err != nil {
+		return fmt.Errorf("ahocorasick: rule %q: %w", r.Name, err)
+	}
+	expr, err := p.parseOr()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	if err != nil {
+		return fmt.Errorf("ahocorasick: rule %q: %w", r.Name, err)
+	}
+	r.expr = expr
+	ac.gen++
+	ac.addWindows(p.windows)
+	ac.addDistances(p.distances)
+	ac.rules = append(ac.rules, &r)
+	return nil
+}
+
+// MatchRules scans text and returns the names of the rules whose conditions
+// hold, in the order the rules were added.
+func (ac *ACKS) MatchRules(text []byte) ([]string, error) {
+	rs := ac.newRuleState()
+	ss := scanState{record: ac.newMatchRecord(), rules: rs}
+	if err := ac.searchWith(&ss, text, &handler{}); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, r := range ac.rules {
+		if r.expr.eval(rs) {
+			names = append(names, r.Name)
+		}
+	}
+	return names, nil
+}
+
+// buildIDIndex assigns every distinct pattern ID a dense slot, in order of
+// first appearance. The lookup index is a sorted slice rather than a map so
+// that Build stays map-free.
+func (ac *ACKS) buildIDIndex() {
+	perm := make([]int, len(ac.patterns))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		return ac.patterns[perm[i]].ID < ac.patterns[perm[j]].ID
+	})
+	// first[k] is the index of the first pattern sharing pattern k's ID.
+	first := make([]int, len(ac.patterns))
+	for i := 0; i < len(perm); {
+		j := i
+		for j < len(perm) && ac.patterns[perm[j]].ID == ac.patterns[perm[i]].ID {
+			first[perm[j]] = perm[i]
+			j++
+		}
+		i = j
+	}
+	ac.ids = ac.ids[:0]
+	for k, p := range ac.patterns {
+		if first[k] == k {
+			p.slot = len(ac.ids)
+			ac.ids = append(ac.ids, p.ID)
+		} else {
+			p.slot = ac.patterns[first[k]].slot
+		}
+	}
+	ac.idOrder = ac.idOrder[:0]
+	for i, k := range perm {
+		if i == 0 || ac.patterns[perm[i-1]].ID != ac.patterns[k].ID {
+			ac.idOrder = append(ac.idOrder, int32(ac.patterns[k].slot))
+		}
+	}
+}
+
+// slotOf returns the dense slot of a pattern ID.
+func (ac *ACKS) slotOf(id uint) (int, bool) {
+	i := sort.Search(len(ac.idOrder), func(i int) bool {
+		return ac.ids[ac.idOrder[i]] >= id
+	})
+	if i == len(ac.idOrder) || ac.ids[ac.idOrder[i]] != id {
+		return 0, false
+	}
+	return int(ac.idOrder[i]), true
+}
+
+// ruleState accumulates the pattern hits that rule conditions are evaluated
+// over.
+type ruleState struct {
+	ac     *ACKS
+	counts []uint64 // matches per ID slot
+
+	// windows holds the state of each online condition; watch lists the
+	// conditions watching each ID slot.
+	windows []windowState
+	watch   [][]int
+	// distances and distWatch do the same for distance conditions.
+	distances []distanceState
+	distWatch [][]int
+}
+
+func (ac *ACKS) newRuleState() *ruleState {
+	rs := &ruleState{ac: ac, counts: make([]uint64, len(ac.ids))}
+	rs.newWindowStates()
+	rs.newDistanceStates()
+	return rs
+}
+
+func (rs *ruleState) observe(pat *Pattern, from, to uint64) {
+	rs.counts[pat.slot]++
+	if rs.watch != nil {
+		rs.observeWindows(pat, from, to)
+	}
+	if rs.distWatch != nil {
+		rs.observeDistances(pat, from, to)
+	}
+}
+
+func (rs *ruleState) count(id uint) uint64 {
+	slot, ok := rs.ac.slotOf(id)
+	if !ok {
+		return 0
+	}
+	return rs.counts[slot]
+}
+
+func (rs *ruleState) reset() {
+	clear(rs.counts)
+	rs.resetWindows()
+	clear(rs.distances)
+}
+
+type ruleExpr interface {
+	eval(rs *ruleState) bool
+}
+
+type (
+	litExpr bool
+	notExpr struct{ x ruleExpr }
+	andExpr struct{ x, y ruleExpr }
+	orExpr  struct{ x, y ruleExpr }
+	// strExpr holds if the pattern was found.
+	strExpr struct{ id uint }
+	// countExpr compares the number of matches of a pattern with n.
+	countExpr struct {
+		id uint
+		op string
+		n  uint64
+	}
+	// ofExpr holds if at least n of ids were found; n < 0 means all of them.
+	ofExpr struct {
+		n   int
+		ids []uint
+	}
+)
+
+func (e litExpr) eval(rs *ruleState) bool { return bool(e) }
+func (e notExpr) eval(rs *ruleState) bool { return !e.x.eval(rs) }
+func (e andExpr) eval(rs *ruleState) bool { return e.x.eval(rs) && e.y.eval(rs) }
+func (e orExpr) eval(rs *ruleState) bool  { return e.x.eval(rs) || e.y.eval(rs) }
+func (e strExpr) eval(rs *ruleState) bool { return rs.count(e.id) > 0 }
+
+func (e countExpr) eval(rs *ruleState) bool {
+	c := rs.count(e.id)
+	switch e.op {
+	case "==":
+		return c == e.n
+	case "!=":
+		return c != e.n
+	case "<":
+		return c < e.n
+	case "<=":
+		return c <= e.n
+	case ">":
+		return c > e.n
+	}
+	return c >= e.n
+}
+
+func (e ofExpr) eval(rs *ruleState) bool {
+	ids := e.ids
+	if ids == nil {
+		ids = rs.ac.ids
+	}
+	need := e.n
+	if need < 0 {
+		need = len(ids)
+	}
+	found := 0
+	for _, id := range ids {
+		if rs.count(id) > 0 {
+			found++
+		}
+	}
+	return found >= need
+}
+
+type ruleParser struct {
+	rule      *Rule
+	toks      []string
+	pos       int
+	windows   []*windowSpec
+	distances []*distanceSpec
+}
+
+func (p *ruleParser) tokenize(s string) error {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			p.toks = append(p.toks, s[i:i+1])
+			i++
+		case strings.ContainsRune("<>=!", rune(c)):
+			j := i + 1
+			if j < len(s) && s[j] == '=' {
+				j++
+			}
+			p.toks = append(p.toks, s[i:j])
+			i = j
+		case c == '$' || c == '#' || isIdentByte(c):
+			j := i + 1
+			for j < len(s) && (isIdentByte(s[j]) || s[j] == '*') {
+				j++
+			}
+			p.toks = append(p.toks, s[i:j])
+			i = j
+		default:
+			return fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c < 0x80 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+func (p *ruleParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *ruleParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *ruleParser) expect(t string) error {
+	if got := p.next(); got != t {
+		return fmt.Errorf("expected %q, got %q", t, got)
+	}
+	return nil
+}
+
+func (p *ruleParser) parseOr() (ruleExpr, error) {
+	x, err := p.parseAnd()
+	for err == nil && p.peek() == "or" {
+		p.next()
+		var y ruleExpr
+		if y, err = p.parseAnd(); err == nil {
+			x = orExpr{x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *ruleParser) parseAnd() (ruleExpr, error) {
+	x, err := p.parseNot()
+	for err == nil && p.peek() == "and" {
+		p.next()
+		var y ruleExpr
+		if y, err = p.parseNot(); err == nil {
+			x = andExpr{x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *ruleParser) parseNot() (ruleExpr, error) {
+	if p.peek() == "not" {
+		p.next()
+		x, err := p.parseNot()
+		return notExpr{x}, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleExpr, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	case t == "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case t == "true" || t == "false":
+		return litExpr(t == "true"), nil
+	case t == "any" || t == "all":
+		n := 1
+		if t == "all" {
+			n = -1
+		}
+		return p.parseOf(n)
+	case t[0] == '$':
+		id, err := p.lookup(t[1:])
+		if err == nil && p.peek() == "within" {
+			p.next()
+			return p.parseDistance(t, id)
+		}
+		return strExpr{id}, err
+	case t[0] == '#':
+		id, err := p.lookup(t[1:])
+		if err != nil {
+			return nil, err
+		}
+		op := p.next()
+		switch op {
+		case "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("expected comparison after %q, got %q", t, op)
+		}
+		n, err := strconv.ParseUint(p.next(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid count in %q comparison", t)
+		}
+		if p.peek() == "within" {
+			p.next()
+			return p.parseWindow(t, id, op, n)
+		}
+		return countExpr{id: id, op: op, n: n}, nil
+	default:
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected %q", t)
+		}
+		return p.parseOf(n)
+	}
+}
+
+// parseWindow parses the "N" of "#a >= k within N".
+func (p *ruleParser) parseWindow(t string, id uint, op string, k uint64) (ruleExpr, error) {
+	n, err := strconv.ParseUint(p.next(), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window size after %q", t)
+	}
+	switch op {
+	case ">":
+		k++
+	case ">=":
+	default:
+		return nil, fmt.Errorf("window condition on %q needs >= or >", t)
+	}
+	if k == 0 || k > 1<<16 {
+		return nil, fmt.Errorf("window count on %q must be between 1 and 65536", t)
+	}
+	spec := &windowSpec{id: id, k: int(k), n: n}
+	p.windows = append(p.windows, spec)
+	return windowExpr{spec}, nil
+}
+
+// parseDistance parses the "N after $a" of "$b within N after $a".
+func (p *ruleParser) parseDistance(t string, b uint) (ruleExpr, error) {
+	n, err := strconv.ParseUint(p.next(), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distance after %q", t)
+	}
+	if err := p.expect("after"); err != nil {
+		return nil, err
+	}
+	at := p.next()
+	if at == "" || at[0] != '$' {
+		return nil, fmt.Errorf("expected string variable, got %q", at)
+	}
+	a, err := p.lookup(at[1:])
+	if err != nil {
+		return nil, err
+	}
+	spec := &distanceSpec{a: a, b: b, n: n}
+	p.distances = append(p.distances, spec)
+	return distanceExpr{spec}, nil
+}
+
+// parseOf parses the "of <set>" part of "N of", "any of" and "all of".
+func (p *ruleParser) parseOf(n int) (ruleExpr, error) {
+	if err := p.expect("of"); err != nil {
+		return nil, err
+	}
+	if p.peek() == "them" {
+		p.next()
+		return ofExpr{n: n, ids: p.them()}, nil
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for {
+		t := p.next()
+		if t == "" || t[0] != '$' {
+			return nil, fmt.Errorf("expected string variable, got %q", t)
+		}
+		if strings.HasSuffix(t, "*") && p.rule.Strings != nil {
+			prefix := t[1 : len(t)-1]
+			matched := false
+			for name, id := range p.rule.Strings {
+				if strings.HasPrefix(name, prefix) {
+					ids = append(ids, id)
+					matched = true
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("no strings match %q", t)
+			}
+		} else {
+			id, err := p.lookup(t[1:])
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		if p.peek() != "," {
+			break
+		}
+		p.next()
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ofExpr{n: n, ids: ids}, p.expect(")")
+}
+
+// them returns the IDs of the rule's strings, or nil for every pattern.
+func (p *ruleParser) them() []uint {
+	if p.rule.Strings == nil {
+		return nil
+	}
+	ids := make([]uint, 0, len(p.rule.Strings))
+	for _, id := range p.rule.Strings {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (p *ruleParser) lookup(name string) (uint, error) {
+	if p.rule.Strings == nil {
+		id, err := strconv.ParseUint(name, 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pattern id %q", name)
+		}
+		return uint(id), nil
+	}
+	id, ok := p.rule.Strings[name]
+	if !ok {
+		return 0, fmt.Errorf("undefined string $%s", name)
+	}
+	return id, nil
+}
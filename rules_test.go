@@ -0,0 +1,75 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACKS_MatchRules(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("MZ", 1, 0))
+	ac.AddPattern(mkPat("This program", 2, 0))
+	ac.AddPattern(mkPat("UPX0", 3, 0))
+	ac.AddPattern(mkPat("UPX1", 4, 0))
+	ac.AddPattern(mkPat("evil", 5, Caseless))
+
+	rules := []Rule{
+		{Name: "pe", Condition: "$mz and $dos", Strings: map[string]uint{"mz": 1, "dos": 2}},
+		{Name: "upx", Condition: "$mz and all of ($upx*)", Strings: map[string]uint{"mz": 1, "upx0": 3, "upx1": 4}},
+		{Name: "evil3", Condition: "#5 >= 3"},
+		{Name: "two", Condition: "2 of them"},
+		{Name: "clean", Condition: "not ($5 or $3)"},
+	}
+	for _, r := range rules {
+		if err := ac.AddRule(r); err != nil {
+			t.Fatalf("AddRule(%q) failed: %v", r.Name, err)
+		}
+	}
+	ac.Build()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"MZ This program UPX0 UPX1", []string{"pe", "upx", "two"}},
+		{"MZ UPX0", []string{"two"}},
+		{"Evil EVIL evil", []string{"evil3"}},
+		{"This program", []string{"clean"}},
+	}
+	for _, tt := range tests {
+		got, err := ac.MatchRules([]byte(tt.text))
+		if err != nil {
+			t.Fatalf("MatchRules failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestACKS_AddRule_Errors(t *testing.T) {
+	ac := NewACKS()
+	for _, cond := range []string{"$a and", "$x", "#1 >=", "2 of ($1", "$1 $2", "($1", "@1"} {
+		r := Rule{Name: "bad", Condition: cond}
+		if cond == "$x" || cond == "$a and" {
+			r.Strings = map[string]uint{"a": 1}
+		}
+		if err := ac.AddRule(r); err == nil {
+			t.Errorf("%q: Expected error", cond)
+		}
+	}
+}
+
+func TestACKS_MatchRules_CountsBeforeFilters(t *testing.T) {
+	ac := NewACKS(WithDedupWindow(100))
+	ac.AddPattern(mkPat("a", 1, SingleMatch))
+	sampled := mkPat("b", 2, 0)
+	sampled.SampleRate = 10
+	ac.AddPattern(sampled)
+	ac.AddRule(Rule{Name: "r", Condition: "#1 == 3 and #2 == 3"})
+	ac.Build()
+	got, err := ac.MatchRules([]byte("ab ab ab"))
+	if err != nil || !reflect.DeepEqual(got, []string{"r"}) {
+		t.Errorf("Expected every hit to count, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,75 @@
+package ahocorasick
+
+// windowSpec is a "#a >= k within n" condition: k matches of a pattern whose
+// span, from the start of the first to the end of the last, is at most n
+// bytes. It is tracked online while scanning, so its state lives in the
+// ruleState rather than being recomputed from counts.
+type windowSpec struct {
+	idx int // index into ACKS.windows and ruleState.windows
+	id  uint
+	k   int
+	n   uint64
+}
+
+// windowState remembers the start offsets of the last k matches in a ring.
+type windowState struct {
+	starts []uint64
+	pos    int
+	full   bool
+	hit    bool
+}
+
+type windowExpr struct{ spec *windowSpec }
+
+func (e windowExpr) eval(rs *ruleState) bool { return rs.windows[e.spec.idx].hit }
+
+// addWindows registers the online conditions of a rule, giving each its
+// slot in the scan scratch.
+func (ac *ACKS) addWindows(specs []*windowSpec) {
+	for _, s := range specs {
+		s.idx = len(ac.windows)
+		ac.windows = append(ac.windows, s)
+	}
+}
+
+// observeWindows feeds a match of pat to the online conditions watching it.
+func (rs *ruleState) observeWindows(pat *Pattern, from, to uint64) {
+	for _, i := range rs.watch[pat.slot] {
+		spec, w := rs.ac.windows[i], &rs.windows[i]
+		if w.hit {
+			continue
+		}
+		w.starts[w.pos] = from
+		w.pos++
+		if w.pos == len(w.starts) {
+			w.pos, w.full = 0, true
+		}
+		// With the ring full, the oldest entry is the k-th most recent match.
+		if w.full && to-w.starts[w.pos] <= spec.n {
+			w.hit = true
+		}
+	}
+}
+
+// newWindowStates sets up the online condition scratch of rs.
+func (rs *ruleState) newWindowStates() {
+	ac := rs.ac
+	if len(ac.windows) == 0 {
+		return
+	}
+	rs.windows = make([]windowState, len(ac.windows))
+	rs.watch = make([][]int, len(ac.ids))
+	for i, spec := range ac.windows {
+		rs.windows[i].starts = make([]uint64, spec.k)
+		if slot, ok := ac.slotOf(spec.id); ok {
+			rs.watch[slot] = append(rs.watch[slot], i)
+		}
+	}
+}
+
+func (rs *ruleState) resetWindows() {
+	for i := range rs.windows {
+		w := &rs.windows[i]
+		w.pos, w.full, w.hit = 0, false, false
+	}
+}
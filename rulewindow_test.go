@@ -0,0 +1,45 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_MatchRules_Window(t *testing.T) {
+	ac := buildWords([]string{"fail", "ok"})
+	for _, r := range []Rule{
+		{Name: "burst", Condition: "#f >= 3 within 20", Strings: map[string]uint{"f": 1}},
+		{Name: "burst-ok", Condition: "#2 > 1 within 5 and not #1 >= 1 within 100"},
+	} {
+		if err := ac.AddRule(r); err != nil {
+			t.Fatalf("AddRule(%q) failed: %v", r.Name, err)
+		}
+	}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		// Three failures, but never three inside 20 bytes.
+		{"fail" + strings.Repeat(".", 10) + "fail" + strings.Repeat(".", 10) + "fail", nil},
+		{"fail" + strings.Repeat(".", 20) + "fail fail fail", []string{"burst"}},
+		{"ok ok", []string{"burst-ok"}},
+		{"ok  ok", nil},
+	}
+	for _, tt := range tests {
+		got, err := ac.MatchRules([]byte(tt.text))
+		if err != nil {
+			t.Fatalf("MatchRules failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: Expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+
+	for _, cond := range []string{"#1 < 3 within 10", "#1 >= 0 within 10", "#1 >= 2 within x"} {
+		if err := ac.AddRule(Rule{Name: "bad", Condition: cond}); err == nil {
+			t.Errorf("Expected %q to be rejected", cond)
+		}
+	}
+}
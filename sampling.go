@@ -0,0 +1,111 @@
+package ahocorasick
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// WithRandomSampling samples patterns with a SampleRate at random, each hit
+// being reported with probability 1/SampleRate. By default sampling is
+// deterministic: the first hit and then every SampleRate-th one are
+// reported.
+func WithRandomSampling() Option {
+	return func(ac *ACKS) {
+		ac.sampleRandom = true
+	}
+}
+
+// sampleOut reports whether this hit of pat is dropped by its SampleRate.
+func (ss *scanState) sampleOut(pat *Pattern, ac *ACKS) bool {
+	if ac.sampleRandom {
+		if ss.rng == 0 {
+			ss.rng = rand.Uint64() | 1
+		}
+		// xorshift64
+		ss.rng ^= ss.rng << 13
+		ss.rng ^= ss.rng >> 7
+		ss.rng ^= ss.rng << 17
+		return ss.rng%uint64(pat.SampleRate) != 0
+	}
+	if ss.hits == nil {
+		ss.hits = make([]uint32, len(ac.ids))
+	}
+	n := ss.hits[pat.slot]
+	ss.hits[pat.slot]++
+	return n%pat.SampleRate != 0
+}
+
+// Accumulator holds exact hit counters per pattern ID, counting every
+// verified hit before SingleMatch, WithDedupWindow or SampleRate drop it.
+// It is safe for concurrent scans.
+type Accumulator struct {
+	ac     *ACKS
+	counts []atomic.Uint64
+	rates  *hitRates
+}
+
+// EnableCounters attaches an Accumulator to the built matcher and returns
+// it. All later scans count into it. It must be called after Build and
+// before scanning starts. A later Build keeps the counts of the IDs that
+// remain.
+func (ac *ACKS) EnableCounters() *Accumulator {
+	a := &Accumulator{ac: ac, counts: make([]atomic.Uint64, len(ac.ids))}
+	ac.acc = a
+	return a
+}
+
+// remap moves the counters, and the hit rates if tracked, from the ID slots
+// old of the previous build to those of the rebuilt matcher. IDs that are
+// gone lose their counts.
+func (a *Accumulator) remap(old []uint) {
+	prev := make(map[uint]int, len(old))
+	for slot, id := range old {
+		prev[id] = slot
+	}
+	ids := a.ac.ids
+	counts := make([]atomic.Uint64, len(ids))
+	for slot, id := range ids {
+		if o, ok := prev[id]; ok {
+			counts[slot].Store(a.counts[o].Load())
+		}
+	}
+	a.counts = counts
+	if r := a.rates; r != nil {
+		r.mu.Lock()
+		last, rates := make([]uint64, len(ids)), make([]float64, len(ids))
+		for slot, id := range ids {
+			if o, ok := prev[id]; ok {
+				last[slot], rates[slot] = r.last[o], r.rates[o]
+			}
+		}
+		r.last, r.rates = last, rates
+		r.mu.Unlock()
+	}
+}
+
+// Count returns the number of hits of pattern id.
+func (a *Accumulator) Count(id uint) uint64 {
+	slot, ok := a.ac.slotOf(id)
+	if !ok {
+		return 0
+	}
+	return a.counts[slot].Load()
+}
+
+// Snapshot returns the non-zero counters by pattern ID.
+func (a *Accumulator) Snapshot() map[uint]uint64 {
+	out := make(map[uint]uint64)
+	for slot := range a.counts {
+		if n := a.counts[slot].Load(); n > 0 {
+			out[a.ac.ids[slot]] = n
+		}
+	}
+	return out
+}
+
+// Reset zeroes every counter.
+func (a *Accumulator) Reset() {
+	for slot := range a.counts {
+		a.counts[slot].Store(0)
+	}
+}
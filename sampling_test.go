@@ -0,0 +1,75 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestACKS_SampleRate(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("GET"), ID: 1, SampleRate: 4})
+	ac.AddPattern(Pattern{Content: []byte("attack"), ID: 2})
+	ac.Build()
+	acc := ac.EnableCounters()
+
+	text := []byte(strings.Repeat("GET / ", 10) + "attack")
+	var offsets []uint64
+	ac.Scan(text, func(id uint, from, to uint64) error {
+		if id == 1 {
+			offsets = append(offsets, to)
+		}
+		return nil
+	})
+	// The 1st, 5th and 9th hits are reported.
+	if len(offsets) != 3 || offsets[0] != 3 || offsets[1] != 27 || offsets[2] != 51 {
+		t.Errorf("Unexpected sampled hits %v", offsets)
+	}
+	if acc.Count(1) != 10 || acc.Count(2) != 1 {
+		t.Errorf("Expected exact counts 10 and 1, got %v", acc.Snapshot())
+	}
+	acc.Reset()
+	if acc.Count(1) != 0 {
+		t.Errorf("Expected Reset to zero the counters")
+	}
+
+	var buf bytes.Buffer
+	ac.WriteTo(&buf)
+	if loaded, err := Load(&buf); err != nil || loaded.patterns[0].SampleRate != 4 {
+		t.Errorf("Expected SampleRate 4 after Load, got %v", err)
+	}
+}
+
+func TestACKS_RandomSampling(t *testing.T) {
+	ac := NewACKS(WithRandomSampling())
+	ac.AddPattern(Pattern{Content: []byte("x"), ID: 1, SampleRate: 10})
+	ac.Build()
+	n := 0
+	ac.Scan(bytes.Repeat([]byte("x"), 100000), func(id uint, from, to uint64) error {
+		n++
+		return nil
+	})
+	if n < 9000 || n > 11000 {
+		t.Errorf("Expected about 10000 sampled hits, got %d", n)
+	}
+}
+
+func TestAccumulator_Rebuild(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("zeta", 9, 0))
+	ac.Build()
+	acc := ac.EnableCounters()
+	acc.TrackRates(time.Second)
+	ac.Scan([]byte("zeta zeta"), nil)
+
+	// Adding a lower ID moves zeta to another slot.
+	ac.AddPattern(mkPat("alpha", 1, 0))
+	ac.Build()
+	ac.Scan([]byte("alpha zeta"), nil)
+	if want := map[uint]uint64{1: 1, 9: 3}; !reflect.DeepEqual(acc.Snapshot(), want) {
+		t.Errorf("Expected %v, got %v", want, acc.Snapshot())
+	}
+	acc.Tick(time.Now())
+}
@@ -0,0 +1,46 @@
+package ahocorasick
+
+import (
+	"io"
+)
+
+// RangeReader is the random-access read interface used by ScanRange. It is
+// satisfied by *os.File, io.SectionReader and most object-store clients that
+// support ranged GETs.
+type RangeReader interface {
+	ReadAt(p []byte, off int64) (n int, err error)
+}
+
+// rangeChunkSize is the read size ScanRange uses for each ReadAt.
+const rangeChunkSize = 1024 * 1024
+
+// ScanRange scans the n bytes of r starting at off and reports matches with
+// absolute offsets. It rereads up to MaxPatternLen()-1 bytes before off, so
+// that matches crossing into the range are found, and reports exactly the
+// matches that end inside the range. Workers scanning adjacent ranges in
+// parallel therefore report every match exactly once, with no coordination.
+//
+// It also reads the byte after the range, which decides LineAnchoredEnd and
+// WordBoundary matches ending at its last byte.
+//
+// SingleMatch, dedup windows, sampling and counters apply per call, to the
+// matches in the range only.
+func (ac *ACKS) ScanRange(r RangeReader, off, n int64, m MatchedHandler) error {
+	if n <= 0 {
+		return nil
+	}
+	start := off - int64(ac.StreamHistorySize())
+	if start < 0 {
+		start = 0
+	}
+	section := io.NewSectionReader(r, start, off+n+1-start)
+	st := ac.NewStream()
+	st.ss.leadIn, st.ss.limit = uint64(off-start), uint64(off+n-start)
+	h := func(id uint, from, to uint64) error {
+		if m == nil {
+			return nil
+		}
+		return m(id, from, uint64(start)+to)
+	}
+	return ac.scanReader(section, min(rangeChunkSize, int(off+n+1-start)), st, h)
+}
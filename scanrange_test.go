@@ -0,0 +1,68 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestACKS_ScanRange(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("needle", 1, 0))
+	ac.AddPattern(mkPat("le", 2, 0))
+	ac.Build()
+
+	text := []byte("needle.needleneedle..needle")
+	var want []uint64
+	ac.Scan(text, func(id uint, from, to uint64) error {
+		want = append(want, to*10+uint64(id))
+		return nil
+	})
+
+	r := bytes.NewReader(text)
+	for _, size := range []int64{1, 3, 5, 8, 100} {
+		var got []uint64
+		for off := int64(0); off < int64(len(text)); off += size {
+			n := min(size, int64(len(text))-off)
+			err := ac.ScanRange(r, off, n, func(id uint, from, to uint64) error {
+				got = append(got, to*10+uint64(id))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ScanRange failed: %v", err)
+			}
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("size %d: Expected %v, got %v", size, want, got)
+		}
+	}
+}
+
+func TestACKS_ScanRangeLeadIn(t *testing.T) {
+	// The lead-in before the range holds a match of bc, which must not use
+	// up SingleMatch, the dedup window or the counters.
+	for _, opts := range [][]Option{nil, {WithDedupWindow(100)}} {
+		ac := NewACKS(opts...)
+		ac.AddPattern(mkPat("abcd", 1, 0))
+		ac.AddPattern(mkPat("bc", 2, SingleMatch))
+		ac.Build()
+		acc := ac.EnableCounters()
+
+		var got []uint64
+		err := ac.ScanRange(bytes.NewReader([]byte("abcd bcd")), 4, 4, func(id uint, from, to uint64) error {
+			got = append(got, to*10+uint64(id))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ScanRange failed: %v", err)
+		}
+		if want := []uint64{72}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: Expected %v, got %v", opts, want, got)
+		}
+		if n := acc.Count(2); n != 1 {
+			t.Errorf("%v: Expected 1 counted hit of bc, got %d", opts, n)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ahocorasick
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+)
+
+// Buckets of a ScanSampler: match density in matches per KiB and mean
+// automaton depth in bytes.
+var (
+	densityLabels = [...]string{"0", "0-1", "1-8", "8-64", ">64"}
+	depthLabels   = [...]string{"0-1", "2-3", "4-7", "8-15", ">15"}
+)
+
+// SampleStats is the scan effort attributed to one ScanSampler bucket.
+type SampleStats struct {
+	Blocks uint64
+	Bytes  uint64
+	Nanos  uint64
+}
+
+// ScanSampler attributes scan time, sampled block by block, to buckets of
+// match density and state depth. Slow blocks with many matches point at
+// the handler or noisy patterns, slow deep blocks at long shared prefixes,
+// and slow shallow blocks without matches at table cache misses. Timing one
+// block in Every keeps the overhead of the rest near zero.
+type ScanSampler struct {
+	// BlockSize is the number of bytes per block, 4 KiB if 0.
+	BlockSize int
+	// Every samples one block in Every, 16 if 0.
+	Every int
+	// Stats is indexed by density and then depth bucket.
+	Stats [len(densityLabels)][len(depthLabels)]SampleStats
+
+	blocks uint64
+}
+
+// depthProbes is how many times the depth is read per sampled block.
+const depthProbes = 8
+
+// SampledScan scans text like Scan, timing a sample of its blocks into s.
+func (ac *ACKS) SampledScan(text []byte, s *ScanSampler, m MatchedHandler) error {
+	block, every := s.BlockSize, s.Every
+	if block <= 0 {
+		block = 4 << 10
+	}
+	if every <= 0 {
+		every = 16
+	}
+	h := handler{scan: m}
+	if len(ac.normalizers) > 0 {
+		text, h.offsets = ac.normalize(text)
+	}
+	st := ac.NewStream()
+	st.ss.observe = false
+	scan := func(piece []byte, more bool) error {
+		st.ss.stream = more
+		if err := ac.searchText(&st.ss, piece, &h); err != nil {
+			return err
+		}
+		st.remember(piece)
+		st.ss.base += uint64(len(piece))
+		return nil
+	}
+	for len(text) > 0 {
+		chunk := text[:min(block, len(text))]
+		text = text[len(chunk):]
+		s.blocks++
+		if s.blocks%uint64(every) != 0 {
+			if err := scan(chunk, len(text) > 0); err != nil {
+				return err
+			}
+			continue
+		}
+		matches, depth := st.ss.matches, 0
+		start := time.Now()
+		step := max(len(chunk)/depthProbes, 1)
+		for i := 0; i < len(chunk); i += step {
+			piece := chunk[i:min(i+step, len(chunk))]
+			if err := scan(piece, len(text) > 0 || i+step < len(chunk)); err != nil {
+				return err
+			}
+			depth += int(ac.depth[st.ss.state])
+		}
+		elapsed := time.Since(start)
+		if ac.nibble {
+			depth /= 2
+		}
+		probes := (len(chunk) + step - 1) / step
+		density := float64(st.ss.matches-matches) * 1024 / float64(len(chunk))
+		c := &s.Stats[densityBucket(density)][depthBucket(depth/probes)]
+		c.Blocks++
+		c.Bytes += uint64(len(chunk))
+		c.Nanos += uint64(elapsed)
+	}
+	return nil
+}
+
+func densityBucket(d float64) int {
+	switch {
+	case d == 0:
+		return 0
+	case d <= 1:
+		return 1
+	case d <= 8:
+		return 2
+	case d <= 64:
+		return 3
+	}
+	return 4
+}
+
+func depthBucket(d int) int {
+	return min(bits.Len(uint(d)>>1), len(depthLabels)-1)
+}
+
+// WriteReport writes the non-empty buckets of s with their share of the
+// sampled time and their cost per byte.
+func (s *ScanSampler) WriteReport(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var total uint64
+	for _, row := range s.Stats {
+		for _, c := range row {
+			total += c.Nanos
+		}
+	}
+	fmt.Fprintf(bw, "sampled %d of %d blocks\n", s.sampled(), s.blocks)
+	for i, row := range s.Stats {
+		for j, c := range row {
+			if c.Blocks == 0 {
+				continue
+			}
+			fmt.Fprintf(bw, "  density=%s depth=%s blocks=%d time=%.1f%% ns/byte=%.2f\n",
+				densityLabels[i], depthLabels[j],
+				c.Blocks, 100*float64(c.Nanos)/float64(max(total, 1)), float64(c.Nanos)/float64(c.Bytes))
+		}
+	}
+	return bw.Flush()
+}
+
+func (s *ScanSampler) sampled() uint64 {
+	var n uint64
+	for _, row := range s.Stats {
+		for _, c := range row {
+			n += c.Blocks
+		}
+	}
+	return n
+}
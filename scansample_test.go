@@ -0,0 +1,49 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_SampledScan(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers", "abcdefghijklmnop"})
+	text := []byte(strings.Repeat("x", 4096) + strings.Repeat("she hers ", 600) + strings.Repeat("abcdefghijklmnop", 300))
+
+	collect := func(out *[]string) MatchedHandler {
+		return func(id uint, from, to uint64) error {
+			*out = append(*out, fmt.Sprint(id, to))
+			return nil
+		}
+	}
+	var want, got []string
+	ac.Scan(text, collect(&want))
+	s := &ScanSampler{BlockSize: 1000, Every: 1}
+	if err := ac.SampledScan(text, s, collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sampling changed the matches: got %d, want %d", len(got), len(want))
+	}
+
+	// Quiet text, dense matches and a deep pattern land in distinct buckets.
+	if s.Stats[0][0].Blocks == 0 || s.Stats[4][0].Blocks+s.Stats[4][1].Blocks == 0 || s.Stats[3][3].Blocks == 0 {
+		t.Errorf("Unexpected buckets %+v", s.Stats)
+	}
+	var sum uint64
+	for _, row := range s.Stats {
+		for _, c := range row {
+			sum += c.Bytes
+		}
+	}
+	if sum != uint64(len(text)) {
+		t.Errorf("Expected %d sampled bytes, got %d", len(text), sum)
+	}
+	var buf bytes.Buffer
+	s.WriteReport(&buf)
+	if !strings.Contains(buf.String(), "density=>64") {
+		t.Errorf("Unexpected report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,495 @@
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"sync/atomic"
+)
+
+// Serialized database layout. All integers are little-endian and every
+// section starts on an 8-byte boundary, so the image can be read in place on
+// any architecture, including wasm:
+//
+//	header       64 bytes, see the hdr* offsets below
+//	translate    [256]uint8
+//	stateTable   [denseStates*alphabetSize]int32
+//	failure      [stateCount]int32
+//	depth        [stateCount]int32
+//	sparseIndex  [stateCount-denseStates+1]int32, empty if all states are dense
+//	sparseChars  [sparseCount]uint8
+//	sparseNext   [sparseCount]int32
+//	outputIndex  [stateCount+1]uint32
+//	outputs      [outputCount]uint32
+//	patterns     [patternCount]{id uint64; flags, plen, contentOff, contentLen, sourceOff, sourceLen uint32;
+//	                            severity int32; sampleRate uint32; classesOff, classCount uint32}
+//	strings      [stringsSize]uint8, starting with the class records
+//	             {offset uint32; set [32]uint8} of every pattern
+//	metadata     [metaSize]uint8: {count uint32} then count key/value pairs,
+//	             each string as {len uint32; bytes}; empty without metadata
+//	trailer      crc32 (IEEE) of everything before it, then 4 zero bytes
+//
+// Normalizers, rules and pattern categories are not part of the image; pass
+// WithNormalizers to Load and re-add rules after loading.
+//
+// The same layout is published as C structs in c/acks.h, with a small
+// reference reader in c/acks.c, so data planes written in C can scan
+// databases compiled in Go. Changes to the layout must bump dbVersion, keep
+// the header in step and keep older versions loading, see version.go.
+const (
+	dbMagic   = "ACKSDB\x00\x00"
+	dbVersion = 5
+
+	dbHeaderSize  = 64
+	dbPatternSize = 48
+	// Version 1 records stop before the severity.
+	dbPatternSizeV1 = 32
+	// dbClassSize is the size of a class record, added in version 4.
+	dbClassSize = 36
+
+	dbNibble     = 1 << 0
+	dbFoldCase   = 1 << 1
+	dbUnusedCode = 1 << 2
+	dbExactCase  = 1 << 3
+	// dbVerifyAll marks byte classes sharing codes: every candidate must be
+	// verified, not only case-sensitive ones under dbFoldCase. Added in
+	// version 3.
+	dbVerifyAll = 1 << 4
+)
+
+// patternFlags are the pattern flags this reader understands. An image
+// with any other bit set was written for matching rules it cannot follow.
+const patternFlags = Caseless | SingleMatch | LineAnchoredStart | LineAnchoredEnd | ExpandEncodings | WordBoundary
+
+// Header field offsets.
+const (
+	hdrVersion      = 8
+	hdrFlags        = 12
+	hdrAlphabetSize = 16
+	hdrStateCount   = 20
+	hdrDenseStates  = 24
+	hdrPatternCount = 28
+	hdrSparseCount  = 32
+	hdrOutputCount  = 36
+	hdrStringsSize  = 40
+	hdrPrefixLen    = 44
+	hdrMaxPattern   = 48
+	// hdrMetaSize was added in version 5.
+	hdrMetaSize = 52
+)
+
+// ErrCorruptDatabase is returned by Load when the image fails validation.
+var ErrCorruptDatabase = errors.New("ahocorasick: corrupt database")
+
+// WriteTo writes the compiled matcher to w in the serialized database format.
+// The matcher must be built.
+func (ac *ACKS) WriteTo(w io.Writer) (int64, error) {
+	if ac.stateCount == 0 {
+		return 0, errors.New("ahocorasick: matcher is not built")
+	}
+	n, err := w.Write(ac.appendImage(nil))
+	return int64(n), err
+}
+
+func (ac *ACKS) appendImage(b []byte) []byte {
+	le := binary.LittleEndian
+	outputCount, stringsSize := 0, 0
+	for _, out := range ac.outputTable {
+		outputCount += len(out)
+	}
+	for _, p := range ac.patterns {
+		stringsSize += len(p.Content) + len(p.Source) + len(p.Classes)*dbClassSize
+	}
+	var flags uint32
+	if ac.nibble {
+		flags |= dbNibble
+	}
+	if ac.foldCase {
+		flags |= dbFoldCase
+	}
+	if ac.unusedCode {
+		flags |= dbUnusedCode
+	}
+	if ac.exactCase {
+		flags |= dbExactCase
+	}
+	if ac.classMerged {
+		flags |= dbVerifyAll
+	}
+
+	meta := ac.appendMetadata(nil)
+
+	start := len(b)
+	b = append(b, dbMagic...)
+	for _, v := range []int{dbVersion, int(flags), ac.alphabetSize, ac.stateCount, ac.denseStates,
+		len(ac.patterns), len(ac.sparseChars), outputCount, stringsSize, ac.prefixLen, ac.maxPatternLen, len(meta)} {
+		b = le.AppendUint32(b, uint32(v))
+	}
+	for len(b)-start < dbHeaderSize {
+		b = append(b, 0)
+	}
+
+	b = append(b, ac.translateTable[:]...)
+	b = appendInt32s(b, start, ac.stateTable)
+	b = appendInt32s(b, start, ac.failure)
+	b = appendInt32s(b, start, ac.depth)
+	b = appendInt32s(b, start, ac.sparseIndex)
+	b = pad8(append(b, ac.sparseChars...), start)
+	b = appendInt32s(b, start, ac.sparseNext)
+
+	var off uint32
+	for _, out := range ac.outputTable {
+		b = le.AppendUint32(b, off)
+		off += uint32(len(out))
+	}
+	b = pad8(le.AppendUint32(b, off), start)
+	for _, out := range ac.outputTable {
+		for _, k := range out {
+			b = le.AppendUint32(b, uint32(k))
+		}
+	}
+	b = pad8(b, start)
+
+	var classOff uint32
+	off = 0
+	for _, p := range ac.patterns {
+		off += uint32(len(p.Classes) * dbClassSize)
+	}
+	for _, p := range ac.patterns {
+		b = le.AppendUint64(b, uint64(p.ID))
+		b = le.AppendUint32(b, uint32(p.Flags))
+		b = le.AppendUint32(b, uint32(p.plen))
+		b = le.AppendUint32(b, off)
+		b = le.AppendUint32(b, uint32(len(p.Content)))
+		off += uint32(len(p.Content))
+		b = le.AppendUint32(b, off)
+		b = le.AppendUint32(b, uint32(len(p.Source)))
+		off += uint32(len(p.Source))
+		b = le.AppendUint32(b, uint32(int32(p.Severity)))
+		b = le.AppendUint32(b, p.SampleRate)
+		b = le.AppendUint32(b, classOff)
+		b = le.AppendUint32(b, uint32(len(p.Classes)))
+		classOff += uint32(len(p.Classes) * dbClassSize)
+	}
+	for _, p := range ac.patterns {
+		for _, c := range p.Classes {
+			b = le.AppendUint32(b, uint32(c.Offset))
+			for _, w := range c.Set {
+				b = le.AppendUint64(b, w)
+			}
+		}
+	}
+	for _, p := range ac.patterns {
+		b = append(b, p.Content...)
+		b = append(b, p.Source...)
+	}
+	b = pad8(b, start)
+	b = pad8(append(b, meta...), start)
+
+	b = le.AppendUint32(b, crc32.ChecksumIEEE(b[start:]))
+	return le.AppendUint32(b, 0)
+}
+
+func appendInt32s(b []byte, start int, v []int32) []byte {
+	for _, x := range v {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x))
+	}
+	return pad8(b, start)
+}
+
+func pad8(b []byte, start int) []byte {
+	for (len(b)-start)%8 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// Load reads a database written by WriteTo and returns a ready matcher.
+// Options are applied first; only those affecting scanning, such as
+// WithNormalizers, have any effect.
+func Load(r io.Reader, opts ...Option) (*ACKS, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return loadImage(data, false, opts...)
+}
+
+// LoadFS loads the database stored as name in fsys, typically an embed.FS
+// holding a database compiled ahead of time.
+func LoadFS(fsys fs.FS, name string, opts ...Option) (*ACKS, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return loadImage(data, false, opts...)
+}
+
+// imageReader decodes consecutive sections of an image, recording the
+// first out-of-bounds read. With inPlace set, sections are aliased rather
+// than copied wherever the host byte order and alignment allow it.
+type imageReader struct {
+	data    []byte
+	pos     int
+	bad     bool
+	inPlace bool
+}
+
+func (d *imageReader) bytes(n int) []byte {
+	if d.bad || n < 0 || n > len(d.data)-d.pos {
+		d.bad = true
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n : d.pos+n]
+	d.pos += n
+	d.pos += (8 - d.pos%8) % 8
+	if d.pos > len(d.data) {
+		d.bad = true
+	}
+	return b
+}
+
+func (d *imageReader) int32s(n int) []int32 {
+	if n < 0 || n > (len(d.data)-d.pos)/4 {
+		d.bad = true
+		return nil
+	}
+	b := d.bytes(4 * n)
+	if d.bad || n == 0 {
+		return nil
+	}
+	if d.inPlace {
+		if v := aliasInt32s(b); v != nil {
+			return v
+		}
+	}
+	v := make([]int32, n)
+	for i := range v {
+		v[i] = int32(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+// loadImage decodes an image. With inPlace set the matcher may alias data,
+// which must then stay unchanged and mapped for the matcher's lifetime.
+func loadImage(data []byte, inPlace bool, opts ...Option) (*ACKS, error) {
+	le := binary.LittleEndian
+	if len(data) < dbHeaderSize+8 || string(data[:8]) != dbMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrCorruptDatabase)
+	}
+	version := le.Uint32(data[hdrVersion:])
+	patternSize := dbPatternSize
+	switch {
+	case version > dbVersion:
+		return nil, fmt.Errorf("%w: version %d", ErrNewerDatabase, version)
+	case version < OldestDatabaseVersion:
+		return nil, fmt.Errorf("%w: version %d", ErrCorruptDatabase, version)
+	case version == 1:
+		patternSize = dbPatternSizeV1
+	}
+	body := len(data) - 8
+	if body%8 != 0 || le.Uint32(data[body:]) != crc32.ChecksumIEEE(data[:body]) || le.Uint32(data[body+4:]) != 0 {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptDatabase)
+	}
+
+	ac := NewACKS(opts...)
+	if inPlace {
+		ac.image = data
+	}
+	field := func(off int) int { return int(le.Uint32(data[off:])) }
+	flags := field(hdrFlags)
+	ac.nibble = flags&dbNibble != 0
+	ac.foldCase = flags&dbFoldCase != 0
+	ac.unusedCode = flags&dbUnusedCode != 0
+	ac.exactCase = flags&dbExactCase != 0
+	ac.classMerged = flags&dbVerifyAll != 0
+	ac.alphabetSize = field(hdrAlphabetSize)
+	ac.stateCount = field(hdrStateCount)
+	ac.denseStates = field(hdrDenseStates)
+	ac.prefixLen = field(hdrPrefixLen)
+	patternCount := field(hdrPatternCount)
+	sparseCount := field(hdrSparseCount)
+	outputCount := field(hdrOutputCount)
+	if ac.alphabetSize < 1 || ac.alphabetSize > 256 || ac.stateCount < 1 ||
+		ac.denseStates < 1 || ac.denseStates > ac.stateCount ||
+		ac.denseStates > body/4/ac.alphabetSize {
+		return nil, fmt.Errorf("%w: bad header", ErrCorruptDatabase)
+	}
+
+	d := &imageReader{data: data[:body], pos: dbHeaderSize, inPlace: inPlace}
+	copy(ac.translateTable[:], d.bytes(256))
+	ac.stateTable = d.int32s(ac.denseStates * ac.alphabetSize)
+	ac.failure = d.int32s(ac.stateCount)
+	ac.depth = d.int32s(ac.stateCount)
+	if ac.denseStates < ac.stateCount {
+		ac.sparseIndex = d.int32s(ac.stateCount - ac.denseStates + 1)
+	}
+	ac.sparseChars = d.bytes(sparseCount)
+	if !inPlace {
+		ac.sparseChars = append([]uint8(nil), ac.sparseChars...)
+	}
+	ac.sparseNext = d.int32s(sparseCount)
+	outputIndex := d.int32s(ac.stateCount + 1)
+	outputs := d.int32s(outputCount)
+	records := d.bytes(patternCount * patternSize)
+	strs := d.bytes(field(hdrStringsSize))
+	if version >= 5 && field(hdrMetaSize) > 0 {
+		var ok bool
+		if ac.meta, ok = parseMetadata(d.bytes(field(hdrMetaSize))); !ok {
+			return nil, fmt.Errorf("%w: metadata", ErrCorruptDatabase)
+		}
+	}
+	if d.bad || d.pos != body {
+		return nil, fmt.Errorf("%w: truncated", ErrCorruptDatabase)
+	}
+
+	for k := 0; k < patternCount; k++ {
+		rec := records[k*patternSize:]
+		str := func(off, n uint32) ([]byte, bool) {
+			if uint64(off)+uint64(n) > uint64(len(strs)) {
+				return nil, false
+			}
+			if inPlace {
+				return strs[off : off+n : off+n], true
+			}
+			return append([]byte(nil), strs[off:off+n]...), true
+		}
+		content, ok1 := str(le.Uint32(rec[16:]), le.Uint32(rec[20:]))
+		source, ok2 := str(le.Uint32(rec[24:]), le.Uint32(rec[28:]))
+		plen := int(le.Uint32(rec[12:]))
+		severity, sampleRate := 0, uint32(0)
+		if patternSize > dbPatternSizeV1 {
+			severity = int(int32(le.Uint32(rec[32:])))
+			sampleRate = le.Uint32(rec[36:])
+		}
+		var classes []CharClass
+		if version >= 4 {
+			off, n := uint64(le.Uint32(rec[40:])), uint64(le.Uint32(rec[44:]))
+			if off+n*dbClassSize > uint64(len(strs)) {
+				return nil, fmt.Errorf("%w: pattern %d", ErrCorruptDatabase, k)
+			}
+			for i := 0; i < int(n); i++ {
+				r := strs[int(off)+i*dbClassSize:]
+				c := CharClass{Offset: int(le.Uint32(r))}
+				for w := range c.Set {
+					c.Set[w] = le.Uint64(r[4+8*w:])
+				}
+				if c.Offset >= len(content) || i > 0 && c.Offset <= classes[i-1].Offset {
+					return nil, fmt.Errorf("%w: pattern %d", ErrCorruptDatabase, k)
+				}
+				classes = append(classes, c)
+			}
+		}
+		if !ok1 || !ok2 || plen > len(content) {
+			return nil, fmt.Errorf("%w: pattern %d", ErrCorruptDatabase, k)
+		}
+		if f := Flag(le.Uint32(rec[8:])); f&^patternFlags != 0 {
+			return nil, fmt.Errorf("%w: pattern %d has flags %#x", ErrNewerDatabase, k, uint(f&^patternFlags))
+		}
+		ac.addCompiled(Pattern{
+			Content:    content,
+			ID:         uint(le.Uint64(rec)),
+			Flags:      Flag(le.Uint32(rec[8:])),
+			Severity:   severity,
+			SampleRate: sampleRate,
+			Source:     string(source),
+			Classes:    classes,
+			plen:       plen,
+		})
+	}
+
+	ac.outputTable = make([][]int, ac.stateCount)
+	for s := range ac.outputTable {
+		lo, hi := outputIndex[s], outputIndex[s+1]
+		if lo < 0 || lo > hi || int(hi) > len(outputs) {
+			return nil, fmt.Errorf("%w: output index", ErrCorruptDatabase)
+		}
+		for _, k := range outputs[lo:hi] {
+			if k < 0 || int(k) >= patternCount {
+				return nil, fmt.Errorf("%w: output pattern", ErrCorruptDatabase)
+			}
+			ac.outputTable[s] = append(ac.outputTable[s], int(k))
+		}
+	}
+	if err := ac.checkTables(); err != nil {
+		return nil, err
+	}
+
+	ac.buildIDIndex()
+	ac.muted = make([]atomic.Uint64, (len(ac.ids)+63)/64)
+	ac.buildStartBytes()
+	ac.buildGramFilter()
+	ac.buildLiterals()
+	ac.buildRareBytes()
+	ac.stateHasOutput = make([]bool, ac.stateCount)
+	for i, out := range ac.outputTable {
+		ac.stateHasOutput[i] = len(out) > 0
+	}
+	ac.buildMinRemain()
+	return ac, nil
+}
+
+// addCompiled appends a pattern taken from a compiled image, bypassing
+// normalization.
+func (ac *ACKS) addCompiled(p Pattern) {
+	p.strlen = len(p.Content)
+	p.indexClasses()
+	ac.patterns = append(ac.patterns, &p)
+	ac.hasSingleMatch = ac.hasSingleMatch || p.Flags&SingleMatch != 0
+	ac.hasCaseless = ac.hasCaseless || p.Flags&Caseless != 0
+	ac.hasClasses = ac.hasClasses || p.sets != nil
+	ac.hasAnchors = ac.hasAnchors || p.Flags&anchorFlags != 0
+	ac.hasSampling = ac.hasSampling || p.SampleRate > 1
+	ac.size = len(ac.patterns)
+	ac.maxID = max(ac.maxID, p.ID)
+	ac.maxPatternLen = max(ac.maxPatternLen, p.strlen)
+}
+
+// checkTables verifies that every state index and code in the loaded tables
+// is in range, so a damaged image cannot make a scan index out of bounds.
+func (ac *ACKS) checkTables() error {
+	n := int32(ac.stateCount)
+	// Nibble mode steps through every state with codes 0-15, so a smaller
+	// alphabet would index past the dense rows.
+	if ac.nibble && ac.alphabetSize != 16 {
+		return fmt.Errorf("%w: nibble alphabet", ErrCorruptDatabase)
+	}
+	for _, c := range ac.translateTable {
+		if int(c) >= ac.alphabetSize {
+			return fmt.Errorf("%w: translate table", ErrCorruptDatabase)
+		}
+	}
+	for _, s := range ac.stateTable {
+		if s < 0 || s >= n {
+			return fmt.Errorf("%w: state table", ErrCorruptDatabase)
+		}
+	}
+	for s, f := range ac.failure {
+		if f < 0 || (s > 0 && int(f) >= s) {
+			return fmt.Errorf("%w: failure links", ErrCorruptDatabase)
+		}
+	}
+	for _, s := range ac.sparseNext {
+		if s < 0 || s >= n {
+			return fmt.Errorf("%w: sparse rows", ErrCorruptDatabase)
+		}
+	}
+	for _, c := range ac.sparseChars {
+		if int(c) >= ac.alphabetSize {
+			return fmt.Errorf("%w: sparse rows", ErrCorruptDatabase)
+		}
+	}
+	for i := 1; i < len(ac.sparseIndex); i++ {
+		if ac.sparseIndex[0] != 0 || ac.sparseIndex[i] < ac.sparseIndex[i-1] ||
+			int(ac.sparseIndex[i]) > len(ac.sparseChars) {
+			return fmt.Errorf("%w: sparse index", ErrCorruptDatabase)
+		}
+	}
+	if len(ac.sparseIndex) > 0 && int(ac.sparseIndex[len(ac.sparseIndex)-1]) != len(ac.sparseChars) {
+		return fmt.Errorf("%w: sparse index", ErrCorruptDatabase)
+	}
+	return nil
+}
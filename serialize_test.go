@@ -0,0 +1,101 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestACKS_WriteToLoad(t *testing.T) {
+	words := []string{"he", "she", "his", "hers", "HERS", "usher"}
+	for _, opts := range [][]Option{nil, {WithDenseStates(3)}, {WithNibbleAlphabet()}, {WithLongPatternPrefix(2)}} {
+		ac := buildWords(words, opts...)
+		ac.AddPattern(Pattern{Content: []byte("Ship"), ID: 40, Flags: Caseless | SingleMatch, Source: "x.txt:4"})
+		if err := ac.Build(); err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		var buf bytes.Buffer
+		n, err := ac.WriteTo(&buf)
+		if err != nil || n != int64(buf.Len()) || n%8 != 0 {
+			t.Fatalf("WriteTo = %d, %v", n, err)
+		}
+		loaded, err := Load(&buf)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if !Equal(ac, loaded) {
+			t.Errorf("Expected loaded matcher to equal the original")
+		}
+		text := []byte("she sells ushers a SHIP, ship, hers")
+		want := ac.FindN(text, -1)
+		got := loaded.FindN(text, -1)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	var buf bytes.Buffer
+	buildWords([]string{"needle"}).WriteTo(&buf)
+	fsys := fstest.MapFS{"keywords.db": {Data: buf.Bytes()}}
+	ac, err := LoadFS(fsys, "keywords.db")
+	if err != nil {
+		t.Fatalf("LoadFS failed: %v", err)
+	}
+	if ids, _ := ac.Search([]byte("haystack needle")); !reflect.DeepEqual(ids, []uint{1}) {
+		t.Errorf("Expected [1], got %v", ids)
+	}
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	buildWords([]string{"he", "she", "his", "hers"}).WriteTo(&buf)
+	image := buf.Bytes()
+	for i := 0; i < len(image); i++ {
+		damaged := bytes.Clone(image)
+		damaged[i] ^= 0x40
+		if _, err := Load(bytes.NewReader(damaged)); err == nil {
+			t.Fatalf("Expected an error with byte %d damaged", i)
+		}
+	}
+	if _, err := Load(bytes.NewReader(image[:len(image)-8])); !errors.Is(err, ErrCorruptDatabase) {
+		t.Errorf("Expected ErrCorruptDatabase for a truncated image, got %v", err)
+	}
+}
+
+func TestLoad_UnknownPatternFlags(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("ERROR", 0x1122334455667788, LineAnchoredEnd))
+	ac.Build()
+	var buf bytes.Buffer
+	ac.WriteTo(&buf)
+	image := bytes.Clone(buf.Bytes())
+	rec := bytes.Index(image, binary.LittleEndian.AppendUint64(nil, 0x1122334455667788))
+	if rec < 0 {
+		t.Fatal("Pattern record not found")
+	}
+	binary.LittleEndian.PutUint32(image[rec+8:], uint32(LineAnchoredEnd|1<<20))
+	// downgrade to the current version only recomputes the checksum.
+	if _, err := Load(bytes.NewReader(downgrade(image, DatabaseVersion))); !errors.Is(err, ErrNewerDatabase) {
+		t.Errorf("Expected ErrNewerDatabase for an unknown flag, got %v", err)
+	}
+}
+
+func TestCheckTables_NibbleAlphabet(t *testing.T) {
+	ac := buildWords([]string{"he", "she"}, WithNibbleAlphabet())
+	if err := ac.checkTables(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// A header claiming fewer codes than a nibble must be rejected even
+	// when every table entry is in range.
+	ac.alphabetSize = 8
+	clear(ac.translateTable[:])
+	clear(ac.sparseChars)
+	if err := ac.checkTables(); !errors.Is(err, ErrCorruptDatabase) {
+		t.Errorf("Expected ErrCorruptDatabase for a nibble image with 8 codes, got %v", err)
+	}
+}
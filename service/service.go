@@ -0,0 +1,208 @@
+// Package service provides a ready-made HTTP scanning service around an
+// ahocorasick matcher, for deploying it as a sidecar.
+//
+// Endpoints:
+//
+//	POST /scan              scan the request body
+//	POST /scan?object=REF   scan the object REF from the configured resolver
+//	GET  /healthz           report whether a database is loaded
+//
+// Matches are streamed back as newline-delimited JSON objects
+// {"id":1,"from":10,"to":15}, flushed as they are found, followed by a final
+// {"done":true,"bytes":N} line or {"error":"..."} if the scan failed part way.
+// Input is scanned in chunks, so memory use does not depend on its size.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+// ObjectResolver opens the objects named in scan requests.
+type ObjectResolver interface {
+	Open(ctx context.Context, ref string) (io.ReadCloser, error)
+}
+
+type fsResolver struct{ fsys fs.FS }
+
+// FSResolver resolves object references as paths in fsys, such as
+// os.DirFS("/data"). Paths escaping fsys are rejected by fs.ValidPath.
+func FSResolver(fsys fs.FS) ObjectResolver {
+	return fsResolver{fsys}
+}
+
+func (r fsResolver) Open(_ context.Context, ref string) (io.ReadCloser, error) {
+	return r.fsys.Open(ref)
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithResolver enables scanning objects by reference.
+func WithResolver(r ObjectResolver) Option {
+	return func(s *Server) {
+		s.resolver = r
+	}
+}
+
+// WithChunkSize sets the size of the chunks input is scanned in.
+// The default is 64 KiB.
+func WithChunkSize(n int) Option {
+	return func(s *Server) {
+		s.chunkSize = n
+	}
+}
+
+// Server is an http.Handler serving scan requests against the current
+// database. The database can be replaced at any time with Swap; scans
+// already running finish with the one they started with.
+type Server struct {
+	db        atomic.Pointer[ahocorasick.ACKS]
+	resolver  ObjectResolver
+	chunkSize int
+	mux       *http.ServeMux
+}
+
+// New returns a Server scanning with db, which may be nil until the first
+// Swap.
+func New(db *ahocorasick.ACKS, opts ...Option) *Server {
+	s := &Server{chunkSize: 64 * 1024, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if db != nil {
+		s.db.Store(db)
+	}
+	s.mux.HandleFunc("/scan", s.handleScan)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	return s
+}
+
+// Swap installs db for subsequent requests.
+func (s *Server) Swap(db *ahocorasick.ACKS) {
+	s.db.Store(db)
+}
+
+// LoadFile loads a database written by ACKS.WriteTo and installs it.
+func (s *Server) LoadFile(path string, opts ...ahocorasick.Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	db, err := ahocorasick.Load(f, opts...)
+	if err != nil {
+		return err
+	}
+	s.Swap(db)
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if s.db.Load() == nil {
+		http.Error(w, "no database loaded", http.StatusServiceUnavailable)
+		return
+	}
+	io.WriteString(w, "ok\n")
+}
+
+type matchLine struct {
+	ID   uint   `json:"id"`
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+type doneLine struct {
+	Done  bool   `json:"done,omitempty"`
+	Bytes int64  `json:"bytes,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	db := s.db.Load()
+	if db == nil {
+		http.Error(w, "no database loaded", http.StatusServiceUnavailable)
+		return
+	}
+	in := io.Reader(r.Body)
+	if ref := r.URL.Query().Get("object"); ref != "" {
+		if s.resolver == nil {
+			http.Error(w, "object references are not enabled", http.StatusBadRequest)
+			return
+		}
+		obj, err := s.resolver.Open(r.Context(), ref)
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "object not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer obj.Close()
+		in = obj
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	n, err := s.scan(r.Context(), db, in, func(id uint, from, to uint64) error {
+		if err := enc.Encode(matchLine{ID: id, From: from, To: to}); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		enc.Encode(doneLine{Error: err.Error()})
+		return
+	}
+	enc.Encode(doneLine{Done: true, Bytes: n})
+}
+
+// scan reads in in chunks and scans them as one stream.
+func (s *Server) scan(ctx context.Context, db *ahocorasick.ACKS, in io.Reader, emit func(id uint, from, to uint64) error) (int64, error) {
+	st := db.NewStream()
+	h := func(_ uint64, id uint, from, to uint64) error {
+		return emit(id, from, to)
+	}
+	buf := make([]byte, s.chunkSize)
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := in.Read(buf)
+		if n > 0 {
+			total += int64(n)
+			if err := db.ScanStreamID(st, buf[:n], h); err != nil {
+				return total, err
+			}
+		}
+		if err == io.EOF {
+			return total, db.FinishStreamID(st, h)
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
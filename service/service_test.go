@@ -0,0 +1,87 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yanlinLiu0424/ahocorasick"
+)
+
+func newDB(t *testing.T, words ...string) *ahocorasick.ACKS {
+	t.Helper()
+	ac := ahocorasick.NewACKS()
+	for i, w := range words {
+		ac.AddPattern(ahocorasick.Pattern{Content: []byte(w), ID: uint(i + 1)})
+	}
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return ac
+}
+
+func TestServer_Scan(t *testing.T) {
+	fsys := fstest.MapFS{"logs/a.txt": {Data: []byte("a secret token")}}
+	srv := httptest.NewServer(New(newDB(t, "secret", "token"), WithResolver(FSResolver(fsys)), WithChunkSize(4)))
+	defer srv.Close()
+
+	tests := []struct {
+		url, body, want string
+	}{
+		{"/scan", "my secret", "{\"id\":1,\"from\":3,\"to\":9}\n{\"done\":true,\"bytes\":9}\n"},
+		{"/scan?object=logs/a.txt", "", "{\"id\":1,\"from\":2,\"to\":8}\n{\"id\":2,\"from\":9,\"to\":14}\n{\"done\":true,\"bytes\":14}\n"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(srv.URL+tt.url, "application/octet-stream", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != tt.want {
+			t.Errorf("%s: Expected %q, got %q", tt.url, tt.want, got)
+		}
+	}
+
+	resp, _ := http.Post(srv.URL+"/scan?object=missing", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing object, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	s := New(nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a database, got %d", rec.Code)
+	}
+	s.Swap(newDB(t, "x"))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+}
+
+func TestServer_ScanLineEndAtEOF(t *testing.T) {
+	ac := ahocorasick.NewACKS()
+	ac.AddPattern(ahocorasick.Pattern{Content: []byte("cat"), ID: 1, Flags: ahocorasick.LineAnchoredEnd})
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	srv := httptest.NewServer(New(ac, WithChunkSize(4)))
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/scan", "application/octet-stream", strings.NewReader("a cat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if want := "{\"id\":1,\"from\":2,\"to\":5}\n{\"done\":true,\"bytes\":5}\n"; string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
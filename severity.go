@@ -0,0 +1,32 @@
+package ahocorasick
+
+import "sort"
+
+// ScanBySeverity scans text, buffering its matches, and then calls h with
+// them ordered by pattern Severity, most severe first. Matches of equal
+// severity keep their scan order. An action engine that only acts on the
+// top finding can stop after the first call by returning an error, which is
+// then returned.
+func (ac *ACKS) ScanBySeverity(text []byte, h PatternHandler) error {
+	type found struct {
+		pat      *Pattern
+		from, to uint64
+	}
+	var matches []found
+	err := ac.searchPatterns(text, &handler{fn: func(from, to uint64, ps *Pattern) error {
+		matches = append(matches, found{ps, from, to})
+		return nil
+	}})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].pat.Severity > matches[j].pat.Severity
+	})
+	for _, m := range matches {
+		if err := h(m.pat, m.from, m.to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
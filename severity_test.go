@@ -0,0 +1,45 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_ScanBySeverity(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("info"), ID: 1})
+	ac.AddPattern(Pattern{Content: []byte("warn"), ID: 2, Severity: 5})
+	ac.AddPattern(Pattern{Content: []byte("crit"), ID: 3, Severity: 9})
+	ac.Build()
+
+	text := []byte("info warn crit info warn")
+	var got []uint
+	err := ac.ScanBySeverity(text, func(p *Pattern, from, to uint64) error {
+		got = append(got, p.ID)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(got, []uint{3, 2, 2, 1, 1}) {
+		t.Errorf("Expected [3 2 2 1 1], got %v, %v", got, err)
+	}
+
+	// Stop after the top finding.
+	stop := errors.New("acted")
+	var top *Pattern
+	err = ac.ScanBySeverity(text, func(p *Pattern, from, to uint64) error {
+		top = p
+		return stop
+	})
+	if err != stop || top.ID != 3 {
+		t.Errorf("Expected to stop at pattern 3, got %v, %v", top, err)
+	}
+
+	// Severity survives serialization.
+	var buf bytes.Buffer
+	ac.WriteTo(&buf)
+	loaded, err := Load(&buf)
+	if err != nil || loaded.patterns[2].Severity != 9 {
+		t.Errorf("Expected severity 9 after Load, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+//go:build linux || darwin || freebsd
+
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Shared databases let many processes scan with one copy of the tables.
+// A publisher writes each generation of the database to its own file in a
+// directory, ideally on a tmpfs such as /dev/shm, and then bumps a
+// generation counter in a small control file. Workers map the current
+// generation read-only, so the kernel shares its pages between them, and
+// pick up a new generation on their next Acquire.
+//
+// The control file "<name>.ctl" holds the magic "ACKSCTL\x00" followed by
+// the generation as a native-endian uint64. Generation n is stored in
+// "<name>.<n>.db". Only one publisher may write to a directory at a time.
+
+const ctlMagic = "ACKSCTL\x00"
+
+// mapControl maps the control file of name in dir, creating it if needed,
+// and returns the mapping and the generation counter inside it.
+func mapControl(dir, name string, create bool) ([]byte, *uint64, error) {
+	path := filepath.Join(dir, name+".ctl")
+	flag := os.O_RDWR
+	if create {
+		flag |= os.O_CREATE
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 && create {
+		if _, err := f.Write(append([]byte(ctlMagic), make([]byte, 8)...)); err != nil {
+			return nil, nil, err
+		}
+	} else if fi.Size() != 16 {
+		return nil, nil, fmt.Errorf("ahocorasick: %s is not a control file", path)
+	}
+	ctl, err := syscall.Mmap(int(f.Fd()), 0, 16, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	if string(ctl[:8]) != ctlMagic {
+		syscall.Munmap(ctl)
+		return nil, nil, fmt.Errorf("ahocorasick: %s is not a control file", path)
+	}
+	return ctl, (*uint64)(unsafe.Pointer(&ctl[8])), nil
+}
+
+func generationPath(dir, name string, gen uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.%d.db", name, gen))
+}
+
+// PublishShared writes ac as the next generation of the shared database
+// name in dir and returns its generation number. Files of generations
+// older than the previous one are removed; workers that still map them
+// keep their pages until they release them.
+func PublishShared(dir, name string, ac *ACKS) (uint64, error) {
+	ctl, genp, err := mapControl(dir, name, true)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Munmap(ctl)
+
+	gen := atomic.LoadUint64(genp) + 1
+	path := generationPath(dir, name, gen)
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
+	if err != nil {
+		return 0, err
+	}
+	_, err = ac.WriteTo(tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	atomic.StoreUint64(genp, gen)
+	if gen > 2 {
+		os.Remove(generationPath(dir, name, gen-2))
+	}
+	return gen, nil
+}
+
+// SharedDB attaches to a database published with PublishShared. It is safe
+// for concurrent use.
+type SharedDB struct {
+	dir, name string
+	opts      []Option
+	ctl       []byte
+	gen       *uint64
+
+	mu     sync.Mutex
+	cur    *mapping
+	closed bool
+}
+
+// mapping is one generation mapped into this process.
+type mapping struct {
+	gen  uint64
+	data []byte
+	ac   *ACKS
+	refs int
+}
+
+// OpenShared attaches to the shared database name in dir. Options are
+// passed to the loader, as with Load.
+func OpenShared(dir, name string, opts ...Option) (*SharedDB, error) {
+	ctl, gen, err := mapControl(dir, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedDB{dir: dir, name: name, opts: opts, ctl: ctl, gen: gen}, nil
+}
+
+// Generation returns the generation currently published.
+func (s *SharedDB) Generation() uint64 {
+	return atomic.LoadUint64(s.gen)
+}
+
+// Acquire returns the matcher of the current generation, mapping it if it
+// changed since the last call. The matcher reads the shared mapping
+// directly and must not be used after release is called.
+func (s *SharedDB) Acquire() (ac *ACKS, release func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, errors.New("ahocorasick: shared database is closed")
+	}
+	for tries := 0; s.cur == nil || s.cur.gen != s.Generation(); tries++ {
+		gen := s.Generation()
+		if gen == 0 {
+			return nil, nil, errors.New("ahocorasick: no shared database published yet")
+		}
+		m, err := s.mapGeneration(gen)
+		if errors.Is(err, os.ErrNotExist) && tries < 3 {
+			// Superseded and removed between reading the counter and
+			// opening the file; read the counter again.
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		old := s.cur
+		s.cur = m
+		m.refs++ // held by s.cur
+		if old != nil {
+			s.unref(old)
+		}
+	}
+	m := s.cur
+	m.refs++
+	var once sync.Once
+	return m.ac, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.unref(m)
+			s.mu.Unlock()
+		})
+	}, nil
+}
+
+func (s *SharedDB) mapGeneration(gen uint64) (*mapping, error) {
+	f, err := os.Open(generationPath(s.dir, s.name, gen))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	ac, err := loadImage(data, true, s.opts...)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	return &mapping{gen: gen, data: data, ac: ac}, nil
+}
+
+// unref drops a reference to m and unmaps it when none remain.
+func (s *SharedDB) unref(m *mapping) {
+	m.refs--
+	if m.refs == 0 {
+		syscall.Munmap(m.data)
+		m.data, m.ac = nil, nil
+	}
+}
+
+// Close detaches from the shared database. Matchers still acquired stay
+// valid until released.
+func (s *SharedDB) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.cur != nil {
+		s.unref(s.cur)
+		s.cur = nil
+	}
+	return syscall.Munmap(s.ctl)
+}
@@ -0,0 +1,70 @@
+//go:build linux || darwin || freebsd
+
+package ahocorasick
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestSharedDB_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	if gen, err := PublishShared(dir, "kw", buildWords([]string{"alpha", "beta"})); err != nil || gen != 1 {
+		t.Fatalf("PublishShared = %d, %v", gen, err)
+	}
+	db, err := OpenShared(dir, "kw")
+	if err != nil {
+		t.Fatalf("OpenShared failed: %v", err)
+	}
+	defer db.Close()
+
+	ac, release, err := db.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if ids, _ := ac.Search([]byte("beta alpha")); !reflect.DeepEqual(ids, []uint{2, 1}) {
+		t.Errorf("Expected [2 1], got %v", ids)
+	}
+
+	// Publish twice while the first generation is still held.
+	PublishShared(dir, "kw", buildWords([]string{"gamma"}))
+	PublishShared(dir, "kw", buildWords([]string{"delta"}))
+	if _, err := os.Stat(filepath.Join(dir, "kw.1.db")); !os.IsNotExist(err) {
+		t.Errorf("Expected generation 1 to be removed, got %v", err)
+	}
+	if ids, _ := ac.Search([]byte("alpha")); !reflect.DeepEqual(ids, []uint{1}) {
+		t.Errorf("Expected the held generation to keep working, got %v", ids)
+	}
+	release()
+
+	ac, release, err = db.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+	if db.Generation() != 3 {
+		t.Errorf("Expected generation 3, got %d", db.Generation())
+	}
+	if ids, _ := ac.Search([]byte("alpha delta")); !reflect.DeepEqual(ids, []uint{1}) {
+		t.Errorf("Expected [1] from the delta generation, got %v", ids)
+	}
+}
+
+func TestLoadImage_InPlace(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers"}, WithDenseStates(3))
+	image := ac.appendImage(nil)
+	loaded, err := loadImage(image, true)
+	if err != nil {
+		t.Fatalf("loadImage failed: %v", err)
+	}
+	if !Equal(ac, loaded) {
+		t.Errorf("Expected the aliased matcher to equal the original")
+	}
+	table := &image[dbHeaderSize+256]
+	if nativeLittleEndian && unsafe.Pointer(&loaded.stateTable[0]) != unsafe.Pointer(table) {
+		t.Errorf("Expected the state table to alias the image")
+	}
+}
@@ -0,0 +1,71 @@
+package ahocorasick
+
+// StartBytes returns the set of bytes a match can begin with, for callers
+// that run their own prefilter: a buffer without any of these bytes contains
+// no match. It applies to text after normalizers have run.
+func (ac *ACKS) StartBytes() [256]bool {
+	var set [256]bool
+	for _, p := range ac.patterns {
+		if len(p.Content) == 0 {
+			continue
+		}
+		for _, b := range ac.caseForms(p, 0) {
+			set[b] = true
+		}
+	}
+	return set
+}
+
+// PairSet is a bitmap over two-byte sequences.
+type PairSet [1 << 16 / 64]uint64
+
+// Has reports whether the pair a, b is in the set.
+func (s *PairSet) Has(a, b byte) bool {
+	i := uint(a)<<8 | uint(b)
+	return s[i/64]&(1<<(i%64)) != 0
+}
+
+func (s *PairSet) add(a, b byte) {
+	i := uint(a)<<8 | uint(b)
+	s[i/64] |= 1 << (i % 64)
+}
+
+// StartPairs returns the set of two-byte sequences a match can begin with.
+// A one-byte pattern contributes every pair starting with its byte; it can
+// also match as the very last byte of a buffer, which no pair covers.
+func (ac *ACKS) StartPairs() *PairSet {
+	set := new(PairSet)
+	for _, p := range ac.patterns {
+		switch len(p.Content) {
+		case 0:
+		case 1:
+			for _, a := range ac.caseForms(p, 0) {
+				for b := 0; b < 256; b++ {
+					set.add(a, byte(b))
+				}
+			}
+		default:
+			for _, a := range ac.caseForms(p, 0) {
+				for _, b := range ac.caseForms(p, 1) {
+					set.add(a, b)
+				}
+			}
+		}
+	}
+	return set
+}
+
+// caseForms returns the bytes that can match p.Content[i]: the bytes of a
+// class, both cases of a letter in a Caseless pattern, the byte itself
+// otherwise.
+func (ac *ACKS) caseForms(p *Pattern, i int) []byte {
+	if set := p.classAt(i); set != nil {
+		return set.appendBytes(nil)
+	}
+	b := p.Content[i]
+	l := toLower(b)
+	if p.Flags&Caseless != 0 && l >= 'a' && l <= 'z' {
+		return []byte{l, l - 32}
+	}
+	return []byte{b}
+}
@@ -0,0 +1,31 @@
+package ahocorasick
+
+import "testing"
+
+func TestACKS_StartBytes(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("foo", 1, 0))
+	ac.AddPattern(mkPat("Bar", 2, Caseless))
+	ac.AddPattern(mkPat("z", 3, 0))
+	ac.Build()
+
+	set := ac.StartBytes()
+	for b := 0; b < 256; b++ {
+		want := b == 'f' || b == 'b' || b == 'B' || b == 'z'
+		if set[b] != want {
+			t.Errorf("StartBytes()[%q] = %v, want %v", b, set[b], want)
+		}
+	}
+
+	pairs := ac.StartPairs()
+	for _, p := range []string{"fo", "ba", "BA", "bA", "z\x00", "zz"} {
+		if !pairs.Has(p[0], p[1]) {
+			t.Errorf("Expected pair %q", p)
+		}
+	}
+	for _, p := range []string{"Fo", "fO", "bb", "ab"} {
+		if pairs.Has(p[0], p[1]) {
+			t.Errorf("Unexpected pair %q", p)
+		}
+	}
+}
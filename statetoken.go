@@ -0,0 +1,99 @@
+package ahocorasick
+
+import (
+	"errors"
+)
+
+// StateToken is an opaque snapshot of the automaton position between two
+// buffers: the state, the offset reached, the few bytes needed to verify
+// matches that started earlier, and any long pattern tails awaiting more
+// input. It is much smaller than a StreamState and can be saved and resumed
+// at will, for custom chunking, checkpointing or speculative scanning. The
+// zero token is the start of an input. SingleMatch, dedup and sampling
+// bookkeeping are not carried over.
+type StateToken struct {
+	state   int32
+	offset  uint64 // input bytes consumed
+	base    uint64 // normalized bytes consumed
+	history []byte
+	pending []pendingTail
+}
+
+// Offset returns the number of input bytes consumed before the token.
+func (t StateToken) Offset() uint64 {
+	return t.offset
+}
+
+var errForeignToken = errors.New("ahocorasick: state token does not belong to this matcher")
+
+// SaveState returns a token for the current position of the stream.
+func (st *StreamState) SaveState() StateToken {
+	ss := &st.ss
+	n := int(st.ac.depth[ss.state])
+	if st.ac.nibble {
+		n /= 2
+	}
+	if st.ac.hasAnchors {
+		n++ // the byte before a match
+	}
+	n = min(n, len(ss.history))
+	return StateToken{
+		state:   int32(ss.state),
+		offset:  st.offset,
+		base:    ss.base,
+		history: append([]byte(nil), ss.history[len(ss.history)-n:]...),
+		pending: append([]pendingTail(nil), ss.pending...),
+	}
+}
+
+// ResumeFrom resets the stream and positions it at tok, which must have
+// been saved from a stream of the same matcher. The stream ID is kept.
+func (st *StreamState) ResumeFrom(tok StateToken) error {
+	if int(tok.state) < 0 || int(tok.state) >= st.ac.stateCount || len(tok.history) > st.ac.StreamHistorySize() {
+		return errForeignToken
+	}
+	id := st.id
+	st.Reset()
+	st.id = id
+	st.ss.state = int(tok.state)
+	st.offset, st.ss.base = tok.offset, tok.base
+	st.ss.history = append(st.ss.history[:0], tok.history...)
+	st.ss.pending = append(st.ss.pending[:0], tok.pending...)
+	return nil
+}
+
+// ScanFrom scans text as the continuation of the input tok was saved from,
+// like Scan, and returns the token for the end of text. Offsets are
+// relative to the start of the whole input. Matches at the end of text that
+// depend on the next byte are held in the token; pass the last token to
+// FinishFrom to report them at the end of the input.
+func (ac *ACKS) ScanFrom(tok StateToken, text []byte, m MatchedHandler) (StateToken, error) {
+	st, err := ac.tokenStream(tok)
+	if err != nil {
+		return tok, err
+	}
+	err = ac.scanStream(st, text, &handler{scan: m})
+	return st.SaveState(), err
+}
+
+// FinishFrom ends the input tok was saved from, reporting the matches that
+// waited for the byte after its end, as FinishStream does.
+func (ac *ACKS) FinishFrom(tok StateToken, m MatchedHandler) error {
+	st, err := ac.tokenStream(tok)
+	if err != nil {
+		return err
+	}
+	return ac.FinishStream(st, m)
+}
+
+// tokenStream returns a temporary stream positioned at tok.
+func (ac *ACKS) tokenStream(tok StateToken) (*StreamState, error) {
+	st := &StreamState{ac: ac}
+	st.ss.history = make([]byte, 0, ac.StreamHistorySize())
+	st.ss.record = ac.newMatchRecord()
+	st.ss.stream = true
+	if err := st.ResumeFrom(tok); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
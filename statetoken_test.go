@@ -0,0 +1,72 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestACKS_ScanFrom(t *testing.T) {
+	text := []byte("ushers say HELLO to his hers, she said Hello")
+	for _, opts := range [][]Option{nil, {WithLongPatternPrefix(2)}, {WithNibbleAlphabet()}, {WithByteClasses(" ,")}} {
+		ac := buildWords([]string{"he", "she", "his", "hers", "s s", "said"}, opts...)
+		ac.AddPattern(mkPat("hello", 9, Caseless))
+		ac.Build()
+		collect := func(out *[]string) MatchedHandler {
+			return func(id uint, from, to uint64) error {
+				*out = append(*out, fmt.Sprint(id, to))
+				return nil
+			}
+		}
+		var want []string
+		ac.Scan(text, collect(&want))
+
+		for cut := 0; cut <= len(text); cut++ {
+			var got []string
+			tok, err := ac.ScanFrom(StateToken{}, text[:cut], collect(&got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tok.Offset() != uint64(cut) {
+				t.Fatalf("Expected offset %d, got %d", cut, tok.Offset())
+			}
+			// Resuming twice from the same token gives the same matches.
+			var again []string
+			ac.ScanFrom(tok, text[cut:], collect(&again))
+			if _, err := ac.ScanFrom(tok, text[cut:], collect(&got)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) || !slices.Equal(again, got[len(got)-len(again):]) {
+				t.Fatalf("%v cut at %d: Expected %v, got %v", opts, cut, want, got)
+			}
+		}
+	}
+}
+
+func TestStreamState_SaveState(t *testing.T) {
+	ac := buildWords([]string{"abcd", "cd"})
+	st := ac.NewStream()
+	st.SetID(5)
+	ac.ScanStream(st, []byte("xab"), func(id uint, from, to uint64) error { return nil })
+	tok := st.SaveState()
+
+	var got []uint64
+	m := func(id uint, from, to uint64) error {
+		got = append(got, to)
+		return nil
+	}
+	ac.ScanStream(st, []byte("cd"), m)
+	if err := st.ResumeFrom(tok); err != nil {
+		t.Fatal(err)
+	}
+	ac.ScanStream(st, []byte("cd"), m)
+	if want := []uint64{5, 5, 5, 5}; !reflect.DeepEqual(got, want) || st.ID() != 5 {
+		t.Errorf("Expected %v, got %v (id %d)", want, got, st.ID())
+	}
+
+	other := buildWords([]string{"a"})
+	if err := other.NewStream().ResumeFrom(tok); err == nil {
+		t.Errorf("Expected error for a token of another matcher")
+	}
+}
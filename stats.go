@@ -0,0 +1,40 @@
+package ahocorasick
+
+// Stats describes the size of a built matcher.
+type Stats struct {
+	Patterns     int
+	States       int
+	DenseStates  int // states with a full transition row
+	AlphabetSize int
+	// AlphabetRatio is AlphabetSize relative to the 256 byte values. Near 1,
+	// alphabet compression had no effect and the dense rows are as large as
+	// uncompressed ones.
+	AlphabetRatio float64
+	// TableBytes is the memory held by the automaton tables, excluding the
+	// pattern contents.
+	TableBytes int
+	// PatternBytes is the total length of the pattern contents.
+	PatternBytes int
+}
+
+// Stats returns the size of the built matcher.
+func (ac *ACKS) Stats() Stats {
+	s := Stats{
+		Patterns:     len(ac.patterns),
+		States:       ac.stateCount,
+		DenseStates:  ac.denseStates,
+		AlphabetSize: ac.alphabetSize,
+	}
+	if !ac.nibble {
+		s.AlphabetRatio = float64(ac.alphabetSize) / 256
+	}
+	s.TableBytes = 4*(len(ac.stateTable)+len(ac.failure)+len(ac.depth)+len(ac.minRemain)+len(ac.sparseIndex)+len(ac.sparseNext)) +
+		len(ac.sparseChars) + len(ac.stateHasOutput) + 24*len(ac.outputTable)
+	for _, out := range ac.outputTable {
+		s.TableBytes += 8 * len(out)
+	}
+	for _, p := range ac.patterns {
+		s.PatternBytes += len(p.Content)
+	}
+	return s
+}
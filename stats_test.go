@@ -0,0 +1,14 @@
+package ahocorasick
+
+import "testing"
+
+func TestACKS_Stats(t *testing.T) {
+	s := buildWords([]string{"he", "she", "his", "hers"}, WithDenseStates(4)).Stats()
+	if s.Patterns != 4 || s.States != 10 || s.DenseStates != 4 || s.AlphabetSize != 6 || s.PatternBytes != 12 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	dense := buildWords([]string{"he", "she", "his", "hers"}).Stats()
+	if s.TableBytes >= dense.TableBytes {
+		t.Errorf("Expected sparse rows to be smaller: %d vs %d", s.TableBytes, dense.TableBytes)
+	}
+}
@@ -0,0 +1,171 @@
+package ahocorasick
+
+// StreamState holds the position of one stream being scanned in chunks with
+// ScanStream. Matches that span chunk boundaries are reported normally, with
+// offsets relative to the start of the stream.
+//
+// A StreamState retains at most MaxPatternLen()-1 bytes of history,
+// regardless of how much data passes through it. The buffer is allocated
+// once by NewStream and never grows.
+type StreamState struct {
+	ac     *ACKS
+	ss     scanState
+	offset uint64
+	id     uint64
+}
+
+// StreamHandler receives the matches of ScanStreamID along with the ID of
+// the stream they were found in, so one handler can serve many streams.
+type StreamHandler func(streamID uint64, id uint, from, to uint64) error
+
+// StreamStats summarizes the activity of a stream since it was created or
+// last reset.
+type StreamStats struct {
+	BytesScanned uint64 // bytes passed to ScanStream
+	Matches      uint64 // matches reported to the handler
+	MaxDepth     int    // longest partial match seen, in bytes
+}
+
+// Stats returns the statistics accumulated by the stream.
+func (st *StreamState) Stats() StreamStats {
+	depth := int(st.ss.maxDepth)
+	if st.ac.nibble {
+		depth /= 2
+	}
+	return StreamStats{
+		BytesScanned: st.offset,
+		Matches:      st.ss.matches,
+		MaxDepth:     depth,
+	}
+}
+
+// NewStream returns a StreamState positioned at the start of a new stream.
+// The matcher must be built before streams are created.
+func (ac *ACKS) NewStream() *StreamState {
+	st := &StreamState{ac: ac}
+	st.ss.history = make([]byte, 0, ac.StreamHistorySize())
+	ac.sizeStreamState(&st.ss)
+	st.ss.observe = true
+	st.ss.stream = true
+	return st
+}
+
+// sizeStreamState allocates the per-ID state of a stream for ac up front,
+// so that the memory of the stream is known when it is created.
+func (ac *ACKS) sizeStreamState(ss *scanState) {
+	ss.record = ac.newMatchRecord()
+	ss.lastEnd, ss.hits, ss.rules = nil, nil, nil
+	if ac.dedupWindow > 0 {
+		ss.lastEnd = make([]uint64, len(ac.ids))
+	}
+	if ac.hasSampling && !ac.sampleRandom {
+		ss.hits = make([]uint32, len(ac.ids))
+	}
+}
+
+// MaxPatternLen returns the length of the longest pattern, after
+// normalization.
+func (ac *ACKS) MaxPatternLen() int {
+	return ac.maxPatternLen
+}
+
+// StreamHistorySize returns the number of bytes of history a StreamState
+// keeps between chunks, MaxPatternLen()-1, or MaxPatternLen() with line
+// anchors or WordBoundary, which look at the byte before a match. This is the worst-case
+// per-stream buffer memory, independent of the amount of data scanned.
+func (ac *ACKS) StreamHistorySize() int {
+	if ac.maxPatternLen == 0 {
+		return 0
+	}
+	if ac.hasAnchors {
+		return ac.maxPatternLen
+	}
+	return ac.maxPatternLen - 1
+}
+
+// SetID sets the caller-defined ID passed to a StreamHandler, such as a
+// connection number.
+func (st *StreamState) SetID(id uint64) {
+	st.id = id
+}
+
+// ID returns the stream ID set by SetID.
+func (st *StreamState) ID() uint64 {
+	return st.id
+}
+
+// Reset rewinds the stream to its initial state so it can be reused for a new
+// stream without allocating. The stream ID is cleared.
+func (st *StreamState) Reset() {
+	st.ss.reset()
+	st.offset = 0
+	st.id = 0
+}
+
+// ScanStream scans the next chunk of the stream st.
+func (ac *ACKS) ScanStream(st *StreamState, data []byte, m MatchedHandler) error {
+	return ac.scanStream(st, data, &handler{scan: m})
+}
+
+// ScanStreamID scans the next chunk of the stream st like ScanStream, and
+// calls h with the stream ID and the start and end offset of each match.
+func (ac *ACKS) ScanStreamID(st *StreamState, data []byte, h StreamHandler) error {
+	return ac.scanStream(st, data, &handler{stream: h, streamID: st.id})
+}
+
+// FinishStream ends the stream st and reports the matches that were waiting
+// for the byte after the last chunk: LineAnchoredEnd and WordBoundary
+// matches at the very end of the stream, which ends a line and a word as
+// the end of the text does for Scan. st must be Reset before it is scanned
+// again.
+func (ac *ACKS) FinishStream(st *StreamState, m MatchedHandler) error {
+	return ac.finishStream(st, &handler{scan: m})
+}
+
+// FinishStreamID ends the stream st like FinishStream, reporting to h as
+// ScanStreamID does.
+func (ac *ACKS) FinishStreamID(st *StreamState, h StreamHandler) error {
+	return ac.finishStream(st, &handler{stream: h, streamID: st.id})
+}
+
+func (ac *ACKS) finishStream(st *StreamState, h *handler) error {
+	if len(ac.normalizers) > 0 {
+		// Only the end of the last chunk is needed to map the matches back.
+		h.offsets = []int{0}
+		h.base, h.normBase = st.offset, st.ss.base
+	}
+	err := ac.flushPending(&st.ss, h)
+	st.ss.pending = st.ss.pending[:0]
+	return err
+}
+
+func (ac *ACKS) scanStream(st *StreamState, data []byte, h *handler) error {
+	text := data
+	if len(ac.normalizers) > 0 {
+		// Normalization is applied per chunk; sequences that span a chunk
+		// boundary are not normalized.
+		text, h.offsets = ac.normalize(data)
+		h.base, h.normBase = st.offset, st.ss.base
+	}
+	err := ac.searchText(&st.ss, text, h)
+	st.remember(text)
+	st.ss.base += uint64(len(text))
+	st.offset += uint64(len(data))
+	return err
+}
+
+// remember appends text to the history, keeping only its last cap bytes.
+func (st *StreamState) remember(text []byte) {
+	h := st.ss.history
+	n := cap(h)
+	if len(text) >= n {
+		st.ss.history = append(h[:0], text[len(text)-n:]...)
+		return
+	}
+	keep := n - len(text)
+	if keep > len(h) {
+		keep = len(h)
+	}
+	copy(h, h[len(h)-keep:])
+	st.ss.history = append(h[:keep], text...)
+}
@@ -0,0 +1,59 @@
+package ahocorasick
+
+import (
+	"sync"
+)
+
+// StreamPool recycles StreamState objects between streams, so servers that
+// open a stream per connection do not allocate fresh state for each one.
+// States are pooled by history capacity, which lets matchers with the same
+// MaxPatternLen share them. A StreamPool is safe for concurrent use.
+type StreamPool struct {
+	mu    sync.RWMutex
+	pools map[int]*sync.Pool
+}
+
+func NewStreamPool() *StreamPool {
+	return &StreamPool{
+		pools: make(map[int]*sync.Pool),
+	}
+}
+
+func (p *StreamPool) pool(capacity int) *sync.Pool {
+	p.mu.RLock()
+	sp, ok := p.pools[capacity]
+	p.mu.RUnlock()
+	if ok {
+		return sp
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if sp, ok = p.pools[capacity]; !ok {
+		sp = &sync.Pool{}
+		p.pools[capacity] = sp
+	}
+	return sp
+}
+
+// Get returns a StreamState for ac positioned at the start of a new stream.
+func (p *StreamPool) Get(ac *ACKS) *StreamState {
+	v := p.pool(ac.StreamHistorySize()).Get()
+	if v == nil {
+		return ac.NewStream()
+	}
+	st := v.(*StreamState)
+	if st.ac != ac {
+		// The SingleMatch record, the dedup window and the sampling
+		// counters are sized for the matcher they were made for.
+		st.ac = ac
+		ac.sizeStreamState(&st.ss)
+	}
+	st.Reset()
+	return st
+}
+
+// Put returns st to the pool. st must not be used afterwards.
+func (p *StreamPool) Put(st *StreamState) {
+	p.pool(cap(st.ss.history)).Put(st)
+}
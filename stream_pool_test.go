@@ -0,0 +1,105 @@
+package ahocorasick
+
+import (
+	"testing"
+)
+
+func TestStreamPool_Reuse(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("abc", 1, SingleMatch))
+	ac.Build()
+
+	pool := NewStreamPool()
+	count := 0
+	h := func(id uint, from, to uint64) error {
+		count++
+		return nil
+	}
+
+	st := pool.Get(ac)
+	ac.ScanStream(st, []byte("xxab"), h)
+	pool.Put(st)
+
+	// A recycled state must not carry the partial match or the SingleMatch
+	// record of the previous stream.
+	for i := 0; i < 3; i++ {
+		st = pool.Get(ac)
+		ac.ScanStream(st, []byte("c abc"), h)
+		pool.Put(st)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 matches, got %d", count)
+	}
+
+	other := NewACKS()
+	other.AddPattern(mkPat("xyz", 500, SingleMatch))
+	other.AddPattern(mkPat("abcd", 2, 0))
+	other.Build()
+	st = pool.Get(other)
+	if st.ac != other || cap(st.ss.history) != 3 {
+		t.Fatalf("Unexpected stream state for other matcher")
+	}
+	if err := other.ScanStream(st, []byte("xyz"), h); err != nil {
+		t.Fatalf("ScanStream failed: %v", err)
+	}
+}
+
+func TestStreamPool_SwapDedupWindow(t *testing.T) {
+	small := NewACKS(WithDedupWindow(10))
+	small.AddPattern(mkPat("abc", 1, 0))
+	small.Build()
+	large := NewACKS(WithDedupWindow(10))
+	large.AddPattern(mkPat("abx", 1, 0))
+	large.AddPattern(mkPat("aby", 2, 0))
+	large.AddPattern(mkPat("abz", 3, 0))
+	large.Build()
+
+	pool := NewStreamPool()
+	count := 0
+	h := func(id uint, from, to uint64) error {
+		count++
+		return nil
+	}
+	for _, ac := range []*ACKS{small, large, small, large} {
+		st := pool.Get(ac)
+		if err := ac.ScanStream(st, []byte("abc abx aby abz"), h); err != nil {
+			t.Fatalf("ScanStream failed: %v", err)
+		}
+		pool.Put(st)
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 matches, got %d", count)
+	}
+}
+
+func TestStreamPool_SwapSampling(t *testing.T) {
+	small := NewACKS()
+	sampled := mkPat("abc", 1, 0)
+	sampled.SampleRate = 2
+	small.AddPattern(sampled)
+	small.Build()
+	large := NewACKS()
+	large.AddPattern(mkPat("abx", 1, 0))
+	large.AddPattern(mkPat("aby", 2, 0))
+	sampled = mkPat("abz", 3, 0)
+	sampled.SampleRate = 2
+	large.AddPattern(sampled)
+	large.Build()
+
+	pool := NewStreamPool()
+	count := 0
+	h := func(id uint, from, to uint64) error {
+		count++
+		return nil
+	}
+	for _, ac := range []*ACKS{small, large, small, large} {
+		st := pool.Get(ac)
+		if err := ac.ScanStream(st, []byte("abc abc abx aby abz abz"), h); err != nil {
+			t.Fatalf("ScanStream failed: %v", err)
+		}
+		pool.Put(st)
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 matches, got %d", count)
+	}
+}
@@ -0,0 +1,198 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestACKS_ScanStream_AcrossChunks(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("hello", 1, 0))
+	ac.AddPattern(mkPat("WORLD", 2, Caseless))
+	ac.AddPattern(mkPat("once", 3, SingleMatch))
+	ac.Build()
+
+	if ac.StreamHistorySize() != 4 {
+		t.Fatalf("Expected history size 4, got %d", ac.StreamHistorySize())
+	}
+
+	text := "say hello world, Hello once once"
+	var want [][2]uint64
+	ac.Scan([]byte(text), func(id uint, from, to uint64) error {
+		want = append(want, [2]uint64{uint64(id), to})
+		return nil
+	})
+
+	for chunk := 1; chunk <= len(text); chunk++ {
+		st := ac.NewStream()
+		var got [][2]uint64
+		h := func(id uint, from, to uint64) error {
+			got = append(got, [2]uint64{uint64(id), to})
+			return nil
+		}
+		for i := 0; i < len(text); i += chunk {
+			end := min(i+chunk, len(text))
+			if err := ac.ScanStream(st, []byte(text[i:end]), h); err != nil {
+				t.Fatalf("ScanStream failed: %v", err)
+			}
+			if cap(st.ss.history) != ac.StreamHistorySize() {
+				t.Fatalf("History grew to %d", cap(st.ss.history))
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunk %d: Expected %v, got %v", chunk, want, got)
+		}
+	}
+}
+
+func TestStreamState_Reset(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("abc", 1, SingleMatch))
+	ac.Build()
+
+	st := ac.NewStream()
+	count := 0
+	h := func(id uint, from, to uint64) error {
+		count++
+		return nil
+	}
+	ac.ScanStream(st, []byte("ab"), h)
+	st.Reset()
+	ac.ScanStream(st, []byte("c abc"), h)
+	st.Reset()
+	ac.ScanStream(st, []byte("abc"), h)
+	if count != 2 {
+		t.Errorf("Expected 2 matches, got %d", count)
+	}
+}
+
+func TestStreamState_Stats(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("abcdef", 1, 0))
+	ac.AddPattern(mkPat("ab", 2, 0))
+	ac.Build()
+
+	st := ac.NewStream()
+	ac.ScanStream(st, []byte("xxabc"), nil)
+	ac.ScanStream(st, []byte("dxab"), nil)
+
+	expected := StreamStats{BytesScanned: 9, Matches: 2, MaxDepth: 4}
+	if st.Stats() != expected {
+		t.Errorf("Expected %+v, got %+v", expected, st.Stats())
+	}
+
+	st.Reset()
+	if st.Stats() != (StreamStats{}) {
+		t.Errorf("Expected zero stats after Reset, got %+v", st.Stats())
+	}
+}
+
+func TestACKS_ScanStreamID(t *testing.T) {
+	ac := buildWords([]string{"hello", "world"})
+	type hit struct {
+		stream   uint64
+		id       uint
+		from, to uint64
+	}
+	var got []hit
+	h := StreamHandler(func(streamID uint64, id uint, from, to uint64) error {
+		got = append(got, hit{streamID, id, from, to})
+		return nil
+	})
+	a, b := ac.NewStream(), ac.NewStream()
+	a.SetID(7)
+	b.SetID(9)
+	ac.ScanStreamID(a, []byte("hel"), h)
+	ac.ScanStreamID(b, []byte("xwor"), h)
+	ac.ScanStreamID(a, []byte("lo"), h)
+	ac.ScanStreamID(b, []byte("ld"), h)
+	want := []hit{{7, 1, 0, 5}, {9, 2, 1, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	noop := StreamHandler(func(uint64, uint, uint64, uint64) error { return nil })
+	data := []byte("say hello world")
+	allocs := testing.AllocsPerRun(100, func() {
+		ac.ScanStreamID(a, data, noop)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestACKS_FinishStream(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("cat", 1, LineAnchoredEnd))
+	ac.AddPattern(mkPat("dog", 2, WordBoundary))
+	ac.AddPattern(mkPat("a", 3, 0))
+	ac.Build()
+	text := []byte("a cat\nhot dog a cat")
+
+	var want []uint64
+	ac.Scan(text, func(id uint, from, to uint64) error {
+		want = append(want, to*10+uint64(id))
+		return nil
+	})
+	if len(want) == 0 || want[len(want)-1] != 191 {
+		t.Fatalf("Expected Scan to report cat at the end, got %v", want)
+	}
+	var got []uint64
+	collect := func(id uint, from, to uint64) error {
+		got = append(got, to*10+uint64(id))
+		return nil
+	}
+	check := func(name string) {
+		t.Helper()
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Expected %v, got %v", name, want, got)
+		}
+		got = nil
+	}
+
+	for cut := 0; cut <= len(text); cut++ {
+		st := ac.NewStream()
+		ac.ScanStream(st, text[:cut], collect)
+		ac.ScanStream(st, text[cut:], collect)
+		if err := ac.FinishStream(st, collect); err != nil {
+			t.Fatalf("FinishStream failed: %v", err)
+		}
+		check(fmt.Sprintf("stream cut at %d", cut))
+
+		tok, _ := ac.ScanFrom(StateToken{}, text[:cut], collect)
+		tok, _ = ac.ScanFrom(tok, text[cut:], collect)
+		if err := ac.FinishFrom(tok, collect); err != nil {
+			t.Fatalf("FinishFrom failed: %v", err)
+		}
+		check(fmt.Sprintf("tokens cut at %d", cut))
+	}
+
+	path := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(path, text, 0o644)
+	if err := ac.ScanFile(path, collect, FileChunkSize(4)); err != nil {
+		t.Fatalf("ScanFile failed: %v", err)
+	}
+	check("ScanFile")
+	var ckpt bytes.Buffer
+	if err := ac.ScanFileResumable(path, &ckpt, collect, FileChunkSize(4)); err != nil {
+		t.Fatalf("ScanFileResumable failed: %v", err)
+	}
+	check("ScanFileResumable")
+
+	// Adjacent ranges report every match once, including those decided by
+	// the byte after a range.
+	for size := int64(1); size <= int64(len(text)); size++ {
+		for off := int64(0); off < int64(len(text)); off += size {
+			if err := ac.ScanRange(bytes.NewReader(text), off, min(size, int64(len(text))-off), collect); err != nil {
+				t.Fatalf("ScanRange failed: %v", err)
+			}
+		}
+		check(fmt.Sprintf("ranges of %d", size))
+	}
+}
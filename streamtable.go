@@ -0,0 +1,127 @@
+package ahocorasick
+
+import (
+	"container/list"
+	"time"
+	"unsafe"
+)
+
+// StreamTableOptions bounds a StreamTable. Zero fields impose no limit.
+type StreamTableOptions[K comparable] struct {
+	MaxEntries  int           // streams kept at once
+	MaxMemory   int64         // total of StreamState.Memory over all streams
+	IdleTimeout time.Duration // streams unused for longer are dropped
+	// OnEvict, if set, is called with every stream dropped to respect the
+	// limits, for example to log the connection as abandoned. It is not
+	// called by Delete.
+	OnEvict func(key K, st *StreamState)
+}
+
+// StreamTable keeps the StreamState of many concurrent streams under
+// caller-chosen keys, dropping the least recently used ones when it would
+// exceed its limits, so long-running scanners cannot leak state for dead
+// connections. States are recycled through a StreamPool. A StreamTable is
+// not safe for concurrent use.
+type StreamTable[K comparable] struct {
+	ac      *ACKS
+	opts    StreamTableOptions[K]
+	pool    *StreamPool
+	lru     *list.List // of *tableEntry[K], most recent first
+	entries map[K]*list.Element
+	memory  int64
+}
+
+type tableEntry[K comparable] struct {
+	key  K
+	st   *StreamState
+	used time.Time
+	mem  int64
+}
+
+// NewStreamTable returns an empty table of streams scanned with ac.
+func NewStreamTable[K comparable](ac *ACKS, opts StreamTableOptions[K]) *StreamTable[K] {
+	return &StreamTable[K]{
+		ac:      ac,
+		opts:    opts,
+		pool:    NewStreamPool(),
+		lru:     list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// Get returns the stream for key, creating it if needed, and marks it as
+// used now. Streams idle past the timeout are dropped first.
+func (t *StreamTable[K]) Get(key K) *StreamState {
+	now := time.Now()
+	t.Expire(now)
+	if e, ok := t.entries[key]; ok {
+		te := e.Value.(*tableEntry[K])
+		te.used = now
+		t.lru.MoveToFront(e)
+		return te.st
+	}
+	st := t.pool.Get(t.ac)
+	te := &tableEntry[K]{key: key, st: st, used: now, mem: int64(st.Memory())}
+	for t.lru.Len() > 0 && (t.opts.MaxEntries > 0 && t.lru.Len() >= t.opts.MaxEntries ||
+		t.opts.MaxMemory > 0 && t.memory+te.mem > t.opts.MaxMemory) {
+		t.evict(t.lru.Back())
+	}
+	t.entries[key] = t.lru.PushFront(te)
+	t.memory += te.mem
+	return st
+}
+
+// Delete drops the stream for key, if any, for example when its connection
+// closes. The StreamState must not be used afterwards.
+func (t *StreamTable[K]) Delete(key K) {
+	if e, ok := t.entries[key]; ok {
+		t.remove(e)
+	}
+}
+
+// Expire drops the streams unused since before now minus the idle timeout
+// and returns how many it dropped. Get calls it, but a table that is rarely
+// used should also call it periodically.
+func (t *StreamTable[K]) Expire(now time.Time) int {
+	if t.opts.IdleTimeout <= 0 {
+		return 0
+	}
+	n := 0
+	for e := t.lru.Back(); e != nil && now.Sub(e.Value.(*tableEntry[K]).used) > t.opts.IdleTimeout; e = t.lru.Back() {
+		t.evict(e)
+		n++
+	}
+	return n
+}
+
+// Len returns the number of streams in the table.
+func (t *StreamTable[K]) Len() int {
+	return t.lru.Len()
+}
+
+// Memory returns the estimated memory held by the streams in the table.
+func (t *StreamTable[K]) Memory() int64 {
+	return t.memory
+}
+
+func (t *StreamTable[K]) evict(e *list.Element) {
+	te := e.Value.(*tableEntry[K])
+	if t.opts.OnEvict != nil {
+		t.opts.OnEvict(te.key, te.st)
+	}
+	t.remove(e)
+}
+
+func (t *StreamTable[K]) remove(e *list.Element) {
+	te := t.lru.Remove(e).(*tableEntry[K])
+	delete(t.entries, te.key)
+	t.memory -= te.mem
+	t.pool.Put(te.st)
+}
+
+// Memory returns an estimate of the bytes held by st, which is fixed when
+// the stream is created apart from rare long-pattern tails.
+func (st *StreamState) Memory() int {
+	return int(unsafe.Sizeof(*st)) + cap(st.ss.history) + 8*cap(st.ss.record.bits) +
+		8*cap(st.ss.lastEnd) + 4*cap(st.ss.hits)
+}
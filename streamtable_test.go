@@ -0,0 +1,70 @@
+package ahocorasick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamTable(t *testing.T) {
+	ac := buildWords([]string{"password"})
+	var evicted []string
+	tbl := NewStreamTable(ac, StreamTableOptions[string]{
+		MaxEntries:  2,
+		IdleTimeout: time.Minute,
+		OnEvict:     func(key string, st *StreamState) { evicted = append(evicted, key) },
+	})
+
+	n := 0
+	count := func(id uint, from, to uint64) error {
+		n++
+		return nil
+	}
+	ac.ScanStream(tbl.Get("a"), []byte("pass"), count)
+	ac.ScanStream(tbl.Get("b"), []byte("xx"), count)
+	ac.ScanStream(tbl.Get("a"), []byte("word"), count)
+	if n != 1 {
+		t.Errorf("Expected the match to span chunks of the same key, got %d", n)
+	}
+
+	tbl.Get("c") // evicts b, the least recently used
+	if tbl.Len() != 2 || len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("Unexpected eviction %v with %d entries", evicted, tbl.Len())
+	}
+	if tbl.Memory() != int64(2*tbl.Get("a").Memory()) {
+		t.Errorf("Unexpected memory %d", tbl.Memory())
+	}
+
+	tbl.Delete("c")
+	if got := tbl.Expire(time.Now().Add(2 * time.Minute)); got != 1 || tbl.Len() != 0 || tbl.Memory() != 0 {
+		t.Errorf("Expected a to expire, dropped %d, %d left", got, tbl.Len())
+	}
+
+	mem := NewStreamTable(ac, StreamTableOptions[int]{MaxMemory: int64(ac.NewStream().Memory()) * 3})
+	for i := 0; i < 10; i++ {
+		mem.Get(i)
+	}
+	if mem.Len() != 3 {
+		t.Errorf("Expected the memory cap to keep 3 streams, got %d", mem.Len())
+	}
+}
+
+func TestStreamTable_MemoryAfterScan(t *testing.T) {
+	ac := NewACKS(WithDedupWindow(8))
+	sampled := mkPat("abc", 1, 0)
+	sampled.SampleRate = 3
+	ac.AddPattern(sampled)
+	ac.AddPattern(mkPat("xyz", 2, 0))
+	ac.Build()
+
+	tbl := NewStreamTable(ac, StreamTableOptions[int]{})
+	st := tbl.Get(1)
+	before := tbl.Memory()
+	if err := ac.ScanStream(st, []byte("abc xyz abc"), func(uint, uint64, uint64) error { return nil }); err != nil {
+		t.Fatalf("ScanStream failed: %v", err)
+	}
+	// The dedup window and the sampling counters exist before the first
+	// match, so the recorded memory does not fall behind.
+	if got := int64(st.Memory()); got != before || got != tbl.Memory() {
+		t.Errorf("Expected memory %d after the scan, stream holds %d", before, got)
+	}
+}
@@ -0,0 +1,158 @@
+package ahocorasick
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// SweepOptions configures Sweep.
+type SweepOptions struct {
+	// Roots are the files or directories to scan recursively.
+	Roots []string
+	// Include and Exclude are filepath.Match globs tested against both the
+	// file name and the path relative to its root. A file is scanned if it
+	// matches some Include glob (or Include is empty) and no Exclude glob.
+	Include []string
+	Exclude []string
+	// Concurrency is the number of files scanned in parallel; 0 means 1.
+	Concurrency int
+}
+
+// SweepHit summarizes the matches of one pattern in one file.
+type SweepHit struct {
+	ID          uint
+	Count       uint64
+	FirstOffset uint64 // end offset of the first match
+}
+
+// FileSummary is the result of scanning one file in a Sweep.
+type FileSummary struct {
+	Path string
+	Hits []SweepHit // sorted by ID
+	Err  error      // error opening or reading the file
+}
+
+// Sweep walks the roots and scans every selected file with a pool of workers,
+// returning a summary for each file that matched or failed, sorted by path.
+// Cancelling ctx stops the sweep and returns ctx.Err() with the summaries
+// gathered so far.
+func (ac *ACKS) Sweep(ctx context.Context, opts SweepOptions) ([]FileSummary, error) {
+	workers := max(opts.Concurrency, 1)
+	paths := make(chan string)
+	results := make(chan FileSummary)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if s, ok := ac.sweepFile(ctx, path); ok {
+					results <- s
+				}
+			}
+		}()
+	}
+
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(paths)
+		for _, root := range opts.Roots {
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err != nil {
+					if d == nil || !d.IsDir() {
+						results <- FileSummary{Path: path, Err: err}
+					}
+					return nil
+				}
+				if !d.Type().IsRegular() || !opts.selected(root, path) {
+					return nil
+				}
+				select {
+				case paths <- path:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil {
+				walkErr <- err
+				return
+			}
+		}
+		walkErr <- nil
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var summaries []FileSummary
+	for s := range results {
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Path < summaries[j].Path })
+	if err := <-walkErr; err != nil {
+		return summaries, err
+	}
+	return summaries, ctx.Err()
+}
+
+// sweepFile scans one file and reports whether it produced a summary.
+func (ac *ACKS) sweepFile(ctx context.Context, path string) (FileSummary, bool) {
+	hits := make(map[uint]*SweepHit)
+	err := ac.ScanFile(path, func(id uint, from, to uint64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h := hits[id]
+		if h == nil {
+			h = &SweepHit{ID: id, FirstOffset: to}
+			hits[id] = h
+		}
+		h.Count++
+		return nil
+	})
+	if ctx.Err() != nil {
+		return FileSummary{}, false
+	}
+	if err == nil && len(hits) == 0 {
+		return FileSummary{}, false
+	}
+	s := FileSummary{Path: path, Err: err}
+	for _, h := range hits {
+		s.Hits = append(s.Hits, *h)
+	}
+	sort.Slice(s.Hits, func(i, j int) bool { return s.Hits[i].ID < s.Hits[j].ID })
+	return s, true
+}
+
+func (o *SweepOptions) selected(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	name := filepath.Base(path)
+	matchAny := func(globs []string) bool {
+		for _, g := range globs {
+			if ok, _ := filepath.Match(g, name); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(g, rel); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if len(o.Include) > 0 && !matchAny(o.Include) {
+		return false
+	}
+	return !matchAny(o.Exclude)
+}
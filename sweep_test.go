@@ -0,0 +1,55 @@
+package ahocorasick
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestACKS_Sweep(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("secret", 1, 0))
+	ac.AddPattern(mkPat("token", 2, 0))
+	ac.Build()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":        "a secret and a token and a secret",
+		"b.txt":        "nothing here",
+		"sub/c.txt":    "token",
+		"sub/d.log":    "secret",
+		"vendor/e.txt": "secret",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ac.Sweep(context.Background(), SweepOptions{
+		Roots:       []string{dir},
+		Include:     []string{"*.txt"},
+		Exclude:     []string{"vendor/*"},
+		Concurrency: 3,
+	})
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	want := []FileSummary{
+		{Path: filepath.Join(dir, "a.txt"), Hits: []SweepHit{{ID: 1, Count: 2, FirstOffset: 8}, {ID: 2, Count: 1, FirstOffset: 20}}},
+		{Path: filepath.Join(dir, "sub/c.txt"), Hits: []SweepHit{{ID: 2, Count: 1, FirstOffset: 5}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ac.Sweep(ctx, SweepOptions{Roots: []string{dir}}); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
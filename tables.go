@@ -0,0 +1,65 @@
+package ahocorasick
+
+// Tables is a copy of the compiled automaton in a flat layout that stays
+// stable across releases, for scanners written in C, assembly or for a GPU
+// while Go remains the compiler. Every state is given a full row:
+//
+//   - byte b is read as the symbol Translate[b], and state 0 is the start;
+//   - Next[s*Alphabet+c] is the state reached from state s on symbol c;
+//   - the patterns ending in state s are Outputs[OutputIndex[s]:OutputIndex[s+1]],
+//     as indices into IDs, Lengths and Verify;
+//   - a match of pattern i ending before offset end starts at end-Lengths[i].
+//
+// When Verify[i] is set the automaton only proves a candidate: the pattern
+// must still be compared with the text, since letters or byte classes share
+// symbols, only a prefix of it is compiled, or it is line anchored. When
+// Nibble is set Alphabet is 16, Translate is unused, and every byte is read
+// as its high and then low nibble, with outputs checked after the low one.
+// SingleMatch, muting, dedup and sampling are left to the scanner.
+type Tables struct {
+	States      int
+	Alphabet    int
+	Nibble      bool
+	Translate   [256]uint8
+	Next        []int32
+	OutputIndex []uint32
+	Outputs     []uint32
+	IDs         []uint
+	Lengths     []uint32
+	Verify      []bool
+}
+
+// Tables returns the compiled automaton of a built matcher. The result is
+// a copy and may be modified freely.
+func (ac *ACKS) Tables() *Tables {
+	t := &Tables{
+		States:      ac.stateCount,
+		Alphabet:    ac.alphabetSize,
+		Nibble:      ac.nibble,
+		Translate:   ac.TranslateTable(),
+		Next:        make([]int32, ac.stateCount*ac.alphabetSize),
+		OutputIndex: make([]uint32, 0, ac.stateCount+1),
+		IDs:         make([]uint, len(ac.patterns)),
+		Lengths:     make([]uint32, len(ac.patterns)),
+		Verify:      make([]bool, len(ac.patterns)),
+	}
+	copy(t.Next, ac.stateTable)
+	for s := ac.denseStates; s < ac.stateCount; s++ {
+		for c := 0; c < ac.alphabetSize; c++ {
+			t.Next[s*ac.alphabetSize+c] = int32(ac.next(s, uint8(c)))
+		}
+	}
+	for _, out := range ac.outputTable {
+		t.OutputIndex = append(t.OutputIndex, uint32(len(t.Outputs)))
+		for _, k := range out {
+			t.Outputs = append(t.Outputs, uint32(k))
+		}
+	}
+	t.OutputIndex = append(t.OutputIndex, uint32(len(t.Outputs)))
+	for i, p := range ac.patterns {
+		t.IDs[i] = p.ID
+		t.Lengths[i] = uint32(p.strlen)
+		t.Verify[i] = ac.inexact(p) || p.plen < p.strlen || p.Flags&anchorFlags != 0
+	}
+	return t
+}
@@ -0,0 +1,51 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// scanTables is a reference scanner over the exported tables.
+func scanTables(t *Tables, text []byte) []string {
+	var got []string
+	s := int32(0)
+	for i, b := range text {
+		if t.Nibble {
+			s = t.Next[int(s)*16+int(b>>4)]
+			s = t.Next[int(s)*16+int(b&0x0f)]
+		} else {
+			s = t.Next[int(s)*t.Alphabet+int(t.Translate[b])]
+		}
+		for _, k := range t.Outputs[t.OutputIndex[s]:t.OutputIndex[s+1]] {
+			got = append(got, fmt.Sprint(t.IDs[k], i+1))
+		}
+	}
+	return got
+}
+
+func TestACKS_Tables(t *testing.T) {
+	text := []byte("ushers say his hers, she said he")
+	for _, opts := range [][]Option{nil, {WithDenseStates(3)}, {WithNibbleAlphabet(), WithoutCaseFolding()}} {
+		ac := buildWords([]string{"he", "she", "his", "hers", "said"}, opts...)
+		tb := ac.Tables()
+		for _, v := range tb.Verify {
+			if v {
+				t.Fatalf("%v: Expected an exact automaton", opts)
+			}
+		}
+		var want []string
+		ac.Scan(text, func(id uint, from, to uint64) error {
+			want = append(want, fmt.Sprint(id, to))
+			return nil
+		})
+		if got := scanTables(tb, text); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: Expected %v, got %v", opts, want, got)
+		}
+	}
+
+	ac := buildWords([]string{"abcdef"}, WithLongPatternPrefix(3))
+	if tb := ac.Tables(); !tb.Verify[0] || tb.Lengths[0] != 6 {
+		t.Errorf("Expected a long pattern to need verification")
+	}
+}
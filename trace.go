@@ -0,0 +1,208 @@
+package ahocorasick
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TraceOutcome is what became of a candidate pattern reached during a traced
+// scan.
+type TraceOutcome int
+
+const (
+	TraceReported         TraceOutcome = iota // the match was reported
+	TraceCaseMismatch                         // rejected by verification of case or byte classes
+	TraceTailMismatch                         // the tail of a long pattern did not match
+	TraceSingleSuppressed                     // already reported once, SingleMatch suppressed it
+	TraceNotLineStart                         // LineAnchoredStart, but the match does not begin a line
+	TraceNotLineEnd                           // LineAnchoredEnd, but the match does not end a line
+	TraceNotWholeWord                         // WordBoundary, but a word byte precedes or follows the match
+)
+
+var traceOutcomeNames = [...]string{"reported", "case-mismatch", "tail-mismatch", "single-suppressed", "not-line-start", "not-line-end", "not-whole-word"}
+
+func (o TraceOutcome) String() string {
+	if int(o) < len(traceOutcomeNames) {
+		return traceOutcomeNames[o]
+	}
+	return fmt.Sprintf("TraceOutcome(%d)", int(o))
+}
+
+// TraceCandidate is a pattern whose output state was reached.
+type TraceCandidate struct {
+	ID      uint
+	Outcome TraceOutcome
+}
+
+// TraceStep records the automaton consuming one byte.
+type TraceStep struct {
+	Offset   int  // offset of the byte in the scanned text
+	Byte     byte // the byte
+	From, To int  // states before and after the byte
+	// Failures lists the states reached by following failure links before
+	// a goto transition (or the root) was found.
+	Failures   []int
+	Candidates []TraceCandidate
+}
+
+// Trace is the record of a debug scan made by ACKS.Trace.
+type Trace struct {
+	ac    *ACKS
+	text  []byte
+	Steps []TraceStep
+}
+
+// Trace scans text in debug mode, recording the states visited, the failure
+// links followed and the fate of every candidate pattern. Steps that stay at
+// the root are omitted. Normalizers are applied first and offsets refer to
+// the normalized text. Tracing is slow and allocates; it is meant for
+// explaining why a pattern did or did not match.
+func (ac *ACKS) Trace(text []byte) *Trace {
+	if len(ac.normalizers) > 0 {
+		text, _ = ac.normalize(text)
+	}
+	tr := &Trace{ac: ac, text: text}
+	var ss scanState
+	seen := make(map[uint]bool)
+	state := 0
+	for i, b := range text {
+		step := TraceStep{Offset: i, Byte: b, From: state}
+		if ac.nibble {
+			lb := ac.fold(b)
+			state = ac.traceNext(&step, state, lb>>4)
+			state = ac.traceNext(&step, state, lb&0x0f)
+		} else {
+			state = ac.traceNext(&step, state, ac.translateTable[b])
+		}
+		step.To = state
+		for _, k := range ac.outputTable[state] {
+			pat := ac.patterns[k]
+			outcome := TraceReported
+			end := i + 1 + pat.strlen - pat.plen
+			lineEnd, wordEnd := true, true
+			if pat.Flags&LineAnchoredEnd != 0 {
+				lineEnd, _ = ss.atLineEnd(text, end)
+			}
+			if pat.Flags&WordBoundary != 0 {
+				wordEnd, _ = ss.atWordEnd(text, end)
+			}
+			switch {
+			case ac.inexact(pat) && !verify(pat, text, i, nil):
+				outcome = TraceCaseMismatch
+			case pat.Flags&LineAnchoredStart != 0 && !ss.atLineStart(text, i+1-pat.plen):
+				outcome = TraceNotLineStart
+			case pat.Flags&WordBoundary != 0 && !ss.atWordStart(text, i+1-pat.plen):
+				outcome = TraceNotWholeWord
+			case pat.plen < pat.strlen && !equalTail(pat, text, i+1):
+				outcome = TraceTailMismatch
+			case !lineEnd:
+				outcome = TraceNotLineEnd
+			case !wordEnd:
+				outcome = TraceNotWholeWord
+			case pat.Flags&SingleMatch != 0 && seen[pat.ID]:
+				outcome = TraceSingleSuppressed
+			}
+			if outcome == TraceReported {
+				seen[pat.ID] = true
+			}
+			step.Candidates = append(step.Candidates, TraceCandidate{ID: pat.ID, Outcome: outcome})
+		}
+		if step.From != 0 || step.To != 0 {
+			tr.Steps = append(tr.Steps, step)
+		}
+	}
+	return tr
+}
+
+// traceNext makes one transition, recording the failure links the dense
+// table has folded into it. A goto transition always goes one level deeper;
+// any other transition is the one from the failure state.
+func (ac *ACKS) traceNext(step *TraceStep, state int, tc uint8) int {
+	for {
+		next := ac.next(state, tc)
+		if state == 0 || ac.depth[next] == ac.depth[state]+1 {
+			return next
+		}
+		state = int(ac.failure[state])
+		step.Failures = append(step.Failures, state)
+	}
+}
+
+func equalTail(pat *Pattern, text []byte, start int) bool {
+	tail := pat.Content[pat.plen:]
+	return len(text)-start >= len(tail) && equalPattern(pat, pat.plen, text[start:start+len(tail)])
+}
+
+// Dump writes the trace as text, one step per line.
+func (t *Trace) Dump(w io.Writer) error {
+	for _, s := range t.Steps {
+		line := fmt.Sprintf("@%d %q %d->%d", s.Offset, s.Byte, s.From, s.To)
+		if len(s.Failures) > 0 {
+			line += fmt.Sprintf(" fail=%v", s.Failures)
+		}
+		for _, c := range s.Candidates {
+			line += fmt.Sprintf(" id=%d:%s", c.ID, c.Outcome)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Explain describes what happened to pattern id during the trace: the state
+// path to every candidate match and its outcome, or, if the pattern was never
+// reached, the longest prefix of it found in the text.
+func (t *Trace) Explain(id uint) string {
+	var sb strings.Builder
+	for n, s := range t.Steps {
+		for _, c := range s.Candidates {
+			if c.ID != id {
+				continue
+			}
+			fmt.Fprintf(&sb, "pattern %d ending at %d: %s via %s\n", id, s.Offset+1, c.Outcome, t.path(n))
+		}
+	}
+	if sb.Len() > 0 {
+		return sb.String()
+	}
+	best, at := 0, -1
+	for _, p := range t.ac.patterns {
+		if p.ID != id {
+			continue
+		}
+		for i := range t.text {
+			n := 0
+			for n < len(p.Content) && i+n < len(t.text) &&
+				equalPattern(p, n, t.text[i+n:i+n+1]) {
+				n++
+			}
+			if n > best {
+				best, at = n, i
+			}
+		}
+	}
+	if at < 0 {
+		return fmt.Sprintf("pattern %d never reached: no prefix of it occurs in the text\n", id)
+	}
+	return fmt.Sprintf("pattern %d never reached: longest prefix found is %d bytes at offset %d\n", id, best, at)
+}
+
+// path renders the states leading to step n, back to the last time the scan
+// left the root.
+func (t *Trace) path(n int) string {
+	first := n
+	for first > 0 && t.Steps[first].From != 0 && t.Steps[first-1].Offset == t.Steps[first].Offset-1 {
+		first--
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d", t.Steps[first].From)
+	for _, s := range t.Steps[first : n+1] {
+		for _, f := range s.Failures {
+			fmt.Fprintf(&sb, " ~> %d", f)
+		}
+		fmt.Fprintf(&sb, " -%q-> %d", s.Byte, s.To)
+	}
+	return sb.String()
+}
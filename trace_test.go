@@ -0,0 +1,87 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestACKS_Trace(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers"})
+	tr := ac.Trace([]byte("ushers"))
+
+	var reported []uint
+	failures := 0
+	for _, s := range tr.Steps {
+		failures += len(s.Failures)
+		for _, c := range s.Candidates {
+			if c.Outcome == TraceReported {
+				reported = append(reported, c.ID)
+			}
+		}
+	}
+	if !reflect.DeepEqual(reported, []uint{2, 1, 4}) {
+		t.Errorf("Expected [2 1 4], got %v", reported)
+	}
+	if failures == 0 {
+		t.Errorf("Expected the move from \"she\" to \"her\" to follow a failure link")
+	}
+	var buf bytes.Buffer
+	tr.Dump(&buf)
+	if !strings.Contains(buf.String(), "id=4:reported") {
+		t.Errorf("Unexpected dump:\n%s", buf.String())
+	}
+
+	got := tr.Explain(4)
+	if !strings.HasPrefix(got, "pattern 4 ending at 6: reported via 0 -'s'-> ") || !strings.Contains(got, "~>") {
+		t.Errorf("Unexpected explanation %q", got)
+	}
+	if got := tr.Explain(3); got != "pattern 3 never reached: longest prefix found is 1 bytes at offset 2\n" {
+		t.Errorf("Unexpected explanation %q", got)
+	}
+}
+
+func TestACKS_Trace_Rejections(t *testing.T) {
+	ac := NewACKS(WithNibbleAlphabet())
+	ac.AddPattern(mkPat("abc", 1, 0))
+	ac.AddPattern(mkPat("xy", 2, SingleMatch))
+	ac.Build()
+	tr := ac.Trace([]byte("ABC xy xy"))
+	var outcomes []TraceOutcome
+	for _, s := range tr.Steps {
+		for _, c := range s.Candidates {
+			outcomes = append(outcomes, c.Outcome)
+		}
+	}
+	want := []TraceOutcome{TraceCaseMismatch, TraceReported, TraceSingleSuppressed}
+	if !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("Expected %v, got %v", want, outcomes)
+	}
+}
+
+func TestACKS_Trace_Anchors(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(mkPat("ab", 1, LineAnchoredStart))
+	ac.AddPattern(mkPat("cd", 2, LineAnchoredEnd))
+	ac.Build()
+	tr := ac.Trace([]byte("ab xab cdx\ncd"))
+	var outcomes []TraceOutcome
+	for _, s := range tr.Steps {
+		for _, c := range s.Candidates {
+			outcomes = append(outcomes, c.Outcome)
+		}
+	}
+	want := []TraceOutcome{TraceReported, TraceNotLineStart, TraceNotLineEnd, TraceReported}
+	if !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("Expected %v, got %v", want, outcomes)
+	}
+	n := 0
+	ac.Scan([]byte("ab xab cdx\ncd"), func(id uint, from, to uint64) error {
+		n++
+		return nil
+	})
+	if n != 2 {
+		t.Errorf("Expected Scan to agree with the trace, got %d matches", n)
+	}
+}
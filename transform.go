@@ -0,0 +1,34 @@
+package ahocorasick
+
+import "fmt"
+
+// PatternTransform rewrites a pattern into the concrete patterns that are
+// compiled in its place, for instance case variants, encodings or wide
+// strings of one logical rule. Returning no patterns drops it.
+type PatternTransform func(Pattern) ([]Pattern, error)
+
+// WithPatternTransform makes AddPattern pass every pattern through t before
+// it is normalized and compiled. Transforms given by several options run in
+// order, each on every pattern produced by the one before.
+func WithPatternTransform(t PatternTransform) Option {
+	return func(ac *ACKS) {
+		ac.transforms = append(ac.transforms, t)
+	}
+}
+
+// transform applies the pattern transforms to p.
+func (ac *ACKS) transform(p Pattern) ([]Pattern, error) {
+	ps := []Pattern{p}
+	for _, t := range ac.transforms {
+		var next []Pattern
+		for _, q := range ps {
+			out, err := t(q)
+			if err != nil {
+				return nil, fmt.Errorf("ahocorasick: transform pattern %d: %w", q.ID, err)
+			}
+			next = append(next, out...)
+		}
+		ps = next
+	}
+	return ps, nil
+}
@@ -0,0 +1,39 @@
+package ahocorasick
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithPatternTransform(t *testing.T) {
+	wide := func(p Pattern) ([]Pattern, error) {
+		if len(p.Content) == 0 {
+			return nil, errors.New("empty")
+		}
+		w := p
+		w.Content = nil
+		for _, b := range p.Content {
+			w.Content = append(w.Content, b, 0)
+		}
+		return []Pattern{p, w}, nil
+	}
+	drop := func(p Pattern) ([]Pattern, error) {
+		if p.ID == 9 {
+			return nil, nil
+		}
+		return []Pattern{p}, nil
+	}
+	ac := NewACKS(WithPatternTransform(wide), WithPatternTransform(drop))
+	ac.AddPattern(mkPat("cmd", 1, 0))
+	ac.AddPattern(mkPat("skip", 9, 0))
+	ac.Build()
+
+	got, _ := ac.Search([]byte("run c\x00m\x00d\x00 or cmd"))
+	if want := []uint{1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if err := ac.AddPattern(Pattern{ID: 3}); err == nil {
+		t.Errorf("Expected the transform error")
+	}
+}
@@ -0,0 +1,59 @@
+package ahocorasick
+
+// trie is the goto graph used while building the matcher. Children are kept
+// in first-child/next-sibling lists sorted by character code, so Build needs
+// no maps and allocates a few flat slices regardless of the pattern count.
+// This keeps Build usable on TinyGo and WASM targets.
+type trie struct {
+	first []int32 // first child of each state, -1 if none
+	next  []int32 // next sibling of each state, -1 if none
+	code  []uint8 // character code on the edge into each state
+}
+
+func newTrie(capacity int) *trie {
+	capacity = max(capacity, 1)
+	t := &trie{
+		first: make([]int32, 1, capacity),
+		next:  make([]int32, 1, capacity),
+		code:  make([]uint8, 1, capacity),
+	}
+	t.first[0], t.next[0] = -1, -1
+	return t
+}
+
+func (t *trie) len() int {
+	return len(t.first)
+}
+
+// child returns the state reached from s on tc, or -1.
+func (t *trie) child(s int, tc uint8) int {
+	for c := t.first[s]; c >= 0 && t.code[c] <= tc; c = t.next[c] {
+		if t.code[c] == tc {
+			return int(c)
+		}
+	}
+	return -1
+}
+
+// add returns the state reached from s on tc, creating it if needed.
+// The boolean reports whether a new state was created.
+func (t *trie) add(s int, tc uint8) (int, bool) {
+	prev := int32(-1)
+	c := t.first[s]
+	for ; c >= 0 && t.code[c] < tc; c = t.next[c] {
+		prev = c
+	}
+	if c >= 0 && t.code[c] == tc {
+		return int(c), false
+	}
+	n := int32(len(t.first))
+	t.first = append(t.first, -1)
+	t.next = append(t.next, c)
+	t.code = append(t.code, tc)
+	if prev < 0 {
+		t.first[s] = n
+	} else {
+		t.next[prev] = n
+	}
+	return int(n), true
+}
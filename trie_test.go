@@ -0,0 +1,47 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrie_SortedChildren(t *testing.T) {
+	tr := newTrie(0)
+	for _, c := range []uint8{5, 2, 9, 2, 7} {
+		tr.add(0, c)
+	}
+	var codes []uint8
+	for c := tr.first[0]; c >= 0; c = tr.next[c] {
+		codes = append(codes, tr.code[c])
+	}
+	if !reflect.DeepEqual(codes, []uint8{2, 5, 7, 9}) {
+		t.Errorf("Expected sorted children, got %v", codes)
+	}
+	if s := tr.child(0, 7); s < 0 || tr.code[s] != 7 {
+		t.Errorf("Expected a child on 7, got %d", s)
+	}
+	if s := tr.child(0, 3); s != -1 {
+		t.Errorf("Expected no child on 3, got %d", s)
+	}
+}
+
+func TestACKS_SlotOf(t *testing.T) {
+	ac := NewACKS()
+	for i, id := range []uint{30, 10, 30, 20} {
+		ac.AddPattern(mkPat(string(rune('a'+i)), id, 0))
+	}
+	if err := ac.Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !reflect.DeepEqual(ac.ids, []uint{30, 10, 20}) {
+		t.Errorf("Expected slots in order of first appearance, got %v", ac.ids)
+	}
+	for slot, id := range ac.ids {
+		if got, ok := ac.slotOf(id); !ok || got != slot {
+			t.Errorf("slotOf(%d) = %d, %v; want %d", id, got, ok, slot)
+		}
+	}
+	if _, ok := ac.slotOf(15); ok {
+		t.Errorf("Expected unknown ID to have no slot")
+	}
+}
@@ -0,0 +1,80 @@
+package ahocorasick
+
+import "fmt"
+
+// Verify checks the built automaton against its own patterns. The tables must
+// be internally consistent, every pattern must be reported when its content
+// is scanned (in the other case too, for Caseless patterns), and every match
+// reported for those texts and for copies with their last byte altered must
+// really occur in the text. It is meant to catch construction bugs or damaged
+// databases before they are deployed, and costs time proportional to the
+// total pattern length times the output fan-out.
+func (ac *ACKS) Verify() error {
+	if ac.stateCount == 0 {
+		return fmt.Errorf("ahocorasick: verify: matcher is not built")
+	}
+	if err := ac.checkTables(); err != nil {
+		return err
+	}
+	if err := ac.checkDeadColumn(); err != nil {
+		return err
+	}
+
+	bySlot := make([][]*Pattern, len(ac.ids))
+	for _, p := range ac.patterns {
+		bySlot[p.slot] = append(bySlot[p.slot], p)
+	}
+	var text []byte
+	// A raw probe sees every match, whatever muting, SingleMatch, dedup or
+	// sampling would drop, and leaves the counters alone.
+	ss := scanState{record: ac.newMatchRecord(), probe: true, raw: true}
+	check := func(text []byte, want *Pattern) error {
+		found := false
+		var bad error
+		h := handler{fn: func(from, to uint64, ps *Pattern) error {
+			if want != nil && ps == want && to == uint64(len(text)) {
+				found = true
+			}
+			for _, p := range bySlot[ps.slot] {
+				if int(to) >= p.strlen && equalPattern(p, 0, text[int(to)-p.strlen:to]) {
+					return nil
+				}
+			}
+			bad = fmt.Errorf("ahocorasick: verify: pattern %d reported at %d in %q but does not occur there", ps.ID, to, text)
+			return bad
+		}}
+		ss.reset()
+		if err := ac.searchText(&ss, text, &h); err != nil {
+			return err
+		}
+		if want != nil && !found {
+			return fmt.Errorf("ahocorasick: verify: pattern %d not found in its own content %q", want.ID, want.Content)
+		}
+		return nil
+	}
+
+	for _, p := range ac.patterns {
+		if p.strlen == 0 {
+			continue
+		}
+		text = append(text[:0], p.Content...)
+		if err := check(text, p); err != nil {
+			return err
+		}
+		if p.Flags&Caseless != 0 {
+			for i, b := range text {
+				if l := toLower(b); l >= 'a' && l <= 'z' {
+					text[i] = b ^ 0x20
+				}
+			}
+			if err := check(text, p); err != nil {
+				return err
+			}
+		}
+		text[len(text)-1] ^= 0x55
+		if err := check(text, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
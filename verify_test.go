@@ -0,0 +1,53 @@
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestACKS_Verify(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithDenseStates(4)}, {WithNibbleAlphabet()}, {WithLongPatternPrefix(3)}} {
+		ac := buildWords([]string{"he", "she", "his", "hers", "ushers"}, opts...)
+		ac.AddPattern(mkPat("HeLLo", 10, Caseless))
+		ac.AddPattern(mkPat("a", 11, SingleMatch))
+		ac.AddPattern(mkPat("ba", 11, SingleMatch))
+		if err := ac.Build(); err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		if err := ac.Verify(); err != nil {
+			t.Errorf("Verify failed: %v", err)
+		}
+	}
+}
+
+func TestACKS_Verify_Corrupt(t *testing.T) {
+	ac := buildWords([]string{"he", "she", "his", "hers"})
+	// Redirect the root transition on 'h' to the state for "s".
+	ac.stateTable[ac.translateTable['h']] = ac.stateTable[ac.translateTable['s']]
+	err := ac.Verify()
+	if err == nil || !strings.HasPrefix(err.Error(), "ahocorasick: verify:") {
+		t.Errorf("Expected a verify error, got %v", err)
+	}
+	if err := NewACKS().Verify(); err == nil {
+		t.Errorf("Expected an error for an unbuilt matcher")
+	}
+}
+
+func TestACKS_Verify_Filters(t *testing.T) {
+	ac := NewACKS(WithRandomSampling(), WithDedupWindow(100))
+	ac.AddPattern(mkPat("alpha", 1, 0))
+	beta := mkPat("beta", 2, 0)
+	beta.SampleRate = 1000
+	ac.AddPattern(beta)
+	ac.Build()
+	acc := ac.EnableCounters()
+	ac.Mute(1)
+	for i := 0; i < 3; i++ {
+		if err := ac.Verify(); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	}
+	if n := len(acc.Snapshot()); n != 0 {
+		t.Errorf("Expected Verify to leave the counters alone, got %v", acc.Snapshot())
+	}
+}
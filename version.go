@@ -0,0 +1,77 @@
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized format is versioned. Load reads every version from
+// OldestDatabaseVersion up to DatabaseVersion, and support for a version is
+// never dropped, so databases cached by a fleet keep loading across package
+// upgrades. WriteTo always writes DatabaseVersion; LoadAny and
+// MigrateDatabase bring older databases up to date.
+const (
+	// DatabaseVersion is the format version written by WriteTo.
+	DatabaseVersion = dbVersion
+	// OldestDatabaseVersion is the oldest format version Load reads.
+	OldestDatabaseVersion = 1
+)
+
+// ErrNewerDatabase is returned when a database was written by a newer
+// release of the package than the one loading it.
+var ErrNewerDatabase = errors.New("ahocorasick: database format is newer than supported")
+
+// ImageVersion returns the format version of a serialized database without
+// loading it.
+func ImageVersion(image []byte) (int, error) {
+	if len(image) < dbHeaderSize || string(image[:8]) != dbMagic {
+		return 0, fmt.Errorf("%w: bad magic", ErrCorruptDatabase)
+	}
+	return int(binary.LittleEndian.Uint32(image[hdrVersion:])), nil
+}
+
+// LoadAny reads a database of any supported version, like Load, and also
+// returns the version it was written in. The matcher is upgraded in memory:
+// fields missing from older versions take their defaults, and WriteTo writes
+// it back in the current version.
+func LoadAny(r io.Reader, opts ...Option) (*ACKS, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	version, err := ImageVersion(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	ac, err := loadImage(data, false, opts...)
+	if err != nil {
+		return nil, version, err
+	}
+	return ac, version, nil
+}
+
+// MigrateDatabase reads a database of any supported version from src and
+// writes it to dst in the current version, returning the version it was
+// read in. A database already in the current version is copied unchanged.
+func MigrateDatabase(dst io.Writer, src io.Reader) (int, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	version, err := ImageVersion(data)
+	if err != nil {
+		return 0, err
+	}
+	ac, err := loadImage(data, false)
+	if err != nil {
+		return version, err
+	}
+	if version == DatabaseVersion {
+		_, err = dst.Write(data)
+	} else {
+		_, err = ac.WriteTo(dst)
+	}
+	return version, err
+}
@@ -0,0 +1,85 @@
+package ahocorasick
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+// downgrade rewrites a current image without classes or metadata in an
+// older format version.
+func downgrade(image []byte, version int) []byte {
+	le := binary.LittleEndian
+	field := func(off int) int { return int(le.Uint32(image[off:])) }
+	states, dense := field(hdrStateCount), field(hdrDenseStates)
+	d := &imageReader{data: image[:len(image)-8], pos: dbHeaderSize}
+	d.bytes(256)
+	d.int32s(dense * field(hdrAlphabetSize))
+	d.int32s(states)
+	d.int32s(states)
+	if dense < states {
+		d.int32s(states - dense + 1)
+	}
+	d.bytes(field(hdrSparseCount))
+	d.int32s(field(hdrSparseCount))
+	d.int32s(states + 1)
+	d.int32s(field(hdrOutputCount))
+	recStart := d.pos
+	d.bytes(field(hdrPatternCount) * dbPatternSize)
+
+	out := bytes.Clone(image[:recStart])
+	le.PutUint32(out[hdrVersion:], uint32(version))
+	size := dbPatternSize
+	if version == 1 {
+		size = dbPatternSizeV1
+	}
+	for k := 0; k < field(hdrPatternCount); k++ {
+		out = append(out, image[recStart+k*dbPatternSize:][:size]...)
+	}
+	out = pad8(out, 0)
+	out = append(out, image[d.pos:len(image)-8]...)
+	out = le.AppendUint32(out, crc32.ChecksumIEEE(out))
+	return le.AppendUint32(out, 0)
+}
+
+func TestLoadAny(t *testing.T) {
+	ac := NewACKS()
+	ac.AddPattern(Pattern{Content: []byte("hers"), ID: 1, Severity: 3})
+	ac.AddPattern(Pattern{Content: []byte("SHE"), ID: 2, Flags: Caseless})
+	ac.Build()
+	var buf bytes.Buffer
+	ac.WriteTo(&buf)
+	current := buf.Bytes()
+	want, _ := ac.Search([]byte("ushers she"))
+
+	for v := OldestDatabaseVersion; v <= DatabaseVersion; v++ {
+		old := downgrade(current, v)
+		loaded, version, err := LoadAny(bytes.NewReader(old))
+		if err != nil || version != v {
+			t.Fatalf("Version %d: got version %d, %v", v, version, err)
+		}
+		if got, _ := loaded.Search([]byte("ushers she")); !reflect.DeepEqual(got, want) {
+			t.Errorf("Version %d: Expected %v, got %v", v, want, got)
+		}
+
+		var migrated bytes.Buffer
+		if from, err := MigrateDatabase(&migrated, bytes.NewReader(old)); err != nil || from != v {
+			t.Fatalf("Version %d: migrated from %d, %v", v, from, err)
+		}
+		if got, _ := ImageVersion(migrated.Bytes()); got != DatabaseVersion {
+			t.Errorf("Version %d: Expected version %d after migration, got %d", v, DatabaseVersion, got)
+		}
+		// Version 1 has no severities; everything else survives.
+		if v > 1 && !bytes.Equal(migrated.Bytes(), current) {
+			t.Errorf("Version %d: migration changed the database", v)
+		}
+	}
+
+	newer := downgrade(current, DatabaseVersion+1)
+	if _, _, err := LoadAny(bytes.NewReader(newer)); !errors.Is(err, ErrNewerDatabase) {
+		t.Errorf("Expected ErrNewerDatabase, got %v", err)
+	}
+}